@@ -0,0 +1,26 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// mapAccessibility to the JS module.
+func mapAccessibility(vu moduleVU, a *common.Accessibility) mapping {
+	return mapping{
+		"snapshot": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewAccessibilitySnapshotOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing accessibility snapshot options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return a.Snapshot(popts) //nolint:wrapcheck
+			}), nil
+		},
+	}
+}
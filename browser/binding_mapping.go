@@ -0,0 +1,78 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+)
+
+// mapBindingSource to the JS module. Like in Playwright, the context,
+// page and frame of the source are properties rather than functions.
+func mapBindingSource(vu moduleVU, src *common.BindingSource) mapping {
+	return mapping{
+		"context": mapBrowserContext(vu, src.Context),
+		"frame":   mapFrame(vu, src.Frame),
+		"page":    mapPage(vu, src.Page),
+	}
+}
+
+// newBindingFunc returns an exposed binding that calls fn in the task queue
+// of the page that called the binding, and waits for its result. fn is
+// passed the mapped source of the call as the first argument if
+// mapSourceFn is not nil.
+//
+// The binding stops waiting when the iteration ends, or when fn doesn't
+// return in the default timeout of the page, e.g. since the task queue
+// of the page is closed. There is no synchronous version of the binding,
+// since fn can't run while a synchronous call blocks the event loop.
+func newBindingFunc(
+	vu moduleVU, fn sobek.Callable, mapSourceFn func(moduleVU, *common.BindingSource) mapping,
+) common.BindingFunc {
+	return func(src *common.BindingSource, args []any) (any, error) {
+		type result struct {
+			value any
+			err   error
+		}
+		// The channel is buffered, as the result of a promise is sent
+		// from the event loop.
+		c := make(chan result, 1)
+
+		tq := vu.taskQueueRegistry.get(src.Page.TargetID())
+		tq.Queue(func() error {
+			rt := vu.Runtime()
+			jsArgs := make([]sobek.Value, 0, len(args)+1)
+			if mapSourceFn != nil {
+				jsArgs = append(jsArgs, rt.ToValue(mapSourceFn(vu, src)))
+			}
+			for _, arg := range args {
+				jsArgs = append(jsArgs, rt.ToValue(arg))
+			}
+			v, err := fn(sobek.Undefined(), jsArgs...)
+			if err != nil {
+				c <- result{err: fmt.Errorf("executing exposed function: %w", err)}
+				return nil
+			}
+			awaitValue(rt, v, func(value any, err error) {
+				c <- result{value, err}
+			})
+			return nil
+		})
+
+		timeout := src.Page.Timeout()
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+
+		select {
+		case r := <-c:
+			return r.value, r.err
+		case <-vu.Context().Done():
+			return nil, errors.New("executing exposed function: iteration ended")
+		case <-t.C:
+			return nil, fmt.Errorf("executing exposed function: timed out after %s", timeout)
+		}
+	}
+}
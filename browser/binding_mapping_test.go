@@ -0,0 +1,54 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/grafana/sobek"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAwaitValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, js string
+		want     any
+		wantErr  string
+	}{
+		{name: "value", js: `42`, want: int64(42)},
+		{name: "undefined", js: `undefined`, want: nil},
+		{name: "resolved", js: `Promise.resolve({ a: 'b' })`, want: map[string]any{"a": "b"}},
+		{name: "rejected", js: `Promise.reject(new Error('boom'))`, wantErr: "Error: boom"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rt := sobek.New()
+			v, err := rt.RunString(tt.js)
+			require.NoError(t, err)
+
+			var (
+				got    any
+				gotErr error
+				called bool
+			)
+			awaitValue(rt, v, func(value any, err error) {
+				got, gotErr, called = value, err, true
+			})
+			// Run the promise jobs.
+			_, err = rt.RunString(``)
+			require.NoError(t, err)
+
+			require.True(t, called)
+			if tt.wantErr != "" {
+				assert.EqualError(t, gotErr, tt.wantErr)
+				return
+			}
+			require.NoError(t, gotErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -73,6 +73,16 @@ func mapBrowserContext(vu moduleVU, bc *common.BrowserContext) mapping { //nolin
 				return bc.Cookies(urls...) //nolint:wrapcheck
 			})
 		},
+		"exposeBinding": func(name string, fn sobek.Callable) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, bc.ExposeBinding(name, newBindingFunc(vu, fn, mapBindingSource)) //nolint:wrapcheck
+			})
+		},
+		"exposeFunction": func(name string, fn sobek.Callable) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, bc.ExposeBinding(name, newBindingFunc(vu, fn, nil)) //nolint:wrapcheck
+			})
+		},
 		"grantPermissions": func(permissions []string, opts sobek.Value) *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				popts := common.NewGrantPermissionsOptions()
@@ -81,6 +91,27 @@ func mapBrowserContext(vu moduleVU, bc *common.BrowserContext) mapping { //nolin
 				return nil, bc.GrantPermissions(permissions, popts) //nolint:wrapcheck
 			})
 		},
+		"on": mapBrowserContextOn(vu, bc, mapPage),
+		"route": func(url sobek.Value, handler sobek.Callable) (*sobek.Promise, error) {
+			matcher, err := common.NewURLMatcher(vu.Context(), url)
+			if err != nil {
+				return nil, fmt.Errorf("parsing browser context route URL: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, bc.Route(matcher, newRouteHandler(vu, handler, mapRoute)) //nolint:wrapcheck
+			}), nil
+		},
+		"routeFromHAR": func(path string, opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewRouteFromHAROptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing browser context routeFromHAR options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, bc.RouteFromHAR(path, popts) //nolint:wrapcheck
+			}), nil
+		},
 		"setDefaultNavigationTimeout": bc.SetDefaultNavigationTimeout,
 		"setDefaultTimeout":           bc.SetDefaultTimeout,
 		"setGeolocation": func(geolocation sobek.Value) *sobek.Promise {
@@ -98,6 +129,17 @@ func mapBrowserContext(vu moduleVU, bc *common.BrowserContext) mapping { //nolin
 				return nil, bc.SetOffline(offline) //nolint:wrapcheck
 			})
 		},
+		"tracing": mapTracing(vu, bc.Tracing()),
+		"unroute": func(url sobek.Value) (*sobek.Promise, error) {
+			matcher, err := common.NewURLMatcher(vu.Context(), url)
+			if err != nil {
+				return nil, fmt.Errorf("parsing browser context unroute URL: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, bc.Unroute(matcher) //nolint:wrapcheck
+			}), nil
+		},
 		"waitForEvent": func(event string, optsOrPredicate sobek.Value) (*sobek.Promise, error) {
 			ctx := vu.Context()
 			popts := common.NewWaitForEventOptions(
@@ -171,3 +213,25 @@ func mapBrowserContext(vu moduleVU, bc *common.BrowserContext) mapping { //nolin
 		},
 	}
 }
+
+// mapBrowserContextOn maps browserContext.on to the JS module. The event
+// handlers are executed in the task queue of the new page, so that they
+// run on the event loop of the VU.
+func mapBrowserContextOn(
+	vu moduleVU, bc *common.BrowserContext, mapPageFn func(moduleVU, *common.Page) mapping,
+) func(event string, handler sobek.Callable) error {
+	return func(event string, handler sobek.Callable) error {
+		runInTaskQueue := func(p *common.Page) {
+			tq := vu.taskQueueRegistry.get(p.TargetID())
+			tq.Queue(func() error {
+				mp := mapPageFn(vu, p)
+				if _, err := handler(sobek.Undefined(), vu.Runtime().ToValue(mp)); err != nil {
+					return fmt.Errorf("executing browserContext.on handler: %w", err)
+				}
+				return nil
+			})
+		}
+
+		return bc.On(event, runInTaskQueue) //nolint:wrapcheck
+	}
+}
@@ -87,9 +87,65 @@ func mapBrowser(vu moduleVU) mapping { //nolint:funlen,cyclop
 				return mapPage(vu, page), nil
 			})
 		},
+		"startTracing": func(page, opts sobek.Value) (*sobek.Promise, error) {
+			b, err := vu.browser()
+			if err != nil {
+				return nil, err
+			}
+			p, popts, err := parseStartTracingArgs(vu, page, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, b.StartTracing(p, popts) //nolint:wrapcheck
+			}), nil
+		},
+		"stopTracing": func() *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				b, err := vu.browser()
+				if err != nil {
+					return nil, err
+				}
+				bb, err := b.StopTracing(vu.filePersister)
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				if bb == nil {
+					return nil, nil
+				}
+
+				ab := vu.Runtime().NewArrayBuffer(bb)
+
+				return &ab, nil
+			})
+		},
 	}
 }
 
+// parseStartTracingArgs parses the page and the options of startTracing.
+// The page is optional, so the options can be the first argument.
+func parseStartTracingArgs(vu moduleVU, page, opts sobek.Value) (*common.Page, *common.TracingOptions, error) {
+	var p *common.Page
+	if sobekValueExists(page) {
+		var err error
+		if p, err = pageFromValue(vu, page); err != nil {
+			if sobekValueExists(opts) {
+				return nil, nil, fmt.Errorf("parsing tracing page: %w", err)
+			}
+			// The page is omitted.
+			opts = page
+		}
+	}
+
+	popts := common.NewTracingOptions()
+	if err := popts.Parse(vu.Context(), opts); err != nil {
+		return nil, nil, fmt.Errorf("parsing tracing options: %w", err)
+	}
+
+	return p, popts, nil
+}
+
 func initBrowserContext(bctx *common.BrowserContext, testRunID string) error {
 	// Setting a k6 object which will contain k6 specific metadata
 	// on the current test run. This allows external applications
@@ -0,0 +1,56 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// mapCoverage to the JS module.
+func mapCoverage(vu moduleVU, c *common.Coverage) mapping {
+	return mapping{
+		"startJSCoverage": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewJSCoverageOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing JS coverage options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, c.StartJSCoverage(popts) //nolint:wrapcheck
+			}), nil
+		},
+		"stopJSCoverage": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewStopCoverageOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing stop coverage options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return c.StopJSCoverage(popts, vu.filePersister) //nolint:wrapcheck
+			}), nil
+		},
+		"startCSSCoverage": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewCSSCoverageOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing CSS coverage options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, c.StartCSSCoverage(popts) //nolint:wrapcheck
+			}), nil
+		},
+		"stopCSSCoverage": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewStopCoverageOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing stop coverage options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return c.StopCSSCoverage(popts, vu.filePersister) //nolint:wrapcheck
+			}), nil
+		},
+	}
+}
@@ -0,0 +1,27 @@
+package browser
+
+import (
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// mapDialog to the JS module.
+func mapDialog(vu moduleVU, d *common.Dialog) mapping {
+	return mapping{
+		"accept": func(promptText string) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, d.Accept(promptText) //nolint:wrapcheck
+			})
+		},
+		"defaultValue": d.DefaultValue,
+		"dismiss": func() *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, d.Dismiss() //nolint:wrapcheck
+			})
+		},
+		"message": d.Message,
+		"type":    d.Type,
+	}
+}
@@ -0,0 +1,104 @@
+package browser
+
+import (
+	"errors"
+	"io"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// readStreamChunkSize is the default number of bytes read by readStream.read.
+const readStreamChunkSize = 64 * 1024
+
+// mapDownload to the JS module.
+func mapDownload(vu moduleVU, d *common.Download) mapping {
+	return mapping{
+		"createReadStream": func() *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				r, err := d.CreateReadStream()
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				return mapReadStream(vu, r), nil
+			})
+		},
+		"failure": func() *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				f, err := d.Failure()
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				if f == "" {
+					return nil, nil
+				}
+				return f, nil
+			})
+		},
+		"page": func() mapping {
+			return mapPage(vu, d.Page())
+		},
+		"path": func() *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				p, err := d.Path()
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				if p == "" {
+					return nil, nil
+				}
+				return p, nil
+			})
+		},
+		"saveAs": func(path string) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, d.SaveAs(path, vu.filePersister) //nolint:wrapcheck
+			})
+		},
+		"suggestedFilename": d.SuggestedFilename,
+		"url":               d.URL,
+	}
+}
+
+// mapReadStream maps a reader of a file to the JS module. The reader is
+// read in chunks, and read returns null once the whole file is read.
+func mapReadStream(vu moduleVU, r io.ReadCloser) mapping {
+	rt := vu.Runtime()
+	return mapping{
+		"close": func() *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, r.Close() //nolint:wrapcheck
+			})
+		},
+		"read": func(size int64) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				b, err := readChunk(r, size)
+				if err != nil || b == nil {
+					return nil, err
+				}
+				ab := rt.NewArrayBuffer(b)
+				return &ab, nil
+			})
+		},
+	}
+}
+
+// readChunk reads up to size bytes from the reader. It returns nil bytes
+// once the reader is fully read.
+func readChunk(r io.Reader, size int64) ([]byte, error) {
+	if size <= 0 {
+		size = readStreamChunkSize
+	}
+	b := make([]byte, size)
+	n, err := io.ReadFull(r, b)
+	if n == 0 && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		return nil, nil
+	}
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return b[:n], nil
+}
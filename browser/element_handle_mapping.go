@@ -145,6 +145,7 @@ func mapElementHandle(vu moduleVU, eh *common.ElementHandle) mapping { //nolint:
 				return nil, fmt.Errorf("parsing element handle screenshot options: %w", err)
 			}
 
+			vu.callStacks.Capture(vu.Runtime(), "elementHandle.screenshot")
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				bb, err := eh.Screenshot(popts, vu.filePersister)
 				if err != nil {
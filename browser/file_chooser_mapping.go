@@ -0,0 +1,26 @@
+package browser
+
+import (
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// mapFileChooser to the JS module.
+func mapFileChooser(vu moduleVU, fc *common.FileChooser) mapping {
+	return mapping{
+		"element": func() mapping {
+			return mapElementHandle(vu, fc.Element())
+		},
+		"isMultiple": fc.IsMultiple,
+		"page": func() mapping {
+			return mapPage(vu, fc.Page())
+		},
+		"setFiles": func(files sobek.Value, opts sobek.Value) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, fc.SetFiles(files, opts) //nolint:wrapcheck
+			})
+		},
+	}
+}
@@ -14,6 +14,34 @@ import (
 //nolint:funlen
 func mapFrame(vu moduleVU, f *common.Frame) mapping { //nolint:gocognit,cyclop
 	maps := mapping{
+		"addScriptTag": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewFrameAddScriptTagOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing addScriptTag options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				eh, err := f.AddScriptTag(popts)
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				return mapElementHandle(vu, eh), nil
+			}), nil
+		},
+		"addStyleTag": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewFrameAddStyleTagOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing addStyleTag options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				eh, err := f.AddStyleTag(popts)
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				return mapElementHandle(vu, eh), nil
+			}), nil
+		},
 		"check": func(selector string, opts sobek.Value) *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return nil, f.Check(selector, opts) //nolint:wrapcheck
@@ -27,10 +27,21 @@ func mapLocator(vu moduleVU, lo *common.Locator) mapping { //nolint:funlen
 				return nil, err
 			}
 
+			vu.callStacks.Capture(vu.Runtime(), "locator.click")
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return nil, lo.Click(popts) //nolint:wrapcheck
 			}), nil
 		},
+		"compareScreenshot": func(name string, opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewCompareScreenshotOptions(lo.Timeout())
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing compare screenshot options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return lo.CompareScreenshot(name, popts, vu.filePersister) //nolint:wrapcheck
+			}), nil
+		},
 		"dblclick": func(opts sobek.Value) *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return nil, lo.Dblclick(opts) //nolint:wrapcheck
@@ -136,6 +147,7 @@ func mapLocator(vu moduleVU, lo *common.Locator) mapping { //nolint:funlen
 			})
 		},
 		"type": func(text string, opts sobek.Value) *sobek.Promise {
+			vu.callStacks.Capture(vu.Runtime(), "locator.type")
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return nil, lo.Type(text, opts) //nolint:wrapcheck
 			})
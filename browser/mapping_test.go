@@ -2,6 +2,7 @@ package browser
 
 import (
 	"context"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -31,9 +32,11 @@ func customMappings() map[string]string {
 		"elementHandleAPI.query":    "$",
 		"elementHandleAPI.queryAll": "$$",
 		// getters
-		"pageAPI.getKeyboard":    "keyboard",
-		"pageAPI.getMouse":       "mouse",
-		"pageAPI.getTouchscreen": "touchscreen",
+		"pageAPI.getAccessibility": "accessibility",
+		"pageAPI.getCoverage":      "coverage",
+		"pageAPI.getKeyboard":      "keyboard",
+		"pageAPI.getMouse":         "mouse",
+		"pageAPI.getTouchscreen":   "touchscreen",
 		// internal methods
 		"elementHandleAPI.objectID":    "",
 		"frameAPI.id":                  "",
@@ -139,9 +142,11 @@ func TestMappings(t *testing.T) {
 			apiInterface: (*pageAPI)(nil),
 			mapp: func() mapping {
 				return mapPage(moduleVU{VU: vu}, &common.Page{
-					Keyboard:    &common.Keyboard{},
-					Mouse:       &common.Mouse{},
-					Touchscreen: &common.Touchscreen{},
+					Accessibility: &common.Accessibility{},
+					Coverage:      &common.Coverage{},
+					Keyboard:      &common.Keyboard{},
+					Mouse:         &common.Mouse{},
+					Touchscreen:   &common.Touchscreen{},
 				})
 			},
 		},
@@ -175,6 +180,48 @@ func TestMappings(t *testing.T) {
 				return mapResponse(moduleVU{VU: vu}, &common.Response{})
 			},
 		},
+		"mapDialog": {
+			apiInterface: (*dialogAPI)(nil),
+			mapp: func() mapping {
+				return mapDialog(moduleVU{VU: vu}, &common.Dialog{})
+			},
+		},
+		"mapDownload": {
+			apiInterface: (*downloadAPI)(nil),
+			mapp: func() mapping {
+				return mapDownload(moduleVU{VU: vu}, &common.Download{})
+			},
+		},
+		"mapVideo": {
+			apiInterface: (*videoAPI)(nil),
+			mapp: func() mapping {
+				return mapVideo(moduleVU{VU: vu}, &common.Video{})
+			},
+		},
+		"mapFileChooser": {
+			apiInterface: (*fileChooserAPI)(nil),
+			mapp: func() mapping {
+				return mapFileChooser(moduleVU{VU: vu}, &common.FileChooser{})
+			},
+		},
+		"mapPageError": {
+			apiInterface: (*pageErrorAPI)(nil),
+			mapp: func() mapping {
+				return mapPageError(moduleVU{VU: vu}, &common.PageError{})
+			},
+		},
+		"mapReadStream": {
+			apiInterface: (*readStreamAPI)(nil),
+			mapp: func() mapping {
+				return mapReadStream(moduleVU{VU: vu}, io.NopCloser(strings.NewReader("")))
+			},
+		},
+		"mapRoute": {
+			apiInterface: (*routeAPI)(nil),
+			mapp: func() mapping {
+				return mapRoute(moduleVU{VU: vu}, &common.Route{})
+			},
+		},
 		"mapWorker": {
 			apiInterface: (*workerAPI)(nil),
 			mapp: func() mapping {
@@ -199,6 +246,24 @@ func TestMappings(t *testing.T) {
 				return mapTouchscreen(moduleVU{VU: vu}, &common.Touchscreen{})
 			},
 		},
+		"mapAccessibility": {
+			apiInterface: (*accessibilityAPI)(nil),
+			mapp: func() mapping {
+				return mapAccessibility(moduleVU{VU: vu}, &common.Accessibility{})
+			},
+		},
+		"mapCoverage": {
+			apiInterface: (*coverageAPI)(nil),
+			mapp: func() mapping {
+				return mapCoverage(moduleVU{VU: vu}, &common.Coverage{})
+			},
+		},
+		"mapTracing": {
+			apiInterface: (*tracingAPI)(nil),
+			mapp: func() mapping {
+				return mapTracing(moduleVU{VU: vu}, &common.Tracing{})
+			},
+		},
 		"mapKeyboard": {
 			apiInterface: (*keyboardAPI)(nil),
 			mapp: func() mapping {
@@ -229,6 +294,7 @@ func toFirstLetterLower(s string) string {
 		"ID":        "id",
 		"JSON":      "json",
 		"JSONValue": "jsonValue",
+		"PDF":       "pdf",
 		"URL":       "url",
 	}
 	if v, ok := special[s]; ok {
@@ -267,6 +333,8 @@ type browserAPI interface {
 	NewContext(opts sobek.Value) (*common.BrowserContext, error)
 	NewPage(opts sobek.Value) (*common.Page, error)
 	On(string) (bool, error)
+	StartTracing(page *common.Page, opts sobek.Value) error
+	StopTracing() (sobek.ArrayBuffer, error)
 	UserAgent() string
 	Version() string
 }
@@ -280,23 +348,34 @@ type browserContextAPI interface {
 	ClearPermissions() error
 	Close() error
 	Cookies(urls ...string) ([]*common.Cookie, error)
+	ExposeBinding(name string, fn sobek.Callable) error
+	ExposeFunction(name string, fn sobek.Callable) error
 	GrantPermissions(permissions []string, opts sobek.Value) error
 	NewPage() (*common.Page, error)
+	On(event string, handler sobek.Callable) error
 	Pages() []*common.Page
+	Route(url sobek.Value, handler sobek.Callable) error
+	RouteFromHAR(path string, opts sobek.Value) error
 	SetDefaultNavigationTimeout(timeout int64)
 	SetDefaultTimeout(timeout int64)
 	SetGeolocation(geolocation sobek.Value) error
 	SetHTTPCredentials(httpCredentials sobek.Value) error
 	SetOffline(offline bool) error
+	Tracing() *common.Tracing
+	Unroute(url sobek.Value) error
 	WaitForEvent(event string, optsOrPredicate sobek.Value) (any, error)
 }
 
 // pageAPI is the interface of a single browser tab.
 type pageAPI interface {
+	AddScriptTag(opts sobek.Value) (*common.ElementHandle, error)
+	AddStyleTag(opts sobek.Value) (*common.ElementHandle, error)
 	BringToFront() error
 	Check(selector string, opts sobek.Value) error
+	CheckAccessibility(opts sobek.Value) ([]*common.AccessibilityViolation, error)
 	Click(selector string, opts sobek.Value) error
 	Close(opts sobek.Value) error
+	CompareScreenshot(name string, opts sobek.Value) (*common.ScreenshotComparison, error)
 	Content() (string, error)
 	Context() *common.BrowserContext
 	Dblclick(selector string, opts sobek.Value) error
@@ -305,10 +384,14 @@ type pageAPI interface {
 	EmulateVisionDeficiency(typ string) error
 	Evaluate(pageFunc sobek.Value, arg ...sobek.Value) (any, error)
 	EvaluateHandle(pageFunc sobek.Value, arg ...sobek.Value) (common.JSHandleAPI, error)
+	ExposeBinding(name string, fn sobek.Callable) error
+	ExposeFunction(name string, fn sobek.Callable) error
 	Fill(selector string, value string, opts sobek.Value) error
 	Focus(selector string, opts sobek.Value) error
 	Frames() []*common.Frame
 	GetAttribute(selector string, name string, opts sobek.Value) (string, bool, error)
+	GetAccessibility() *common.Accessibility
+	GetCoverage() *common.Coverage
 	GetKeyboard() *common.Keyboard
 	GetMouse() *common.Mouse
 	GetTouchscreen() *common.Touchscreen
@@ -326,12 +409,16 @@ type pageAPI interface {
 	IsVisible(selector string, opts sobek.Value) (bool, error)
 	Locator(selector string, opts sobek.Value) *common.Locator
 	MainFrame() *common.Frame
+	Metrics() (map[string]float64, error)
 	On(event string, handler func(*common.ConsoleMessage) error) error
 	Opener() pageAPI
+	PDF(opts sobek.Value) ([]byte, error)
 	Press(selector string, key string, opts sobek.Value) error
 	Query(selector string) (*common.ElementHandle, error)
 	QueryAll(selector string) ([]*common.ElementHandle, error)
 	Reload(opts sobek.Value) *common.Response
+	Route(url sobek.Value, handler sobek.Callable) error
+	RouteFromHAR(path string, opts sobek.Value) error
 	Screenshot(opts sobek.Value) ([]byte, error)
 	SelectOption(selector string, values sobek.Value, opts sobek.Value) ([]string, error)
 	SetContent(html string, opts sobek.Value) error
@@ -347,8 +434,11 @@ type pageAPI interface {
 	Title() (string, error)
 	Type(selector string, text string, opts sobek.Value) error
 	Uncheck(selector string, opts sobek.Value) error
+	Unroute(url sobek.Value) error
 	URL() (string, error)
 	ViewportSize() map[string]float64
+	Video() *common.Video
+	WaitForEvent(event string, optsOrPredicate sobek.Value) (any, error)
 	WaitForFunction(fn, opts sobek.Value, args ...sobek.Value) (any, error)
 	WaitForLoadState(state string, opts sobek.Value) error
 	WaitForNavigation(opts sobek.Value) (*common.Response, error)
@@ -367,6 +457,8 @@ type consoleMessageAPI interface {
 
 // frameAPI is the interface of a CDP target frame.
 type frameAPI interface {
+	AddScriptTag(opts sobek.Value) (*common.ElementHandle, error)
+	AddStyleTag(opts sobek.Value) (*common.ElementHandle, error)
 	Check(selector string, opts sobek.Value) error
 	ChildFrames() []*common.Frame
 	Click(selector string, opts sobek.Value) error
@@ -497,10 +589,66 @@ type responseAPI interface {
 	Text() (string, error)
 }
 
+// dialogAPI is the interface of a JavaScript dialog.
+type dialogAPI interface {
+	Accept(promptText string) error
+	DefaultValue() string
+	Dismiss() error
+	Message() string
+	Type() string
+}
+
+// downloadAPI is the interface of a file downloaded by a page.
+type downloadAPI interface {
+	CreateReadStream() (io.ReadCloser, error)
+	Failure() (string, error)
+	Page() *common.Page
+	Path() (string, error)
+	SaveAs(path string) error
+	SuggestedFilename() string
+	URL() string
+}
+
+// videoAPI is the interface of the video recording of a page.
+type videoAPI interface {
+	Path() string
+	SaveAs(path string) error
+}
+
+// fileChooserAPI is the interface of a file chooser dialog.
+type fileChooserAPI interface {
+	Element() *common.ElementHandle
+	IsMultiple() bool
+	Page() *common.Page
+	SetFiles(files sobek.Value, opts sobek.Value) error
+}
+
+// pageErrorAPI is the interface of an uncaught exception thrown in a page.
+type pageErrorAPI interface {
+	Message() string
+	Name() string
+	Stack() string
+}
+
+// readStreamAPI is the interface of a reader of a downloaded file.
+type readStreamAPI interface {
+	Close() error
+	Read(size int64) (any, error)
+}
+
+// routeAPI is the interface of an intercepted request route.
+type routeAPI interface {
+	Abort(errorCode string) error
+	Continue(opts sobek.Value) error
+	Fulfill(opts sobek.Value) error
+	Request() *common.Request
+}
+
 // locatorAPI represents a way to find element(s) on a page at any moment.
 type locatorAPI interface {
 	Clear(opts *common.FrameFillOptions) error
 	Click(opts sobek.Value) error
+	CompareScreenshot(name string, opts sobek.Value) (*common.ScreenshotComparison, error)
 	Dblclick(opts sobek.Value) error
 	Check(opts sobek.Value) error
 	Uncheck(opts sobek.Value) error
@@ -526,6 +674,25 @@ type locatorAPI interface {
 	WaitFor(opts sobek.Value) error
 }
 
+// accessibilityAPI is the interface of the accessibility tree of a page.
+type accessibilityAPI interface {
+	Snapshot(opts sobek.Value) (*common.AccessibilityNode, error)
+}
+
+// tracingAPI is the interface of the tracing of a browser context.
+type tracingAPI interface {
+	Start(opts sobek.Value) error
+	Stop(opts sobek.Value) error
+}
+
+// coverageAPI is the interface of the JS and CSS coverage of a page.
+type coverageAPI interface {
+	StartJSCoverage(opts sobek.Value) error
+	StopJSCoverage(opts sobek.Value) ([]*common.JSCoverageEntry, error)
+	StartCSSCoverage(opts sobek.Value) error
+	StopCSSCoverage(opts sobek.Value) ([]*common.CSSCoverageEntry, error)
+}
+
 // keyboardAPI is the interface of a keyboard input device.
 type keyboardAPI interface {
 	Down(key string) error
@@ -37,6 +37,8 @@ type (
 		remoteRegistry *remoteRegistry
 		initOnce       *sync.Once
 		tracesMetadata map[string]string
+		traceFiles     *traceFileRegistry
+		userMetrics    *k6ext.UserMetrics
 		filePersister  filePersister
 		testRunID      string
 		isSync         bool // remove later
@@ -98,20 +100,33 @@ func (m *RootModule) NewModuleInstance(vu k6modules.VU) k6modules.Instance {
 		mapper = syncMapBrowserToSobek
 	}
 
+	// The call stacks are captured by the mappings and taken by the
+	// browser, so both of them need the same call stacks of the VU.
+	callStacks := k6ext.NewCallStacks()
+
 	return &ModuleInstance{
 		mod: &JSModule{
 			Browser: mapper(moduleVU{
 				VU:          vu,
 				pidRegistry: m.PidRegistry,
 				browserRegistry: newBrowserRegistry(
-					context.Background(),
+					k6ext.WithCallStacks(
+						k6ext.WithUserMetrics(
+							common.WithFilePersister(context.Background(), m.filePersister),
+							m.userMetrics,
+						),
+						callStacks,
+					),
 					vu,
 					m.remoteRegistry,
 					m.PidRegistry,
 					m.tracesMetadata,
+					m.traceFiles,
 				),
 				taskQueueRegistry: newTaskQueueRegistry(vu),
+				pageRegistry:      newPageRegistry(),
 				filePersister:     m.filePersister,
+				callStacks:        callStacks,
 				testRunID:         m.testRunID,
 			}),
 			Devices:         common.GetDevices(),
@@ -148,6 +163,13 @@ func (m *RootModule) initialize(vu k6modules.VU) {
 	if err != nil {
 		k6ext.Abort(vu.Context(), "failed to create file persister: %v", err)
 	}
+	m.traceFiles, err = newTraceFileRegistry(initEnv.LookupEnv, m.filePersister)
+	if err != nil {
+		k6ext.Abort(vu.Context(), "parsing browser traces output: %v", err)
+	}
+	// The user metrics are kept for the module instance, since its VUs
+	// share the registry of the test run.
+	m.userMetrics = k6ext.NewUserMetrics(initEnv.Registry)
 	if e, ok := initEnv.LookupEnv(env.K6TestRunID); ok && e != "" {
 		m.testRunID = e
 	}
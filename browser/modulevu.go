@@ -20,9 +20,14 @@ type moduleVU struct {
 	*browserRegistry
 
 	*taskQueueRegistry
+	*pageRegistry
 
 	filePersister
 
+	// callStacks records the JS call stacks of the async API calls that
+	// are traced into the trace archives.
+	callStacks *k6ext.CallStacks
+
 	testRunID string
 }
 
@@ -0,0 +1,15 @@
+package browser
+
+import (
+	"github.com/grafana/xk6-browser/common"
+)
+
+// mapPageError to the JS module. Like a JavaScript Error, the name, message
+// and stack of the error are properties rather than functions.
+func mapPageError(_ moduleVU, pe *common.PageError) mapping {
+	return mapping{
+		"message": pe.Message,
+		"name":    pe.Name,
+		"stack":   pe.Stack,
+	}
+}
@@ -2,6 +2,7 @@ package browser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,8 +16,41 @@ import (
 //
 //nolint:funlen
 func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
+	if m, ok := vu.pageRegistry.get(p.TargetID()); ok {
+		return m
+	}
+
 	rt := vu.Runtime()
 	maps := mapping{
+		"accessibility": mapAccessibility(vu, p.GetAccessibility()),
+		"addScriptTag": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewFrameAddScriptTagOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing addScriptTag options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				eh, err := p.AddScriptTag(popts)
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				return mapElementHandle(vu, eh), nil
+			}), nil
+		},
+		"addStyleTag": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewFrameAddStyleTagOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing addStyleTag options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				eh, err := p.AddStyleTag(popts)
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				return mapElementHandle(vu, eh), nil
+			}), nil
+		},
 		"bringToFront": func() *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return nil, p.BringToFront() //nolint:wrapcheck
@@ -27,6 +61,17 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 				return nil, p.Check(selector, opts) //nolint:wrapcheck
 			})
 		},
+		"checkAccessibility": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewPageCheckAccessibilityOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing check accessibility options: %w", err)
+			}
+
+			vu.callStacks.Capture(vu.Runtime(), "page.checkAccessibility")
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return p.CheckAccessibility(popts) //nolint:wrapcheck
+			}), nil
+		},
 		"click": func(selector string, opts sobek.Value) (*sobek.Promise, error) {
 			popts, err := parseFrameClickOptions(vu.Context(), opts, p.Timeout())
 			if err != nil {
@@ -39,11 +84,24 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 			}), nil
 		},
 		"close": func(opts sobek.Value) *sobek.Promise {
+			vu.callStacks.Capture(vu.Runtime(), "page.close")
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				vu.taskQueueRegistry.close(p.TargetID())
+				vu.pageRegistry.close(p.TargetID())
 				return nil, p.Close(opts) //nolint:wrapcheck
 			})
 		},
+		"compareScreenshot": func(name string, opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewCompareScreenshotOptions(p.Timeout())
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing compare screenshot options: %w", err)
+			}
+
+			vu.callStacks.Capture(vu.Runtime(), "page.compareScreenshot")
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return p.CompareScreenshot(name, popts, vu.filePersister) //nolint:wrapcheck
+			}), nil
+		},
 		"content": func() *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return p.Content() //nolint:wrapcheck
@@ -52,6 +110,7 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 		"context": func() mapping {
 			return mapBrowserContext(vu, p.Context())
 		},
+		"coverage": mapCoverage(vu, p.GetCoverage()),
 		"dblclick": func(selector string, opts sobek.Value) *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return nil, p.Dblclick(selector, opts) //nolint:wrapcheck
@@ -90,6 +149,16 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 				return mapJSHandle(vu, jsh), nil
 			})
 		},
+		"exposeBinding": func(name string, fn sobek.Callable) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, p.ExposeBinding(name, newBindingFunc(vu, fn, mapBindingSource)) //nolint:wrapcheck
+			})
+		},
+		"exposeFunction": func(name string, fn sobek.Callable) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, p.ExposeBinding(name, newBindingFunc(vu, fn, nil)) //nolint:wrapcheck
+			})
+		},
 		"fill": func(selector string, value string, opts sobek.Value) *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return nil, p.Fill(selector, value, opts) //nolint:wrapcheck
@@ -130,6 +199,7 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 			if err := gopts.Parse(vu.Context(), opts); err != nil {
 				return nil, fmt.Errorf("parsing page navigation options to %q: %w", url, err)
 			}
+			vu.callStacks.Capture(vu.Runtime(), "page.goto")
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				resp, err := p.Goto(url, gopts)
 				if err != nil {
@@ -199,37 +269,44 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 			mf := mapFrame(vu, p.MainFrame())
 			return rt.ToValue(mf).ToObject(rt)
 		},
-		"mouse": mapMouse(vu, p.GetMouse()),
-		"on": func(event string, handler sobek.Callable) error {
-			tq := vu.taskQueueRegistry.get(p.TargetID())
-
-			mapMsgAndHandleEvent := func(m *common.ConsoleMessage) error {
-				mapping := mapConsoleMessage(vu, m)
-				_, err := handler(sobek.Undefined(), vu.Runtime().ToValue(mapping))
-				return err
-			}
-			runInTaskQueue := func(m *common.ConsoleMessage) {
-				tq.Queue(func() error {
-					if err := mapMsgAndHandleEvent(m); err != nil {
-						return fmt.Errorf("executing page.on handler: %w", err)
-					}
-					return nil
-				})
-			}
-
-			return p.On(event, runInTaskQueue) //nolint:wrapcheck
+		"metrics": func() *sobek.Promise {
+			vu.callStacks.Capture(vu.Runtime(), "page.metrics")
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return p.Metrics() //nolint:wrapcheck
+			})
 		},
+		"mouse": mapMouse(vu, p.GetMouse()),
+		"on":    mapPageOn(vu, p, pageOnEventMappings()),
 		"opener": func() *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return p.Opener(), nil
 			})
 		},
+		"pdf": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewPagePDFOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing page pdf options: %w", err)
+			}
+
+			vu.callStacks.Capture(vu.Runtime(), "page.pdf")
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				bb, err := p.PDF(popts, vu.filePersister)
+				if err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+
+				ab := rt.NewArrayBuffer(bb)
+
+				return &ab, nil
+			}), nil
+		},
 		"press": func(selector string, key string, opts sobek.Value) *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				return nil, p.Press(selector, key, opts) //nolint:wrapcheck
 			})
 		},
 		"reload": func(opts sobek.Value) *sobek.Promise {
+			vu.callStacks.Capture(vu.Runtime(), "page.reload")
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				resp, err := p.Reload(opts)
 				if err != nil {
@@ -241,12 +318,33 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 				return rt.ToValue(r).ToObject(rt), nil
 			})
 		},
+		"route": func(url sobek.Value, handler sobek.Callable) (*sobek.Promise, error) {
+			matcher, err := common.NewURLMatcher(vu.Context(), url)
+			if err != nil {
+				return nil, fmt.Errorf("parsing page route URL: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, p.Route(matcher, newRouteHandler(vu, handler, mapRoute)) //nolint:wrapcheck
+			}), nil
+		},
+		"routeFromHAR": func(path string, opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewRouteFromHAROptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing page routeFromHAR options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, p.RouteFromHAR(path, popts) //nolint:wrapcheck
+			}), nil
+		},
 		"screenshot": func(opts sobek.Value) (*sobek.Promise, error) {
 			popts := common.NewPageScreenshotOptions()
 			if err := popts.Parse(vu.Context(), opts); err != nil {
 				return nil, fmt.Errorf("parsing page screenshot options: %w", err)
 			}
 
+			vu.callStacks.Capture(vu.Runtime(), "page.screenshot")
 			return k6ext.Promise(vu.Context(), func() (any, error) {
 				bb, err := p.Screenshot(popts, vu.filePersister)
 				if err != nil {
@@ -332,8 +430,26 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 				return nil, p.Uncheck(selector, opts) //nolint:wrapcheck
 			})
 		},
+		"unroute": func(url sobek.Value) (*sobek.Promise, error) {
+			matcher, err := common.NewURLMatcher(vu.Context(), url)
+			if err != nil {
+				return nil, fmt.Errorf("parsing page unroute URL: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, p.Unroute(matcher) //nolint:wrapcheck
+			}), nil
+		},
 		"url":          p.URL,
 		"viewportSize": p.ViewportSize,
+		"video": func() mapping {
+			v := p.Video()
+			if v == nil {
+				return nil
+			}
+			return mapVideo(vu, v)
+		},
+		"waitForEvent": mapPageWaitForEvent(vu, p, pageOnEventMappings()),
 		"waitForFunction": func(pageFunc, opts sobek.Value, args ...sobek.Value) (*sobek.Promise, error) {
 			js, popts, pargs, err := parseWaitForFunctionArgs(
 				vu.Context(), p.Timeout(), pageFunc, opts, args...,
@@ -357,6 +473,7 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 				return nil, fmt.Errorf("parsing page wait for navigation options: %w", err)
 			}
 
+			vu.callStacks.Capture(vu.Runtime(), "page.waitForNavigation")
 			return k6ext.Promise(vu.Context(), func() (result any, reason error) {
 				resp, err := p.WaitForNavigation(popts)
 				if err != nil {
@@ -421,9 +538,22 @@ func mapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 		})
 	}
 
+	vu.pageRegistry.set(p, maps)
+
 	return maps
 }
 
+// pageFromValue returns the page of a page object.
+func pageFromValue(vu moduleVU, v sobek.Value) (*common.Page, error) {
+	m, _ := v.Export().(mapping)
+	p, ok := vu.pageRegistry.page(m)
+	if !ok {
+		return nil, errors.New("not a page")
+	}
+
+	return p, nil
+}
+
 func parseWaitForFunctionArgs(
 	ctx context.Context, timeout time.Duration, pageFunc, opts sobek.Value, gargs ...sobek.Value,
 ) (string, *common.FrameWaitForFunctionOptions, []any, error) {
@@ -441,3 +571,141 @@ func parseWaitForFunctionArgs(
 
 	return js, popts, exportArgs(gargs), nil
 }
+
+// pageOnEventMapping maps the data of a page event to the JS module.
+type pageOnEventMapping func(vu moduleVU, e common.PageOnEvent) mapping
+
+// pageOnEventMappings returns the mappings of the page events that can be
+// subscribed to with page.on.
+func pageOnEventMappings() map[string]pageOnEventMapping {
+	return map[string]pageOnEventMapping{
+		common.EventPageConsole: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapConsoleMessage(vu, e.ConsoleMessage)
+		},
+		common.EventPageDialog: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapDialog(vu, e.Dialog)
+		},
+		common.EventPageDownload: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapDownload(vu, e.Download)
+		},
+		common.EventPageFilechooser: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapFileChooser(vu, e.FileChooser)
+		},
+		common.EventPageError: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapPageError(vu, e.PageError)
+		},
+		common.EventPagePopup: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapPage(vu, e.Page)
+		},
+		common.EventPageRequest: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapRequest(vu, e.Request)
+		},
+		common.EventPageRequestFailed: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapRequest(vu, e.Request)
+		},
+		common.EventPageRequestFinished: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapRequest(vu, e.Request)
+		},
+		common.EventPageResponse: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapResponse(vu, e.Response)
+		},
+	}
+}
+
+// mapPageOn maps page.on to the JS module. The event handlers are executed
+// in the task queue of the page, so that they run on the event loop of the VU.
+func mapPageOn(
+	vu moduleVU, p *common.Page, mappings map[string]pageOnEventMapping,
+) func(event string, handler sobek.Callable) error {
+	return func(event string, handler sobek.Callable) error {
+		mapEvent, ok := mappings[event]
+		if !ok {
+			return fmt.Errorf("unknown page event: %q", event)
+		}
+
+		tq := vu.taskQueueRegistry.get(p.TargetID())
+
+		mapAndHandleEvent := func(e common.PageOnEvent) (sobek.Value, error) {
+			mapping := mapEvent(vu, e)
+			return handler(sobek.Undefined(), vu.Runtime().ToValue(mapping))
+		}
+		runInTaskQueue := func(e common.PageOnEvent) {
+			tq.Queue(func() error {
+				v, err := mapAndHandleEvent(e)
+				if err != nil {
+					err = fmt.Errorf("executing page.on handler: %w", err)
+					if e.Dialog != nil {
+						e.Dialog.HandlerFailed(err)
+					}
+					return err
+				}
+				// A dialog blocks the page, so it's handled by default if
+				// the promise of an async handler rejects.
+				if e.Dialog != nil {
+					awaitValue(vu.Runtime(), v, func(_ any, err error) {
+						if err != nil {
+							e.Dialog.HandlerFailed(fmt.Errorf("executing page.on handler: %w", err))
+						}
+					})
+				}
+				return nil
+			})
+		}
+
+		return p.On(event, runInTaskQueue) //nolint:wrapcheck
+	}
+}
+
+// mapPageWaitForEvent maps page.waitForEvent to the JS module. The predicate
+// is executed in the task queue of the page with the mapped event data.
+func mapPageWaitForEvent(
+	vu moduleVU, p *common.Page, mappings map[string]pageOnEventMapping,
+) func(event string, optsOrPredicate sobek.Value) (*sobek.Promise, error) {
+	return func(event string, optsOrPredicate sobek.Value) (*sobek.Promise, error) {
+		mapEvent, ok := mappings[event]
+		if !ok {
+			return nil, fmt.Errorf("unknown page event: %q", event)
+		}
+
+		ctx := vu.Context()
+		popts := common.NewWaitForEventOptions(p.Timeout())
+		if err := popts.Parse(ctx, optsOrPredicate); err != nil {
+			return nil, fmt.Errorf("parsing waitForEvent options: %w", err)
+		}
+
+		return k6ext.Promise(ctx, func() (any, error) {
+			var runInTaskQueue func(common.PageOnEvent) (bool, error)
+			if popts.PredicateFn != nil {
+				runInTaskQueue = func(e common.PageOnEvent) (bool, error) {
+					tq := vu.taskQueueRegistry.get(p.TargetID())
+
+					var rtn bool
+					var err error
+					// The function on the taskqueue runs in its own goroutine
+					// so we need to use a channel to wait for it to complete
+					// before returning the result to the caller.
+					c := make(chan bool)
+					tq.Queue(func() error {
+						var resp sobek.Value
+						resp, err = popts.PredicateFn(vu.Runtime().ToValue(mapEvent(vu, e)))
+						if err == nil {
+							rtn = resp.ToBoolean()
+						}
+						close(c)
+						return nil
+					})
+					<-c
+
+					return rtn, err //nolint:wrapcheck
+				}
+			}
+
+			e, err := p.WaitForEvent(event, runInTaskQueue, popts.Timeout)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			return mapEvent(vu, e), nil
+		}), nil
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/mstoykov/k6-taskqueue-lib/taskqueue"
 	"go.opentelemetry.io/otel/attribute"
@@ -33,6 +35,12 @@ import (
 var errBrowserNotFoundInRegistry = errors.New("browser not found in registry. " +
 	"make sure to set browser type option in scenario definition in order to use the browser module")
 
+// sampleRuntimeMetricsTimeout is the maximum time to wait for the
+// runtime metrics of the open pages to be sampled at the end of an
+// iteration. The iteration context is already cancelled at that point,
+// so this bounds the time the IterEnd event is blocked instead.
+const sampleRuntimeMetricsTimeout = 5 * time.Second
+
 // pidRegistry keeps track of the launched browser process IDs.
 type pidRegistry struct {
 	mu  sync.RWMutex
@@ -176,6 +184,8 @@ type browserRegistry struct {
 	tr             *tracesRegistry
 	trInit         sync.Once
 	tracesMetadata map[string]string
+	traceFiles     *traceFileRegistry
+	traceFile      *browsertrace.FileExporter
 
 	mu sync.RWMutex
 	m  map[int64]*common.Browser
@@ -188,7 +198,12 @@ type browserRegistry struct {
 type browserBuildFunc func(ctx context.Context) (*common.Browser, error)
 
 func newBrowserRegistry(
-	ctx context.Context, vu k6modules.VU, remote *remoteRegistry, pids *pidRegistry, tracesMetadata map[string]string,
+	ctx context.Context,
+	vu k6modules.VU,
+	remote *remoteRegistry,
+	pids *pidRegistry,
+	tracesMetadata map[string]string,
+	traceFiles *traceFileRegistry,
 ) *browserRegistry {
 	bt := chromium.NewBrowserType(vu)
 	builder := func(ctx context.Context) (*common.Browser, error) {
@@ -218,6 +233,7 @@ func newBrowserRegistry(
 	r := &browserRegistry{
 		vu:             vu,
 		tracesMetadata: tracesMetadata,
+		traceFiles:     traceFiles,
 		m:              make(map[int64]*common.Browser),
 		buildFn:        builder,
 	}
@@ -234,7 +250,7 @@ func newBrowserRegistry(
 		vu.Events().Global.Unsubscribe(exitSubID)
 	}
 
-	go r.handleExitEvent(exitCh, unsubscribe)
+	go r.handleExitEvent(ctx, exitCh, unsubscribe)
 	go r.handleIterEvents(ctx, eventsCh, unsubscribe)
 
 	return r
@@ -301,6 +317,14 @@ func (r *browserRegistry) handleIterEvents( //nolint:funlen
 			}
 			r.setBrowser(data.Iteration, b)
 		case k6event.IterEnd:
+			if b, err := r.getBrowser(data.Iteration); err == nil {
+				sampleCtx, cancel := context.WithTimeout(ctx, sampleRuntimeMetricsTimeout)
+				b.SampleRuntimeMetrics(sampleCtx)
+				cancel()
+				if data.Error != nil {
+					b.MarkIterationFailed()
+				}
+			}
 			r.deleteBrowser(data.Iteration)
 			r.tr.endIterationTrace(data.Iteration)
 		default:
@@ -311,7 +335,7 @@ func (r *browserRegistry) handleIterEvents( //nolint:funlen
 	}
 }
 
-func (r *browserRegistry) handleExitEvent(exitCh <-chan *k6event.Event, unsubscribeFn func()) {
+func (r *browserRegistry) handleExitEvent(ctx context.Context, exitCh <-chan *k6event.Event, unsubscribeFn func()) {
 	defer unsubscribeFn()
 
 	e, ok := <-exitCh
@@ -324,7 +348,7 @@ func (r *browserRegistry) handleExitEvent(exitCh <-chan *k6event.Event, unsubscr
 	// Stop traces registry before calling e.Done()
 	// so we avoid a race condition between active spans
 	// being flushed and test exiting
-	r.stopTracesRegistry()
+	r.stopTracesRegistry(ctx)
 }
 
 func (r *browserRegistry) setBrowser(id int64, b *common.Browser) {
@@ -372,18 +396,40 @@ func (r *browserRegistry) initTracesRegistry() {
 	// Use a sync.Once so the traces registry is only initialized once
 	// per VU, as that is the scope for both browser and traces registry.
 	r.trInit.Do(func() {
+		tp := r.vu.State().TracerProvider
+		// The spans are written to a file instead of being sent to k6
+		// if the traces output is configured.
+		tf, ok, err := r.traceFiles.exporter(k6ext.GetScenarioOpts(r.vu.Context(), r.vu))
+		if err != nil {
+			r.vu.State().Logger.Warnf("not writing browser traces to a file: %v", err)
+		}
+		if ok {
+			tf.Acquire()
+			r.traceFile = tf
+			tp = tf.TracerProvider()
+		}
 		r.tr = newTracesRegistry(
-			browsertrace.NewTracer(r.vu.State().TracerProvider, r.tracesMetadata),
+			browsertrace.NewTracer(tp, r.tracesMetadata),
 		)
 	})
 }
 
-func (r *browserRegistry) stopTracesRegistry() {
+func (r *browserRegistry) stopTracesRegistry(ctx context.Context) {
 	// Because traces registry is initialized on iterStart event, it is not
 	// initialized for the initial NewModuleInstance call, whose VU does not
 	// execute any iteration.
-	if r.tr != nil {
-		r.tr.stop()
+	if r.tr == nil {
+		return
+	}
+	r.tr.stop()
+
+	// The iteration spans have ended, so the trace file has all the spans
+	// of this VU.
+	if r.traceFile == nil {
+		return
+	}
+	if err := r.traceFile.Release(ctx); err != nil {
+		r.vu.State().Logger.Errorf("writing browser traces: %v", err)
 	}
 }
 
@@ -521,3 +567,81 @@ func (t *taskQueueRegistry) close(targetID string) {
 		delete(t.tq, targetID)
 	}
 }
+
+// pageRegistry keeps the page object of each page, so that the same page
+// is mapped to the same page object, and a page object that is passed back
+// to the module, such as to browser.startTracing, can be resolved to its
+// page.
+type pageRegistry struct {
+	mu    sync.Mutex
+	pages map[string]pageObject
+}
+
+// pageObject is the mapping of a page.
+type pageObject struct {
+	page *common.Page
+	m    mapping
+}
+
+func newPageRegistry() *pageRegistry {
+	return &pageRegistry{
+		pages: make(map[string]pageObject),
+	}
+}
+
+// get returns the page object of the page with the target ID.
+func (r *pageRegistry) get(targetID string) (mapping, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	po, ok := r.pages[targetID]
+
+	return po.m, ok
+}
+
+// set sets the page object of the page.
+func (r *pageRegistry) set(p *common.Page, m mapping) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pages[p.TargetID()] = pageObject{page: p, m: m}
+}
+
+// page returns the page of the page object.
+func (r *pageRegistry) page(m mapping) (*common.Page, bool) {
+	if r == nil || m == nil {
+		return nil, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The page objects are the same map if they are of the same page.
+	ptr := reflect.ValueOf(m).Pointer()
+	for _, po := range r.pages {
+		if reflect.ValueOf(po.m).Pointer() == ptr {
+			return po.page, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *pageRegistry) close(targetID string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pages, targetID)
+}
@@ -3,6 +3,7 @@ package browser
 import (
 	"context"
 	"errors"
+	"reflect"
 	"strconv"
 	"sync"
 	"testing"
@@ -10,10 +11,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/xk6-browser/common"
 	"github.com/grafana/xk6-browser/env"
 	"github.com/grafana/xk6-browser/k6ext/k6test"
+	browsertrace "github.com/grafana/xk6-browser/trace"
 
 	k6event "go.k6.io/k6/event"
+	k6metrics "go.k6.io/k6/metrics"
 )
 
 func TestPidRegistry(t *testing.T) {
@@ -206,7 +210,7 @@ func TestBrowserRegistry(t *testing.T) {
 		var (
 			ctx             = context.Background()
 			vu              = k6test.NewVU(t)
-			browserRegistry = newBrowserRegistry(ctx, vu, remoteRegistry, &pidRegistry{}, nil)
+			browserRegistry = newBrowserRegistry(ctx, vu, remoteRegistry, &pidRegistry{}, nil, nil)
 		)
 
 		vu.ActivateVU()
@@ -242,13 +246,47 @@ func TestBrowserRegistry(t *testing.T) {
 		browserRegistry.mu.RUnlock()
 	})
 
+	t.Run("sample_runtime_metrics_on_iter_end", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			ctx             = context.Background()
+			vu              = k6test.NewVU(t)
+			browserRegistry = newBrowserRegistry(ctx, vu, remoteRegistry, &pidRegistry{}, nil, nil)
+		)
+
+		vu.ActivateVU()
+		vu.StartIteration(t, k6test.WithIteration(0))
+
+		b, err := browserRegistry.getBrowser(0)
+		require.NoError(t, err)
+		bctx, err := b.NewContext(vu.ToSobekValue(map[string]any{"runtimeMetrics": true}))
+		require.NoError(t, err)
+		_, err = bctx.NewPage()
+		require.NoError(t, err)
+
+		// k6 cancels the iteration context before sending the IterEnd
+		// event, so the page that is still open must be sampled anyway.
+		iterCtx, cancel := context.WithCancel(vu.CtxField)
+		cancel()
+		vu.CtxField = iterCtx
+
+		vu.EndIteration(t, k6test.WithIteration(0))
+
+		got := make(map[string]bool)
+		vu.AssertSamples(func(s k6metrics.Sample) {
+			got[s.Metric.Name] = true
+		})
+		assert.True(t, got["browser_js_heap_used"], "expected a browser_js_heap_used sample")
+	})
+
 	t.Run("close_browsers_on_exit_event", func(t *testing.T) {
 		t.Parallel()
 
 		var (
 			ctx             = context.Background()
 			vu              = k6test.NewVU(t)
-			browserRegistry = newBrowserRegistry(ctx, vu, remoteRegistry, &pidRegistry{}, nil)
+			browserRegistry = newBrowserRegistry(ctx, vu, remoteRegistry, &pidRegistry{}, nil, nil)
 		)
 
 		vu.ActivateVU()
@@ -283,7 +321,7 @@ func TestBrowserRegistry(t *testing.T) {
 		var (
 			ctx             = context.Background()
 			vu              = k6test.NewVU(t)
-			browserRegistry = newBrowserRegistry(ctx, vu, remoteRegistry, &pidRegistry{}, nil)
+			browserRegistry = newBrowserRegistry(ctx, vu, remoteRegistry, &pidRegistry{}, nil, nil)
 		)
 
 		vu.ActivateVU()
@@ -380,3 +418,117 @@ func TestParseTracesMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTracesOutput(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		value      string
+		expOutput  tracesOutput
+		expErrMssg string
+	}{
+		{
+			name:      "file",
+			value:     "file=traces.json",
+			expOutput: tracesOutput{path: "traces.json", format: browsertrace.FileFormatOTLP},
+		},
+		{
+			name:      "file and format",
+			value:     "format=chrome,file=traces.json",
+			expOutput: tracesOutput{path: "traces.json", format: browsertrace.FileFormatChrome},
+		},
+		{
+			name:       "no file",
+			value:      "format=otlp",
+			expErrMssg: `missing file in "format=otlp"`,
+		},
+		{
+			name:       "invalid format",
+			value:      "file=traces.json,format=zipkin",
+			expErrMssg: `invalid traces format "zipkin"`,
+		},
+		{
+			name:       "invalid option",
+			value:      "file=traces.json,level=debug",
+			expErrMssg: `invalid option "level"`,
+		},
+		{
+			name:       "invalid k=v",
+			value:      "traces.json",
+			expErrMssg: `format of value must be k=v, received "traces.json"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := parseTracesOutput(tc.value)
+			if tc.expErrMssg != "" {
+				assert.ErrorContains(t, err, tc.expErrMssg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expOutput, out)
+		})
+	}
+}
+
+func TestTraceFileRegistry(t *testing.T) {
+	t.Parallel()
+
+	lookup := func(key string) (string, bool) {
+		if key == env.TracesOutput {
+			return "file=traces.json", true
+		}
+		return "", false
+	}
+	r, err := newTraceFileRegistry(lookup, nil)
+	require.NoError(t, err)
+
+	e1, ok, err := r.exporter(nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, e1)
+	e2, ok, err := r.exporter(map[string]any{"type": "chromium"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Same(t, e1, e2, "must share the exporter of the same file")
+
+	e3, ok, err := r.exporter(map[string]any{"tracesOutput": "file=other.json,format=chrome"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.NotSame(t, e1, e3)
+
+	r, err = newTraceFileRegistry(func(string) (string, bool) { return "", false }, nil)
+	require.NoError(t, err)
+	_, ok, err = r.exporter(nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPageRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := newPageRegistry()
+	p := &common.Page{}
+	m := mapping{"goto": func() {}}
+	r.set(p, m)
+
+	got, ok := r.get(p.TargetID())
+	require.True(t, ok)
+	assert.Equal(t, reflect.ValueOf(m).Pointer(), reflect.ValueOf(got).Pointer())
+
+	gotPage, ok := r.page(m)
+	require.True(t, ok)
+	assert.Same(t, p, gotPage)
+
+	_, ok = r.page(mapping{"goto": func() {}})
+	assert.False(t, ok, "must not resolve another page object")
+
+	r.close(p.TargetID())
+	_, ok = r.page(m)
+	assert.False(t, ok, "must not resolve the page object of a closed page")
+}
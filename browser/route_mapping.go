@@ -0,0 +1,102 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// mapRoute to the JS module.
+func mapRoute(vu moduleVU, r *common.Route) mapping {
+	return mapping{
+		"abort": func(errorCode string) *sobek.Promise {
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, r.Abort(errorCode) //nolint:wrapcheck
+			})
+		},
+		"continue": func(opts sobek.Value) (*sobek.Promise, error) {
+			copts := common.NewRouteContinueOptions()
+			if err := copts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing route continue options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, r.Continue(copts) //nolint:wrapcheck
+			}), nil
+		},
+		"fulfill": func(opts sobek.Value) (*sobek.Promise, error) {
+			fopts := common.NewRouteFulfillOptions()
+			if err := fopts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing route fulfill options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, r.Fulfill(fopts) //nolint:wrapcheck
+			}), nil
+		},
+		"request": func() mapping {
+			return mapRequest(vu, r.Request())
+		},
+	}
+}
+
+// newRouteHandler returns a route handler that runs the JS handler with
+// the route mapped by mapRouteFn on the task queue of the page that made
+// the request. The request is aborted if the handler throws or its
+// promise rejects before the route is handled.
+//
+// The handler never blocks the event loop. While a synchronous call, such
+// as goto, blocks it, the handler runs after the call returns, and the
+// request is continued if it's not handled in the default timeout of the
+// page.
+func newRouteHandler(
+	vu moduleVU, handler sobek.Callable, mapRouteFn func(moduleVU, *common.Route) mapping,
+) common.RouteHandler {
+	return func(r *common.Route) {
+		tq := vu.taskQueueRegistry.get(r.Request().Frame().Page().TargetID())
+		tq.Queue(func() error {
+			rt := vu.Runtime()
+			v, err := handler(sobek.Undefined(), rt.ToValue(mapRouteFn(vu, r)))
+			if err != nil {
+				err = fmt.Errorf("executing route handler: %w", err)
+				r.HandlerFailed(err)
+				return err
+			}
+			awaitValue(rt, v, func(_ any, err error) {
+				if err != nil {
+					r.HandlerFailed(fmt.Errorf("executing route handler: %w", err))
+				}
+			})
+			return nil
+		})
+	}
+}
+
+// awaitValue calls done with the exported value, or with the exported
+// result of the value once it is settled if the value is a promise.
+func awaitValue(rt *sobek.Runtime, v sobek.Value, done func(any, error)) {
+	if _, ok := v.Export().(*sobek.Promise); !ok {
+		done(v.Export(), nil)
+		return
+	}
+
+	obj := v.ToObject(rt)
+	then, ok := sobek.AssertFunction(obj.Get("then"))
+	if !ok {
+		done(nil, errors.New("promise has no then function"))
+		return
+	}
+	onFulfilled := func(v sobek.Value) {
+		done(v.Export(), nil)
+	}
+	onRejected := func(v sobek.Value) {
+		done(nil, errors.New(v.String()))
+	}
+	if _, err := then(obj, rt.ToValue(onFulfilled), rt.ToValue(onRejected)); err != nil {
+		done(nil, fmt.Errorf("awaiting promise: %w", err))
+	}
+}
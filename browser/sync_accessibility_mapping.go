@@ -0,0 +1,23 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+)
+
+// syncMapAccessibility is like mapAccessibility but returns synchronous functions.
+func syncMapAccessibility(vu moduleVU, a *common.Accessibility) mapping {
+	return mapping{
+		"snapshot": func(opts sobek.Value) (*common.AccessibilityNode, error) {
+			popts := common.NewAccessibilitySnapshotOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing accessibility snapshot options: %w", err)
+			}
+
+			return a.Snapshot(popts) //nolint:wrapcheck
+		},
+	}
+}
@@ -54,11 +54,37 @@ func syncMapBrowserContext(vu moduleVU, bc *common.BrowserContext) mapping { //n
 
 			return bc.GrantPermissions(permissions, pOpts) //nolint:wrapcheck
 		},
+		"on": mapBrowserContextOn(vu, bc, syncMapPage),
+		"route": func(url sobek.Value, handler sobek.Callable) error {
+			matcher, err := common.NewURLMatcher(vu.Context(), url)
+			if err != nil {
+				return fmt.Errorf("parsing browser context route URL: %w", err)
+			}
+
+			return bc.Route(matcher, newRouteHandler(vu, handler, syncMapRoute)) //nolint:wrapcheck
+		},
+		"routeFromHAR": func(path string, opts sobek.Value) error {
+			popts := common.NewRouteFromHAROptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return fmt.Errorf("parsing browser context routeFromHAR options: %w", err)
+			}
+
+			return bc.RouteFromHAR(path, popts) //nolint:wrapcheck
+		},
 		"setDefaultNavigationTimeout": bc.SetDefaultNavigationTimeout,
 		"setDefaultTimeout":           bc.SetDefaultTimeout,
 		"setGeolocation":              bc.SetGeolocation,
 		"setHTTPCredentials":          bc.SetHTTPCredentials, //nolint:staticcheck
 		"setOffline":                  bc.SetOffline,
+		"tracing":                     syncMapTracing(vu, bc.Tracing()),
+		"unroute": func(url sobek.Value) error {
+			matcher, err := common.NewURLMatcher(vu.Context(), url)
+			if err != nil {
+				return fmt.Errorf("parsing browser context unroute URL: %w", err)
+			}
+
+			return bc.Unroute(matcher) //nolint:wrapcheck
+		},
 		"waitForEvent": func(event string, optsOrPredicate sobek.Value) (*sobek.Promise, error) {
 			ctx := vu.Context()
 			popts := common.NewWaitForEventOptions(
@@ -77,5 +77,34 @@ func syncMapBrowser(vu moduleVU) mapping { //nolint:funlen,cyclop
 
 			return syncMapPage(vu, page), nil
 		},
+		"startTracing": func(page, opts sobek.Value) error {
+			b, err := vu.browser()
+			if err != nil {
+				return err
+			}
+			p, popts, err := parseStartTracingArgs(vu, page, opts)
+			if err != nil {
+				return err
+			}
+
+			return b.StartTracing(p, popts) //nolint:wrapcheck
+		},
+		"stopTracing": func() (*sobek.ArrayBuffer, error) {
+			b, err := vu.browser()
+			if err != nil {
+				return nil, err
+			}
+			bb, err := b.StopTracing(vu.filePersister)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			if bb == nil {
+				return nil, nil
+			}
+
+			ab := rt.NewArrayBuffer(bb)
+
+			return &ab, nil
+		},
 	}
 }
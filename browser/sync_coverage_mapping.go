@@ -0,0 +1,47 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+)
+
+// syncMapCoverage is like mapCoverage but returns synchronous functions.
+func syncMapCoverage(vu moduleVU, c *common.Coverage) mapping {
+	return mapping{
+		"startJSCoverage": func(opts sobek.Value) error {
+			popts := common.NewJSCoverageOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return fmt.Errorf("parsing JS coverage options: %w", err)
+			}
+
+			return c.StartJSCoverage(popts) //nolint:wrapcheck
+		},
+		"stopJSCoverage": func(opts sobek.Value) ([]*common.JSCoverageEntry, error) {
+			popts := common.NewStopCoverageOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing stop coverage options: %w", err)
+			}
+
+			return c.StopJSCoverage(popts, vu.filePersister) //nolint:wrapcheck
+		},
+		"startCSSCoverage": func(opts sobek.Value) error {
+			popts := common.NewCSSCoverageOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return fmt.Errorf("parsing CSS coverage options: %w", err)
+			}
+
+			return c.StartCSSCoverage(popts) //nolint:wrapcheck
+		},
+		"stopCSSCoverage": func(opts sobek.Value) ([]*common.CSSCoverageEntry, error) {
+			popts := common.NewStopCoverageOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing stop coverage options: %w", err)
+			}
+
+			return c.StopCSSCoverage(popts, vu.filePersister) //nolint:wrapcheck
+		},
+	}
+}
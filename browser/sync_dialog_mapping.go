@@ -0,0 +1,16 @@
+package browser
+
+import (
+	"github.com/grafana/xk6-browser/common"
+)
+
+// syncMapDialog is like mapDialog but returns synchronous functions.
+func syncMapDialog(_ moduleVU, d *common.Dialog) mapping {
+	return mapping{
+		"accept":       d.Accept,
+		"defaultValue": d.DefaultValue,
+		"dismiss":      d.Dismiss,
+		"message":      d.Message,
+		"type":         d.Type,
+	}
+}
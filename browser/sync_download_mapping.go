@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"io"
+
+	"github.com/grafana/xk6-browser/common"
+)
+
+// syncMapDownload is like mapDownload but returns synchronous functions.
+func syncMapDownload(vu moduleVU, d *common.Download) mapping {
+	return mapping{
+		"createReadStream": func() (mapping, error) {
+			r, err := d.CreateReadStream()
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			return syncMapReadStream(vu, r), nil
+		},
+		"failure": func() (any, error) {
+			f, err := d.Failure()
+			if err != nil || f == "" {
+				return nil, err //nolint:wrapcheck
+			}
+			return f, nil
+		},
+		"page": func() mapping {
+			return syncMapPage(vu, d.Page())
+		},
+		"path": func() (any, error) {
+			p, err := d.Path()
+			if err != nil || p == "" {
+				return nil, err //nolint:wrapcheck
+			}
+			return p, nil
+		},
+		"saveAs": func(path string) error {
+			return d.SaveAs(path, vu.filePersister) //nolint:wrapcheck
+		},
+		"suggestedFilename": d.SuggestedFilename,
+		"url":               d.URL,
+	}
+}
+
+// syncMapReadStream is like mapReadStream but returns synchronous functions.
+func syncMapReadStream(vu moduleVU, r io.ReadCloser) mapping {
+	rt := vu.Runtime()
+	return mapping{
+		"close": r.Close,
+		"read": func(size int64) (any, error) {
+			b, err := readChunk(r, size)
+			if err != nil || b == nil {
+				return nil, err
+			}
+			ab := rt.NewArrayBuffer(b)
+			return &ab, nil
+		},
+	}
+}
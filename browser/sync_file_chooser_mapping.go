@@ -0,0 +1,19 @@
+package browser
+
+import (
+	"github.com/grafana/xk6-browser/common"
+)
+
+// syncMapFileChooser is like mapFileChooser but returns synchronous functions.
+func syncMapFileChooser(vu moduleVU, fc *common.FileChooser) mapping {
+	return mapping{
+		"element": func() mapping {
+			return syncMapElementHandle(vu, fc.Element())
+		},
+		"isMultiple": fc.IsMultiple,
+		"page": func() mapping {
+			return syncMapPage(vu, fc.Page())
+		},
+		"setFiles": fc.SetFiles,
+	}
+}
@@ -13,6 +13,28 @@ import (
 func syncMapFrame(vu moduleVU, f *common.Frame) mapping { //nolint:gocognit,cyclop,funlen
 	rt := vu.Runtime()
 	maps := mapping{
+		"addScriptTag": func(opts sobek.Value) (mapping, error) {
+			popts := common.NewFrameAddScriptTagOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing addScriptTag options: %w", err)
+			}
+			eh, err := f.AddScriptTag(popts)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			return syncMapElementHandle(vu, eh), nil
+		},
+		"addStyleTag": func(opts sobek.Value) (mapping, error) {
+			popts := common.NewFrameAddStyleTagOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing addStyleTag options: %w", err)
+			}
+			eh, err := f.AddStyleTag(popts)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			return syncMapElementHandle(vu, eh), nil
+		},
 		"check": f.Check,
 		"childFrames": func() *sobek.Object {
 			var (
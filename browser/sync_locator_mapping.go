@@ -32,6 +32,14 @@ func syncMapLocator(vu moduleVU, lo *common.Locator) mapping { //nolint:funlen
 				return nil, lo.Click(popts) //nolint:wrapcheck
 			}), nil
 		},
+		"compareScreenshot": func(name string, opts sobek.Value) (*common.ScreenshotComparison, error) {
+			popts := common.NewCompareScreenshotOptions(lo.Timeout())
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing compare screenshot options: %w", err)
+			}
+
+			return lo.CompareScreenshot(name, popts, vu.filePersister) //nolint:wrapcheck
+		},
 		"dblclick":   lo.Dblclick,
 		"check":      lo.Check,
 		"uncheck":    lo.Uncheck,
@@ -11,10 +11,45 @@ import (
 
 // syncMapPage is like mapPage but returns synchronous functions.
 func syncMapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop,funlen
+	if m, ok := vu.pageRegistry.get(p.TargetID()); ok {
+		return m
+	}
+
 	rt := vu.Runtime()
 	maps := mapping{
+		"accessibility": syncMapAccessibility(vu, p.GetAccessibility()),
+		"addScriptTag": func(opts sobek.Value) (mapping, error) {
+			popts := common.NewFrameAddScriptTagOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing addScriptTag options: %w", err)
+			}
+			eh, err := p.AddScriptTag(popts)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			return syncMapElementHandle(vu, eh), nil
+		},
+		"addStyleTag": func(opts sobek.Value) (mapping, error) {
+			popts := common.NewFrameAddStyleTagOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing addStyleTag options: %w", err)
+			}
+			eh, err := p.AddStyleTag(popts)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			return syncMapElementHandle(vu, eh), nil
+		},
 		"bringToFront": p.BringToFront,
 		"check":        p.Check,
+		"checkAccessibility": func(opts sobek.Value) ([]*common.AccessibilityViolation, error) {
+			popts := common.NewPageCheckAccessibilityOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing check accessibility options: %w", err)
+			}
+
+			return p.CheckAccessibility(popts) //nolint:wrapcheck
+		},
 		"click": func(selector string, opts sobek.Value) (*sobek.Promise, error) {
 			popts, err := parseFrameClickOptions(vu.Context(), opts, p.Timeout())
 			if err != nil {
@@ -28,11 +63,21 @@ func syncMapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 		},
 		"close": func(opts sobek.Value) error {
 			vu.taskQueueRegistry.close(p.TargetID())
+			vu.pageRegistry.close(p.TargetID())
 
 			return p.Close(opts) //nolint:wrapcheck
 		},
+		"compareScreenshot": func(name string, opts sobek.Value) (*common.ScreenshotComparison, error) {
+			popts := common.NewCompareScreenshotOptions(p.Timeout())
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing compare screenshot options: %w", err)
+			}
+
+			return p.CompareScreenshot(name, popts, vu.filePersister) //nolint:wrapcheck
+		},
 		"content":  p.Content,
 		"context":  p.Context,
+		"coverage": syncMapCoverage(vu, p.GetCoverage()),
 		"dblclick": p.Dblclick,
 		"dispatchEvent": func(selector, typ string, eventInit, opts sobek.Value) error {
 			popts := common.NewFrameDispatchEventOptions(p.Timeout())
@@ -112,28 +157,28 @@ func syncMapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 			mf := syncMapFrame(vu, p.MainFrame())
 			return rt.ToValue(mf).ToObject(rt)
 		},
-		"mouse": rt.ToValue(p.GetMouse()).ToObject(rt),
-		"on": func(event string, handler sobek.Callable) error {
-			tq := vu.taskQueueRegistry.get(p.TargetID())
+		"metrics": p.Metrics,
+		"mouse":   rt.ToValue(p.GetMouse()).ToObject(rt),
+		"on":      mapPageOn(vu, p, syncPageOnEventMappings()),
+		"opener":  p.Opener,
+		"pdf": func(opts sobek.Value) (*sobek.ArrayBuffer, error) {
+			ctx := vu.Context()
 
-			mapMsgAndHandleEvent := func(m *common.ConsoleMessage) error {
-				mapping := syncMapConsoleMessage(vu, m)
-				_, err := handler(sobek.Undefined(), vu.Runtime().ToValue(mapping))
-				return err
+			popts := common.NewPagePDFOptions()
+			if err := popts.Parse(ctx, opts); err != nil {
+				return nil, fmt.Errorf("parsing page pdf options: %w", err)
 			}
-			runInTaskQueue := func(m *common.ConsoleMessage) {
-				tq.Queue(func() error {
-					if err := mapMsgAndHandleEvent(m); err != nil {
-						return fmt.Errorf("executing page.on handler: %w", err)
-					}
-					return nil
-				})
+
+			bb, err := p.PDF(popts, vu.filePersister)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
 			}
 
-			return p.On(event, runInTaskQueue) //nolint:wrapcheck
+			ab := rt.NewArrayBuffer(bb)
+
+			return &ab, nil
 		},
-		"opener": p.Opener,
-		"press":  p.Press,
+		"press": p.Press,
 		"reload": func(opts sobek.Value) (*sobek.Object, error) {
 			resp, err := p.Reload(opts)
 			if err != nil {
@@ -144,6 +189,22 @@ func syncMapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 
 			return rt.ToValue(r).ToObject(rt), nil
 		},
+		"route": func(url sobek.Value, handler sobek.Callable) error {
+			matcher, err := common.NewURLMatcher(vu.Context(), url)
+			if err != nil {
+				return fmt.Errorf("parsing page route URL: %w", err)
+			}
+
+			return p.Route(matcher, newRouteHandler(vu, handler, syncMapRoute)) //nolint:wrapcheck
+		},
+		"routeFromHAR": func(path string, opts sobek.Value) error {
+			popts := common.NewRouteFromHAROptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return fmt.Errorf("parsing page routeFromHAR options: %w", err)
+			}
+
+			return p.RouteFromHAR(path, popts) //nolint:wrapcheck
+		},
 		"screenshot": func(opts sobek.Value) (*sobek.ArrayBuffer, error) {
 			ctx := vu.Context()
 
@@ -193,8 +254,24 @@ func syncMapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 		"touchscreen":     syncMapTouchscreen(vu, p.GetTouchscreen()),
 		"type":            p.Type,
 		"uncheck":         p.Uncheck,
-		"url":             p.URL,
-		"viewportSize":    p.ViewportSize,
+		"unroute": func(url sobek.Value) error {
+			matcher, err := common.NewURLMatcher(vu.Context(), url)
+			if err != nil {
+				return fmt.Errorf("parsing page unroute URL: %w", err)
+			}
+
+			return p.Unroute(matcher) //nolint:wrapcheck
+		},
+		"url":          p.URL,
+		"viewportSize": p.ViewportSize,
+		"video": func() mapping {
+			v := p.Video()
+			if v == nil {
+				return nil
+			}
+			return syncMapVideo(vu, v)
+		},
+		"waitForEvent": mapPageWaitForEvent(vu, p, syncPageOnEventMappings()),
 		"waitForFunction": func(pageFunc, opts sobek.Value, args ...sobek.Value) (*sobek.Promise, error) {
 			js, popts, pargs, err := parseWaitForFunctionArgs(
 				vu.Context(), p.Timeout(), pageFunc, opts, args...,
@@ -267,5 +344,44 @@ func syncMapPage(vu moduleVU, p *common.Page) mapping { //nolint:gocognit,cyclop
 		return mehs, nil
 	}
 
+	vu.pageRegistry.set(p, maps)
+
 	return maps
 }
+
+// syncPageOnEventMappings returns the mappings of the page events that
+// can be subscribed to with page.on.
+func syncPageOnEventMappings() map[string]pageOnEventMapping {
+	return map[string]pageOnEventMapping{
+		common.EventPageConsole: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapConsoleMessage(vu, e.ConsoleMessage)
+		},
+		common.EventPageDialog: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapDialog(vu, e.Dialog)
+		},
+		common.EventPageDownload: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapDownload(vu, e.Download)
+		},
+		common.EventPageFilechooser: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapFileChooser(vu, e.FileChooser)
+		},
+		common.EventPageError: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return mapPageError(vu, e.PageError)
+		},
+		common.EventPagePopup: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapPage(vu, e.Page)
+		},
+		common.EventPageRequest: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapRequest(vu, e.Request)
+		},
+		common.EventPageRequestFailed: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapRequest(vu, e.Request)
+		},
+		common.EventPageRequestFinished: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapRequest(vu, e.Request)
+		},
+		common.EventPageResponse: func(vu moduleVU, e common.PageOnEvent) mapping {
+			return syncMapResponse(vu, e.Response)
+		},
+	}
+}
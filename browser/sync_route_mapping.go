@@ -0,0 +1,33 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+)
+
+// syncMapRoute is like mapRoute but returns synchronous functions.
+func syncMapRoute(vu moduleVU, r *common.Route) mapping {
+	return mapping{
+		"abort": r.Abort,
+		"continue": func(opts sobek.Value) error {
+			copts := common.NewRouteContinueOptions()
+			if err := copts.Parse(vu.Context(), opts); err != nil {
+				return fmt.Errorf("parsing route continue options: %w", err)
+			}
+
+			return r.Continue(copts) //nolint:wrapcheck
+		},
+		"fulfill": func(opts sobek.Value) error {
+			fopts := common.NewRouteFulfillOptions()
+			if err := fopts.Parse(vu.Context(), opts); err != nil {
+				return fmt.Errorf("parsing route fulfill options: %w", err)
+			}
+
+			return r.Fulfill(fopts) //nolint:wrapcheck
+		},
+		"request": func() mapping { return syncMapRequest(vu, r.Request()) },
+	}
+}
@@ -0,0 +1,31 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+)
+
+// syncMapTracing is like mapTracing but returns synchronous functions.
+func syncMapTracing(vu moduleVU, t *common.Tracing) mapping {
+	return mapping{
+		"start": func(opts sobek.Value) error {
+			popts := common.NewTracingStartOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return fmt.Errorf("parsing tracing start options: %w", err)
+			}
+
+			return t.Start(popts) //nolint:wrapcheck
+		},
+		"stop": func(opts sobek.Value) error {
+			popts := common.NewTracingStopOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return fmt.Errorf("parsing tracing stop options: %w", err)
+			}
+
+			return t.Stop(popts, vu.filePersister) //nolint:wrapcheck
+		},
+	}
+}
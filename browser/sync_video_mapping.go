@@ -0,0 +1,15 @@
+package browser
+
+import (
+	"github.com/grafana/xk6-browser/common"
+)
+
+// syncMapVideo is like mapVideo but returns synchronous functions.
+func syncMapVideo(vu moduleVU, v *common.Video) mapping {
+	return mapping{
+		"path": v.Path,
+		"saveAs": func(path string) error {
+			return v.SaveAs(path, vu.filePersister) //nolint:wrapcheck
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package browser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grafana/xk6-browser/env"
+	browsertrace "github.com/grafana/xk6-browser/trace"
+)
+
+// tracesOutputOption is the browser option of a scenario that overrides
+// the K6_BROWSER_TRACES_OUTPUT environment variable for the scenario.
+const tracesOutputOption = "tracesOutput"
+
+// tracesOutput is where and in which format the browser traces are written.
+type tracesOutput struct {
+	path   string
+	format browsertrace.FileFormat
+}
+
+// parseTracesOutput parses a value such as:
+// file=traces.json,format=chrome
+// The format is optional and defaults to otlp.
+func parseTracesOutput(v string) (tracesOutput, error) {
+	out := tracesOutput{format: browsertrace.FileFormatOTLP}
+	for _, elem := range strings.Split(v, ",") {
+		kv := strings.Split(elem, "=")
+		if len(kv) != 2 {
+			return tracesOutput{}, fmt.Errorf("format of value must be k=v, received %q", elem)
+		}
+
+		switch k, v := kv[0], kv[1]; k {
+		case "file":
+			out.path = v
+		case "format":
+			switch f := browsertrace.FileFormat(v); f {
+			case browsertrace.FileFormatOTLP, browsertrace.FileFormatChrome:
+				out.format = f
+			default:
+				return tracesOutput{}, fmt.Errorf("invalid traces format %q, must be one of 'otlp' or 'chrome'", v)
+			}
+		default:
+			return tracesOutput{}, fmt.Errorf("invalid option %q", k)
+		}
+	}
+	if out.path == "" {
+		return tracesOutput{}, fmt.Errorf("missing file in %q", v)
+	}
+
+	return out, nil
+}
+
+// traceFileRegistry holds the exporters of the trace files of a test run,
+// which are shared by all the VUs that write to the same file.
+type traceFileRegistry struct {
+	fp  filePersister
+	out *tracesOutput // from the environment variable, if set
+
+	mu sync.Mutex
+	m  map[string]*browsertrace.FileExporter
+}
+
+func newTraceFileRegistry(envLookup env.LookupFunc, fp filePersister) (*traceFileRegistry, error) {
+	r := &traceFileRegistry{
+		fp: fp,
+		m:  make(map[string]*browsertrace.FileExporter),
+	}
+
+	v, ok := envLookup(env.TracesOutput)
+	if !ok || v == "" {
+		return r, nil
+	}
+	out, err := parseTracesOutput(v)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", env.TracesOutput, err)
+	}
+	r.out = &out
+
+	return r, nil
+}
+
+// exporter returns the exporter of the trace file that the scenario with
+// the browser options writes to. It returns false if the traces are not
+// written to a file.
+func (r *traceFileRegistry) exporter(opts map[string]any) (*browsertrace.FileExporter, bool, error) {
+	if r == nil {
+		return nil, false, nil
+	}
+
+	out := r.out
+	if v, ok := opts[tracesOutputOption].(string); ok && v != "" {
+		o, err := parseTracesOutput(v)
+		if err != nil {
+			return nil, false, fmt.Errorf("parsing %s browser option: %w", tracesOutputOption, err)
+		}
+		out = &o
+	}
+	if out == nil {
+		return nil, false, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.m[out.path]
+	if !ok {
+		e = browsertrace.NewFileExporter(out.path, out.format, r.fp)
+		r.m[out.path] = e
+	}
+
+	return e, true, nil
+}
@@ -0,0 +1,36 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// mapTracing to the JS module.
+func mapTracing(vu moduleVU, t *common.Tracing) mapping {
+	return mapping{
+		"start": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewTracingStartOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing tracing start options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, t.Start(popts) //nolint:wrapcheck
+			}), nil
+		},
+		"stop": func(opts sobek.Value) (*sobek.Promise, error) {
+			popts := common.NewTracingStopOptions()
+			if err := popts.Parse(vu.Context(), opts); err != nil {
+				return nil, fmt.Errorf("parsing tracing stop options: %w", err)
+			}
+
+			return k6ext.Promise(vu.Context(), func() (any, error) {
+				return nil, t.Stop(popts, vu.filePersister) //nolint:wrapcheck
+			}), nil
+		},
+	}
+}
@@ -0,0 +1,629 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	k6metrics "go.k6.io/k6/metrics"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// Accessibility provides the accessibility tree of a page, which is what
+// assistive technologies, such as screen readers, see.
+// Each Page has a publicly accessible Accessibility.
+type Accessibility struct {
+	ctx     context.Context
+	session session
+	page    *Page
+}
+
+// NewAccessibility returns a new Accessibility for the page.
+func NewAccessibility(ctx context.Context, s session, p *Page) *Accessibility {
+	return &Accessibility{
+		ctx:     ctx,
+		session: s,
+		page:    p,
+	}
+}
+
+// AccessibilityNode is a node of the accessibility tree of a page. The
+// properties that don't apply to the node are left empty.
+type AccessibilityNode struct {
+	Role        string `js:"role"`
+	Name        string `js:"name"`
+	Value       string `js:"value"`
+	Description string `js:"description"`
+	Focused     bool   `js:"focused"`
+	Disabled    bool   `js:"disabled"`
+	Expanded    bool   `js:"expanded"`
+	Required    bool   `js:"required"`
+	Selected    bool   `js:"selected"`
+	// Checked and Pressed are either "true", "false" or "mixed".
+	Checked  string               `js:"checked"`
+	Pressed  string               `js:"pressed"`
+	Level    int64                `js:"level"`
+	Children []*AccessibilityNode `js:"children"`
+}
+
+// Snapshot returns the accessibility tree of the page, or of the root
+// element if it's set in the options.
+func (a *Accessibility) Snapshot(opts *AccessibilitySnapshotOptions) (*AccessibilityNode, error) {
+	a.page.logger.Debugf("Accessibility:Snapshot", "sid:%v root:%q interestingOnly:%t",
+		a.page.sessionID(), opts.Root, opts.InterestingOnly)
+
+	tree, err := a.tree()
+	if err != nil {
+		return nil, fmt.Errorf("taking accessibility snapshot: %w", err)
+	}
+	root := tree.root
+	if opts.Root != "" {
+		if root, err = a.rootNode(tree, opts.Root); err != nil {
+			return nil, fmt.Errorf("taking accessibility snapshot: %w", err)
+		}
+	}
+	if root == nil {
+		return nil, nil
+	}
+
+	var interesting map[*axNode]bool
+	if opts.InterestingOnly {
+		interesting = make(map[*axNode]bool)
+		collectInterestingNodes(interesting, root, false)
+	}
+	nodes := serializeAXTree(root, root, interesting)
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+
+	return nodes[0], nil
+}
+
+// tree returns the full accessibility tree of the page.
+func (a *Accessibility) tree() (*axTree, error) {
+	nodes, err := accessibility.GetFullAXTree().Do(cdp.WithExecutor(a.ctx, a.session))
+	if err != nil {
+		return nil, fmt.Errorf("getting accessibility tree: %w", err)
+	}
+
+	return newAXTree(nodes), nil
+}
+
+// rootNode returns the node of the element that matches the selector.
+func (a *Accessibility) rootNode(tree *axTree, selector string) (*axNode, error) {
+	h, err := a.page.MainFrame().Query(selector, true)
+	if err != nil {
+		return nil, fmt.Errorf("querying root %q: %w", selector, err)
+	}
+	if h == nil {
+		return nil, fmt.Errorf("root %q doesn't match any elements", selector)
+	}
+	defer func() {
+		if err := h.Dispose(); err != nil {
+			a.page.logger.Debugf("Accessibility:rootNode", "disposing element handle: %v", err)
+		}
+	}()
+
+	node, err := dom.DescribeNode().WithObjectID(h.remoteObject.ObjectID).Do(cdp.WithExecutor(a.ctx, a.session))
+	if err != nil {
+		return nil, fmt.Errorf("describing root %q: %w", selector, err)
+	}
+
+	return tree.byBackendNodeID[node.BackendNodeID], nil
+}
+
+// axTree is the accessibility tree of a page that is built from the flat
+// list of nodes that the browser returns.
+type axTree struct {
+	root            *axNode
+	byBackendNodeID map[cdp.BackendNodeID]*axNode
+}
+
+type axNode struct {
+	*accessibility.Node
+	children []*axNode
+}
+
+func newAXTree(nodes []*accessibility.Node) *axTree {
+	t := &axTree{
+		byBackendNodeID: make(map[cdp.BackendNodeID]*axNode),
+	}
+	if len(nodes) == 0 {
+		return t
+	}
+
+	byID := make(map[accessibility.NodeID]*axNode, len(nodes))
+	for _, n := range nodes {
+		an := &axNode{Node: n}
+		byID[n.NodeID] = an
+		if n.BackendDOMNodeID != 0 {
+			t.byBackendNodeID[n.BackendDOMNodeID] = an
+		}
+	}
+	for _, n := range nodes {
+		an := byID[n.NodeID]
+		for _, id := range n.ChildIDs {
+			if c, ok := byID[id]; ok {
+				an.children = append(an.children, c)
+			}
+		}
+	}
+	// The first node is the root of the document.
+	t.root = byID[nodes[0].NodeID]
+
+	return t
+}
+
+// walk calls fn for the node and its descendants, in document order.
+func (n *axNode) walk(fn func(*axNode)) {
+	fn(n)
+	for _, c := range n.children {
+		c.walk(fn)
+	}
+}
+
+func (n *axNode) role() string {
+	return axValueString(n.Role)
+}
+
+func (n *axNode) name() string {
+	return axValueString(n.Name)
+}
+
+// property returns the value of the property with the name, if any.
+func (n *axNode) property(name accessibility.PropertyName) *accessibility.Value {
+	for _, p := range n.Properties {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+
+	return nil
+}
+
+func (n *axNode) boolProperty(name accessibility.PropertyName) bool {
+	return axValueString(n.property(name)) == "true"
+}
+
+// isControl reports whether the node is an interactive widget.
+func (n *axNode) isControl() bool {
+	switch n.role() {
+	case "button", "checkbox", "ColorWell", "combobox", "DisclosureTriangle", "listbox",
+		"menu", "menubar", "menuitem", "menuitemcheckbox", "menuitemradio", "radio",
+		"scrollbar", "searchbox", "slider", "spinbutton", "switch", "tab", "textbox",
+		"tree", "treeitem":
+		return true
+	}
+
+	return false
+}
+
+// isLeaf reports whether the children of the node are part of the node
+// itself for assistive technologies, such as the text of a heading.
+func (n *axNode) isLeaf() bool {
+	if len(n.children) == 0 {
+		return true
+	}
+	switch n.role() {
+	case "image", "img", "meter", "progressbar", "scrollbar", "separator", "slider", "StaticText":
+		return true
+	}
+	for _, c := range n.children {
+		focusable := false
+		c.walk(func(d *axNode) { focusable = focusable || d.boolProperty(accessibility.PropertyNameFocusable) })
+		if focusable {
+			return false
+		}
+	}
+	if n.boolProperty(accessibility.PropertyNameFocusable) && n.name() != "" {
+		return true
+	}
+
+	return n.role() == "heading" && n.name() != ""
+}
+
+func (n *axNode) isInteresting(insideControl bool) bool {
+	if n.Ignored || n.role() == "InlineTextBox" {
+		return false
+	}
+	if n.boolProperty(accessibility.PropertyNameFocusable) || n.isControl() {
+		return true
+	}
+	if insideControl {
+		return false
+	}
+
+	return n.isLeaf() && n.name() != ""
+}
+
+func collectInterestingNodes(nodes map[*axNode]bool, n *axNode, insideControl bool) {
+	if n.isInteresting(insideControl) {
+		nodes[n] = true
+	}
+	if n.isLeaf() {
+		return
+	}
+	insideControl = insideControl || n.isControl()
+	for _, c := range n.children {
+		collectInterestingNodes(nodes, c, insideControl)
+	}
+}
+
+// serializeAXTree returns the serialized node, or its serialized children
+// if the node is left out of the snapshot. The ignored nodes are always
+// left out, and the other nodes are left out if they are not in the
+// interesting nodes. The root is never left out.
+func serializeAXTree(root, n *axNode, interesting map[*axNode]bool) []*AccessibilityNode {
+	var children []*AccessibilityNode
+	if interesting == nil || !n.isLeaf() {
+		for _, c := range n.children {
+			children = append(children, serializeAXTree(root, c, interesting)...)
+		}
+	}
+
+	keep := n == root
+	if !keep && interesting != nil {
+		keep = interesting[n]
+	} else if !keep {
+		keep = !n.Ignored && n.role() != "InlineTextBox"
+	}
+	if !keep {
+		return children
+	}
+	sn := n.serialize()
+	sn.Children = children
+
+	return []*AccessibilityNode{sn}
+}
+
+func (n *axNode) serialize() *AccessibilityNode {
+	sn := &AccessibilityNode{
+		Role:        n.role(),
+		Name:        n.name(),
+		Value:       axValueString(n.Value),
+		Description: axValueString(n.Description),
+		Focused:     n.boolProperty(accessibility.PropertyNameFocused),
+		Disabled:    n.boolProperty(accessibility.PropertyNameDisabled),
+		Expanded:    n.boolProperty(accessibility.PropertyNameExpanded),
+		Required:    n.boolProperty(accessibility.PropertyNameRequired),
+		Selected:    n.boolProperty(accessibility.PropertyNameSelected),
+		Checked:     axValueString(n.property(accessibility.PropertyNameChecked)),
+		Pressed:     axValueString(n.property(accessibility.PropertyNamePressed)),
+	}
+	if v := n.property(accessibility.PropertyNameLevel); v != nil {
+		_ = json.Unmarshal(v.Value, &sn.Level)
+	}
+
+	return sn
+}
+
+// axValueString returns the value as a string, or an empty string if the
+// value is not set.
+func axValueString(v *accessibility.Value) string {
+	if v == nil || len(v.Value) == 0 {
+		return ""
+	}
+	var s any
+	if err := json.Unmarshal(v.Value, &s); err != nil {
+		return ""
+	}
+	switch s := s.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	default:
+		return strings.TrimSpace(string(v.Value))
+	}
+}
+
+// AccessibilityRule is a rule of CheckAccessibility.
+type AccessibilityRule string
+
+const (
+	// AccessibilityRuleImageAlt checks that the images have an alternative
+	// text.
+	AccessibilityRuleImageAlt AccessibilityRule = "image-alt"
+
+	// AccessibilityRuleLabel checks that the form controls have a label.
+	AccessibilityRuleLabel AccessibilityRule = "label"
+
+	// AccessibilityRuleColorContrast checks that the text has enough
+	// contrast with its background, as defined by WCAG 2 level AA.
+	AccessibilityRuleColorContrast AccessibilityRule = "color-contrast"
+
+	// AccessibilityRuleLandmark checks that the page has a single main
+	// landmark, and at most one banner and content info landmark.
+	AccessibilityRuleLandmark AccessibilityRule = "landmark"
+
+	// AccessibilityRuleTabIndex checks that no element changes the natural
+	// tab order with a positive tabindex.
+	AccessibilityRuleTabIndex AccessibilityRule = "tabindex"
+)
+
+// accessibilityRules are all the rules in the order they run.
+var accessibilityRules = []AccessibilityRule{ //nolint:gochecknoglobals
+	AccessibilityRuleImageAlt,
+	AccessibilityRuleLabel,
+	AccessibilityRuleColorContrast,
+	AccessibilityRuleLandmark,
+	AccessibilityRuleTabIndex,
+}
+
+// AccessibilityViolation is a violation of an accessibility rule.
+type AccessibilityViolation struct {
+	Rule    AccessibilityRule `js:"rule" json:"rule"`
+	Message string            `js:"message" json:"message"`
+	// Role and Name are the role and the accessible name of the node
+	// that violates the rule, if the rule applies to a single node.
+	Role string `js:"role" json:"role"`
+	Name string `js:"name" json:"name"`
+	// Element describes the element that violates the rule, such as
+	// img#logo, if the rule applies to a single element.
+	Element string `js:"element" json:"element"`
+}
+
+// checkAccessibilityScript checks the rules that depend on the styles and
+// attributes of the elements rather than on the accessibility tree.
+const checkAccessibilityScript = `(opts) => {
+	const describe = (el) => el.localName + (el.id ? '#' + el.id : '');
+	const parse = (color) => {
+		const m = /rgba?\(([^)]+)\)/.exec(color);
+		if (!m) {
+			return null;
+		}
+		const c = m[1].split(/[\s,\/]+/).filter(Boolean).map(Number);
+		return [c[0], c[1], c[2], c.length > 3 ? c[3] : 1];
+	};
+	const luminance = (c) => {
+		const l = (v) => {
+			v /= 255;
+			return v <= 0.03928 ? v / 12.92 : Math.pow((v + 0.055) / 1.055, 2.4);
+		};
+		return 0.2126 * l(c[0]) + 0.7152 * l(c[1]) + 0.0722 * l(c[2]);
+	};
+	const background = (el) => {
+		for (; el; el = el.parentElement) {
+			const c = parse(getComputedStyle(el).backgroundColor);
+			if (c && c[3] > 0) {
+				return c;
+			}
+		}
+		return [255, 255, 255, 1];
+	};
+
+	const violations = [];
+	if (opts.contrast && document.body) {
+		const seen = new Set();
+		const walker = document.createTreeWalker(document.body, NodeFilter.SHOW_TEXT);
+		for (let n = walker.nextNode(); n; n = walker.nextNode()) {
+			const el = n.parentElement;
+			if (!el || seen.has(el) || !n.textContent.trim()) {
+				continue;
+			}
+			seen.add(el);
+			const style = getComputedStyle(el);
+			const fg = parse(style.color);
+			if (!fg || style.visibility !== 'visible' || el.getClientRects().length === 0) {
+				continue;
+			}
+			const bg = background(el);
+			const text = [0, 1, 2].map(i => fg[i] * fg[3] + bg[i] * (1 - fg[3]));
+			const l1 = luminance(text), l2 = luminance(bg);
+			const ratio = (Math.max(l1, l2) + 0.05) / (Math.min(l1, l2) + 0.05);
+			const size = parseFloat(style.fontSize);
+			const large = size >= 24 || (parseInt(style.fontWeight, 10) >= 700 && size >= 18.66);
+			const min = large ? 3 : 4.5;
+			if (ratio < min) {
+				violations.push({
+					rule: 'color-contrast',
+					message: 'text has a contrast ratio of ' + ratio.toFixed(2) + ', expected at least ' + min,
+					element: describe(el),
+				});
+			}
+		}
+	}
+	if (opts.tabIndex) {
+		for (const el of document.querySelectorAll('[tabindex]')) {
+			if (el.tabIndex > 0) {
+				violations.push({
+					rule: 'tabindex',
+					message: 'element has a positive tabindex of ' + el.tabIndex + ', which changes the natural tab order',
+					element: describe(el),
+				});
+			}
+		}
+	}
+	return violations;
+}`
+
+// CheckAccessibility runs the accessibility rules over the page and returns
+// the violations. The number of violations of each rule is emitted as the
+// browser_a11y_violations metric.
+func (p *Page) CheckAccessibility(opts *PageCheckAccessibilityOptions) ([]*AccessibilityViolation, error) {
+	p.logger.Debugf("Page:CheckAccessibility", "sid:%v rules:%v", p.sessionID(), opts.Rules)
+
+	_, span := TraceAPICall(p.ctx, p.targetID.String(), "page.checkAccessibility")
+	defer span.End()
+
+	violations, err := p.checkAccessibility(opts.Rules)
+	if err != nil {
+		err := fmt.Errorf("checking accessibility: %w", err)
+		spanRecordError(span, err)
+		return nil, err
+	}
+	p.emitAccessibilityViolations(opts.Rules, violations)
+
+	return violations, nil
+}
+
+func (p *Page) checkAccessibility(rules []AccessibilityRule) ([]*AccessibilityViolation, error) {
+	enabled := make(map[AccessibilityRule]bool, len(rules))
+	for _, r := range rules {
+		enabled[r] = true
+	}
+
+	tree, err := p.Accessibility.tree()
+	if err != nil {
+		return nil, err
+	}
+	violations := checkAXTree(tree, enabled)
+	for _, v := range violations {
+		v.Element = p.describeElement(v.backendNodeID)
+	}
+
+	result := make([]*AccessibilityViolation, 0, len(violations))
+	for _, v := range violations {
+		result = append(result, v.AccessibilityViolation)
+	}
+	if !enabled[AccessibilityRuleColorContrast] && !enabled[AccessibilityRuleTabIndex] {
+		return result, nil
+	}
+
+	v, err := p.MainFrame().Evaluate(checkAccessibilityScript, map[string]bool{
+		"contrast": enabled[AccessibilityRuleColorContrast],
+		"tabIndex": enabled[AccessibilityRuleTabIndex],
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling violations: %w", err)
+	}
+	var domViolations []*AccessibilityViolation
+	if err := json.Unmarshal(b, &domViolations); err != nil {
+		return nil, fmt.Errorf("unmarshaling violations: %w", err)
+	}
+
+	return append(result, domViolations...), nil
+}
+
+// axViolation is a violation that is found in the accessibility tree.
+type axViolation struct {
+	*AccessibilityViolation
+	backendNodeID cdp.BackendNodeID
+}
+
+// checkAXTree checks the enabled rules that apply to the accessibility
+// tree.
+func checkAXTree(tree *axTree, enabled map[AccessibilityRule]bool) []*axViolation {
+	var (
+		violations []*axViolation
+		landmarks  = make(map[string]int)
+	)
+	if tree.root == nil {
+		return nil
+	}
+	violation := func(n *axNode, rule AccessibilityRule, msg string) {
+		violations = append(violations, &axViolation{
+			AccessibilityViolation: &AccessibilityViolation{
+				Rule:    rule,
+				Message: msg,
+				Role:    n.role(),
+				Name:    n.name(),
+			},
+			backendNodeID: n.BackendDOMNodeID,
+		})
+	}
+	tree.root.walk(func(n *axNode) {
+		if n.Ignored {
+			return
+		}
+		switch role := n.role(); role {
+		case "image", "img":
+			if enabled[AccessibilityRuleImageAlt] && n.name() == "" {
+				violation(n, AccessibilityRuleImageAlt, "image has no alternative text")
+			}
+		case "textbox", "searchbox", "combobox", "listbox", "checkbox", "radio",
+			"spinbutton", "slider", "switch":
+			if enabled[AccessibilityRuleLabel] && n.name() == "" {
+				violation(n, AccessibilityRuleLabel, "form control has no label")
+			}
+		case "main", "banner", "contentinfo":
+			landmarks[role]++
+		}
+	})
+
+	if !enabled[AccessibilityRuleLandmark] {
+		return violations
+	}
+	landmark := func(msg string) {
+		violations = append(violations, &axViolation{
+			AccessibilityViolation: &AccessibilityViolation{
+				Rule:    AccessibilityRuleLandmark,
+				Message: msg,
+			},
+		})
+	}
+	if landmarks["main"] == 0 {
+		landmark("page has no main landmark")
+	}
+	for _, role := range []string{"main", "banner", "contentinfo"} {
+		if landmarks[role] > 1 {
+			landmark(fmt.Sprintf("page has %d %s landmarks, expected at most one", landmarks[role], role))
+		}
+	}
+
+	return violations
+}
+
+// describeElement describes the DOM node with the backend node ID, such
+// as img#logo. It returns an empty string if the node can't be described.
+func (p *Page) describeElement(id cdp.BackendNodeID) string {
+	if id == 0 {
+		return ""
+	}
+	node, err := dom.DescribeNode().WithBackendNodeID(id).Do(cdp.WithExecutor(p.ctx, p.session))
+	if err != nil {
+		p.logger.Debugf("Page:describeElement", "sid:%v id:%d %v", p.sessionID(), id, err)
+		return ""
+	}
+
+	s := node.LocalName
+	for i := 0; i+1 < len(node.Attributes); i += 2 {
+		if node.Attributes[i] == "id" && node.Attributes[i+1] != "" {
+			s += "#" + node.Attributes[i+1]
+		}
+	}
+
+	return s
+}
+
+func (p *Page) emitAccessibilityViolations(rules []AccessibilityRule, violations []*AccessibilityViolation) {
+	state := p.vu.State()
+	if state == nil {
+		return
+	}
+	tags := state.Tags.GetCurrentValues().Tags
+	if state.Options.SystemTags.Has(k6metrics.TagURL) {
+		tags = tags.With("url", p.MainFrame().URL())
+	}
+
+	counts := make(map[AccessibilityRule]int, len(rules))
+	for _, v := range violations {
+		counts[v.Rule]++
+	}
+	var (
+		metric  = k6ext.GetCustomMetrics(p.ctx).BrowserA11yViolations
+		now     = time.Now()
+		samples = make([]k6metrics.Sample, 0, len(rules))
+	)
+	for _, r := range rules {
+		samples = append(samples, k6metrics.Sample{
+			TimeSeries: k6metrics.TimeSeries{
+				Metric: metric,
+				Tags:   tags.With("rule", string(r)),
+			},
+			Value: float64(counts[r]),
+			Time:  now,
+		})
+	}
+	k6metrics.PushIfNotDone(p.vu.Context(), state.Samples, k6metrics.ConnectedSamples{Samples: samples})
+}
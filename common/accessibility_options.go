@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// AccessibilitySnapshotOptions are the options of Accessibility.Snapshot.
+type AccessibilitySnapshotOptions struct {
+	// Root is the selector of the element that the snapshot starts from.
+	// The snapshot starts from the document if it's empty.
+	Root string `js:"root"`
+	// InterestingOnly leaves out the nodes that are not interesting for
+	// assistive technologies, such as the generic containers.
+	InterestingOnly bool `js:"interestingOnly"`
+}
+
+// NewAccessibilitySnapshotOptions returns the default accessibility
+// snapshot options.
+func NewAccessibilitySnapshotOptions() *AccessibilitySnapshotOptions {
+	return &AccessibilitySnapshotOptions{
+		InterestingOnly: true,
+	}
+}
+
+// Parse parses the accessibility snapshot options.
+func (o *AccessibilitySnapshotOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	obj := opts.ToObject(k6ext.Runtime(ctx))
+	for _, k := range obj.Keys() {
+		switch k {
+		case "root":
+			o.Root = obj.Get(k).String()
+		case "interestingOnly":
+			o.InterestingOnly = obj.Get(k).ToBoolean()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAXNode is a short way of writing a node of the accessibility tree
+// that the browser returns.
+type testAXNode struct {
+	id        string
+	role      string
+	name      string
+	ignored   bool
+	focusable bool
+	level     int
+	children  []string
+}
+
+func newTestAXTree(t *testing.T, nodes ...testAXNode) *axTree {
+	t.Helper()
+
+	value := func(v any) *accessibility.Value {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return &accessibility.Value{Value: b}
+	}
+	axNodes := make([]*accessibility.Node, 0, len(nodes))
+	for i, n := range nodes {
+		an := &accessibility.Node{
+			NodeID:           accessibility.NodeID(n.id),
+			Ignored:          n.ignored,
+			Role:             value(n.role),
+			Name:             value(n.name),
+			BackendDOMNodeID: cdp.BackendNodeID(i + 1),
+		}
+		if n.focusable {
+			an.Properties = append(an.Properties, &accessibility.Property{
+				Name: accessibility.PropertyNameFocusable, Value: value(true),
+			})
+		}
+		if n.level > 0 {
+			an.Properties = append(an.Properties, &accessibility.Property{
+				Name: accessibility.PropertyNameLevel, Value: value(n.level),
+			})
+		}
+		for _, c := range n.children {
+			an.ChildIDs = append(an.ChildIDs, accessibility.NodeID(c))
+		}
+		axNodes = append(axNodes, an)
+	}
+
+	return newAXTree(axNodes)
+}
+
+func TestAccessibilitySnapshotTree(t *testing.T) {
+	t.Parallel()
+
+	tree := newTestAXTree(t,
+		testAXNode{id: "1", role: "RootWebArea", name: "Shop", focusable: true, children: []string{"2"}},
+		testAXNode{id: "2", role: "generic", children: []string{"3", "5", "7"}},
+		testAXNode{id: "3", role: "heading", name: "Products", level: 1, children: []string{"4"}},
+		testAXNode{id: "4", role: "StaticText", name: "Products"},
+		testAXNode{id: "5", role: "button", name: "Buy", focusable: true, children: []string{"6"}},
+		testAXNode{id: "6", role: "StaticText", name: "Buy"},
+		testAXNode{id: "7", role: "none", ignored: true, children: []string{"8"}},
+		testAXNode{id: "8", role: "StaticText", name: "Footer"},
+	)
+
+	t.Run("interesting_only", func(t *testing.T) {
+		t.Parallel()
+
+		interesting := make(map[*axNode]bool)
+		collectInterestingNodes(interesting, tree.root, false)
+		nodes := serializeAXTree(tree.root, tree.root, interesting)
+		require.Len(t, nodes, 1)
+
+		root := nodes[0]
+		assert.Equal(t, "RootWebArea", root.Role)
+		assert.Equal(t, "Shop", root.Name)
+		require.Len(t, root.Children, 3)
+		assert.Equal(t, "heading", root.Children[0].Role)
+		assert.Equal(t, int64(1), root.Children[0].Level)
+		assert.Empty(t, root.Children[0].Children, "the text of a heading is part of the heading")
+		assert.Equal(t, "button", root.Children[1].Role)
+		assert.Empty(t, root.Children[1].Children)
+		assert.Equal(t, "StaticText", root.Children[2].Role)
+		assert.Equal(t, "Footer", root.Children[2].Name)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		t.Parallel()
+
+		nodes := serializeAXTree(tree.root, tree.root, nil)
+		require.Len(t, nodes, 1)
+		require.Len(t, nodes[0].Children, 1)
+
+		generic := nodes[0].Children[0]
+		assert.Equal(t, "generic", generic.Role)
+		require.Len(t, generic.Children, 3, "the ignored node should be left out")
+		assert.Equal(t, "StaticText", generic.Children[0].Children[0].Role)
+		assert.Equal(t, "Footer", generic.Children[2].Name)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		t.Parallel()
+
+		button := tree.byBackendNodeID[5]
+		interesting := make(map[*axNode]bool)
+		collectInterestingNodes(interesting, button, false)
+		nodes := serializeAXTree(button, button, interesting)
+		require.Len(t, nodes, 1)
+		assert.Equal(t, "Buy", nodes[0].Name)
+	})
+}
+
+func TestCheckAXTree(t *testing.T) {
+	t.Parallel()
+
+	all := map[AccessibilityRule]bool{
+		AccessibilityRuleImageAlt: true,
+		AccessibilityRuleLabel:    true,
+		AccessibilityRuleLandmark: true,
+	}
+
+	t.Run("violations", func(t *testing.T) {
+		t.Parallel()
+
+		tree := newTestAXTree(t,
+			testAXNode{id: "1", role: "RootWebArea", children: []string{"2", "3", "4", "5", "6", "7"}},
+			testAXNode{id: "2", role: "image"},
+			testAXNode{id: "3", role: "image", name: "Logo"},
+			testAXNode{id: "4", role: "textbox"},
+			testAXNode{id: "5", role: "textbox", name: "Email"},
+			testAXNode{id: "6", role: "banner"},
+			testAXNode{id: "7", role: "banner"},
+		)
+		violations := checkAXTree(tree, all)
+
+		var rules []AccessibilityRule
+		for _, v := range violations {
+			rules = append(rules, v.Rule)
+		}
+		assert.Equal(t, []AccessibilityRule{
+			AccessibilityRuleImageAlt,
+			AccessibilityRuleLabel,
+			AccessibilityRuleLandmark,
+			AccessibilityRuleLandmark,
+		}, rules)
+		assert.Equal(t, cdp.BackendNodeID(2), violations[0].backendNodeID)
+		assert.Equal(t, "textbox", violations[1].Role)
+		assert.Equal(t, "page has no main landmark", violations[2].Message)
+		assert.Contains(t, violations[3].Message, "2 banner landmarks")
+	})
+
+	t.Run("ignored", func(t *testing.T) {
+		t.Parallel()
+
+		tree := newTestAXTree(t,
+			testAXNode{id: "1", role: "RootWebArea", children: []string{"2", "3"}},
+			testAXNode{id: "2", role: "main"},
+			testAXNode{id: "3", role: "image", ignored: true},
+		)
+		assert.Empty(t, checkAXTree(tree, all))
+	})
+
+	t.Run("disabled_rules", func(t *testing.T) {
+		t.Parallel()
+
+		tree := newTestAXTree(t,
+			testAXNode{id: "1", role: "RootWebArea", children: []string{"2"}},
+			testAXNode{id: "2", role: "image"},
+		)
+		violations := checkAXTree(tree, map[AccessibilityRule]bool{AccessibilityRuleLabel: true})
+		assert.Empty(t, violations)
+	})
+}
+
+func TestAXValueString(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		raw  string
+		want string
+	}{
+		{raw: `"button"`, want: "button"},
+		{raw: `true`, want: "true"},
+		{raw: `2`, want: "2"},
+		{raw: `null`, want: ""},
+		{raw: ``, want: ""},
+	} {
+		assert.Equal(t, tt.want, axValueString(&accessibility.Value{Value: []byte(tt.raw)}), tt.raw)
+	}
+	assert.Empty(t, axValueString(nil))
+}
@@ -0,0 +1,180 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	cdpruntime "github.com/chromedp/cdproto/runtime"
+)
+
+// exposedBinding is the name of the CDP binding that the functions exposed
+// with exposeBinding and exposeFunction call into.
+const exposedBinding = "k6browserExposedBinding"
+
+// addBindingScript adds a function with the name to the window object.
+// The function sends its arguments to the exposedBinding and returns a
+// promise that is settled with the result delivered by deliverBindingScript.
+const addBindingScript = `(name) => {
+	const send = globalThis['` + exposedBinding + `'];
+	const key = '__k6browserBindings';
+	if (!globalThis[key]) {
+		globalThis[key] = { seq: 0, callbacks: new Map() };
+	}
+	const bindings = globalThis[key];
+	globalThis[name] = (...args) => {
+		const seq = ++bindings.seq;
+		const promise = new Promise((resolve, reject) => {
+			bindings.callbacks.set(seq, { resolve, reject });
+		});
+		send(JSON.stringify({ name, seq, args }));
+		return promise;
+	};
+}`
+
+// deliverBindingScript settles the promise of a call to an exposed function.
+const deliverBindingScript = `(seq, result, error) => {
+	const bindings = globalThis['__k6browserBindings'];
+	const callback = bindings.callbacks.get(seq);
+	bindings.callbacks.delete(seq);
+	if (error) {
+		callback.reject(new Error(error));
+	} else {
+		callback.resolve(result);
+	}
+}`
+
+// BindingSource is where an exposed binding is called from.
+type BindingSource struct {
+	Context *BrowserContext
+	Page    *Page
+	Frame   *Frame
+}
+
+// BindingFunc is called when the page calls an exposed binding. The
+// arguments and the returned value are serialized as in evaluate.
+type BindingFunc func(source *BindingSource, args []any) (any, error)
+
+// bindingCall is the payload that the page sends to the exposedBinding.
+type bindingCall struct {
+	Name string `json:"name"`
+	Seq  int64  `json:"seq"`
+	Args []any  `json:"args"`
+}
+
+// bindings holds the exposed bindings of a page or a browser context.
+type bindings struct {
+	mu sync.RWMutex
+	m  map[string]BindingFunc
+}
+
+// add adds the binding with the name. It returns false if there is
+// already a binding with the same name.
+func (b *bindings) add(name string, fn BindingFunc) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.m[name]; ok {
+		return false
+	}
+	if b.m == nil {
+		b.m = make(map[string]BindingFunc)
+	}
+	b.m[name] = fn
+
+	return true
+}
+
+func (b *bindings) get(name string) (BindingFunc, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	fn, ok := b.m[name]
+
+	return fn, ok
+}
+
+func (b *bindings) names() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	names := make([]string, 0, len(b.m))
+	for n := range b.m {
+		names = append(names, n)
+	}
+
+	return names
+}
+
+// installBinding adds the exposed binding with the name to the current
+// frames of the page and to the documents that are created afterwards,
+// e.g. after a navigation or in a new frame.
+func (p *Page) installBinding(name string) error {
+	b, err := json.Marshal(name)
+	if err != nil {
+		return fmt.Errorf("marshaling binding name: %w", err)
+	}
+	if err := p.evaluateOnNewDocument(fmt.Sprintf("(%s)(%s)", addBindingScript, b)); err != nil {
+		return err
+	}
+
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	for _, f := range p.frameManager.Frames() {
+		// Frames that don't have an execution context yet get the binding
+		// from the init script.
+		if _, err := f.evaluate(p.ctx, mainWorld, opts, addBindingScript, name); err != nil {
+			p.logger.Debugf("Page:installBinding", "sid:%v fid:%s name:%q err:%v",
+				p.sessionID(), f.ID(), name, err)
+		}
+	}
+
+	return nil
+}
+
+// onBindingCalled calls the exposed binding that the page called, and
+// delivers its result back to the page.
+func (p *Page) onBindingCalled(event *cdpruntime.EventBindingCalled) {
+	var call bindingCall
+	if err := json.Unmarshal([]byte(event.Payload), &call); err != nil {
+		p.logger.Errorf("Page:onBindingCalled", "parsing binding call: %v", err)
+		return
+	}
+	execCtx, err := p.executionContextForID(event.ExecutionContextID)
+	if err != nil {
+		p.logger.Errorf("Page:onBindingCalled", "name:%q: %v", call.Name, err)
+		return
+	}
+
+	fn, ok := p.bindings.get(call.Name)
+	if !ok {
+		fn, ok = p.browserCtx.bindings.get(call.Name)
+	}
+	var (
+		result any
+		errMsg string
+	)
+	if !ok {
+		errMsg = fmt.Sprintf("function %q is not exposed", call.Name)
+	} else {
+		source := &BindingSource{Context: p.browserCtx, Page: p, Frame: execCtx.Frame()}
+		if result, err = fn(source, call.Args); err != nil {
+			errMsg = err.Error()
+		}
+	}
+
+	opts := evalOptions{
+		forceCallable: true,
+		returnByValue: true,
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, p.defaultTimeout())
+	defer cancel()
+	if _, err := execCtx.eval(ctx, opts, deliverBindingScript, call.Seq, result, errMsg); err != nil {
+		// The frame might have navigated away while the binding was running.
+		p.logger.Debugf("Page:onBindingCalled", "sid:%v name:%q delivering result: %v",
+			p.sessionID(), call.Name, err)
+	}
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindings(t *testing.T) {
+	t.Parallel()
+
+	var b bindings
+	_, ok := b.get("add")
+	assert.False(t, ok)
+
+	add := func(_ *BindingSource, args []any) (any, error) {
+		return args[0].(float64) + args[1].(float64), nil //nolint:forcetypeassert
+	}
+	require.True(t, b.add("add", add))
+	assert.False(t, b.add("add", add), "should not add a binding with the same name twice")
+	assert.Equal(t, []string{"add"}, b.names())
+
+	fn, ok := b.get("add")
+	require.True(t, ok)
+	got, err := fn(nil, []any{1.0, 2.0})
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, got)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -65,6 +66,21 @@ type Browser struct {
 	sessionIDtoTargetIDMu sync.RWMutex
 	sessionIDtoTargetID   map[target.SessionID]target.ID
 
+	// downloads are the in progress downloads by their GUIDs.
+	downloadsMu sync.Mutex
+	downloads   map[string]*Download
+
+	// videos are the videos recorded by the pages of the browser.
+	// iterationFailed is set if the iteration that the browser runs in
+	// fails, so that the videos in the retain-on-failure mode are kept.
+	videosMu        sync.Mutex
+	videos          []*Video
+	iterationFailed bool
+
+	// tracing is the performance trace that is being captured, if any.
+	tracingMu sync.Mutex
+	tracing   *browserTracing
+
 	// Used to display a warning when the browser is reclosed.
 	closed bool
 
@@ -115,17 +131,20 @@ func newBrowser(
 	browserOpts *BrowserOptions,
 	logger *log.Logger,
 ) *Browser {
-	return &Browser{
-		ctx:                 ctx,
+	b := &Browser{
 		cancelFn:            cancelFn,
 		state:               int64(BrowserStateOpen),
 		browserProc:         browserProc,
 		browserOpts:         browserOpts,
 		pages:               make(map[target.ID]*Page),
 		sessionIDtoTargetID: make(map[target.SessionID]target.ID),
+		downloads:           make(map[string]*Download),
 		vu:                  k6ext.GetVU(ctx),
 		logger:              logger,
 	}
+	b.ctx = withBrowser(ctx, b)
+
+	return b
 }
 
 func (b *Browser) connect() error {
@@ -168,6 +187,57 @@ func (b *Browser) disposeContext(id cdp.BrowserContextID) error {
 	return nil
 }
 
+// onDownloadWillBegin emits the download event on the page that started
+// the download.
+func (b *Browser) onDownloadWillBegin(ev *cdpbrowser.EventDownloadWillBegin) {
+	b.logger.Debugf("Browser:onDownloadWillBegin", "guid:%s url:%s fid:%s", ev.GUID, ev.URL, ev.FrameID)
+
+	var page *Page
+	for _, p := range b.getPages() {
+		if _, ok := p.frameManager.getFrameByID(ev.FrameID); ok {
+			page = p
+			break
+		}
+	}
+	if page == nil || page.browserCtx == nil || page.browserCtx.downloadsDir == "" {
+		b.logger.Debugf("Browser:onDownloadWillBegin", "guid:%s no page accepts the download", ev.GUID)
+		return
+	}
+
+	d := newDownload(
+		b.ctx, b.logger, page, ev.GUID, ev.URL, ev.SuggestedFilename,
+		filepath.Join(page.browserCtx.downloadsDir, ev.GUID),
+	)
+	b.downloadsMu.Lock()
+	b.downloads[ev.GUID] = d
+	b.downloadsMu.Unlock()
+
+	page.emit(EventPageDownload, d)
+	page.callEventHandlers(EventPageDownload, PageOnEvent{Download: d})
+}
+
+// onDownloadProgress finishes the download once it's completed or canceled.
+func (b *Browser) onDownloadProgress(ev *cdpbrowser.EventDownloadProgress) {
+	var failure string
+	switch ev.State { //nolint:exhaustive
+	case cdpbrowser.DownloadProgressStateCompleted:
+	case cdpbrowser.DownloadProgressStateCanceled:
+		failure = "canceled"
+	default:
+		return
+	}
+	b.logger.Debugf("Browser:onDownloadProgress", "guid:%s state:%s", ev.GUID, ev.State)
+
+	b.downloadsMu.Lock()
+	d, ok := b.downloads[ev.GUID]
+	delete(b.downloads, ev.GUID)
+	b.downloadsMu.Unlock()
+
+	if ok {
+		d.finish(failure)
+	}
+}
+
 // getDefaultBrowserContextOrMatchedID returns the BrowserContext for the given browser context ID.
 // If the browser context is not found, the default BrowserContext is returned.
 func (b *Browser) getDefaultBrowserContextOrMatchedID(id cdp.BrowserContextID) *BrowserContext {
@@ -199,6 +269,8 @@ func (b *Browser) initEvents() error { //nolint:cyclop
 	b.conn.on(cancelCtx, []string{
 		cdproto.EventTargetAttachedToTarget,
 		cdproto.EventTargetDetachedFromTarget,
+		cdproto.EventBrowserDownloadWillBegin,
+		cdproto.EventBrowserDownloadProgress,
 		EventConnectionClose,
 	}, chHandler)
 
@@ -223,6 +295,10 @@ func (b *Browser) initEvents() error { //nolint:cyclop
 				} else if ev, ok := event.data.(*target.EventDetachedFromTarget); ok {
 					b.logger.Debugf("Browser:initEvents:onDetachedFromTarget", "sid:%v", ev.SessionID)
 					b.onDetachedFromTarget(ev)
+				} else if ev, ok := event.data.(*cdpbrowser.EventDownloadWillBegin); ok {
+					b.onDownloadWillBegin(ev)
+				} else if ev, ok := event.data.(*cdpbrowser.EventDownloadProgress); ok {
+					b.onDownloadProgress(ev)
 				} else if event.typ == EventConnectionClose {
 					b.logger.Debugf("Browser:initEvents:EventConnectionClose", "")
 					return
@@ -314,6 +390,12 @@ func (b *Browser) onAttachedToTarget(ev *target.EventAttachedToTarget) error {
 	// Background pages are created by extensions.
 	if isPage {
 		browserCtx.emit(EventBrowserContextPage, p)
+		browserCtx.callPageHandlers(p)
+	}
+	// A page that is opened by another page is a popup of the opener.
+	if opener != nil {
+		opener.emit(EventPagePopup, p)
+		opener.callEventHandlers(EventPagePopup, PageOnEvent{Page: p})
 	}
 
 	return nil
@@ -506,6 +588,19 @@ func (b *Browser) Close() {
 	}()
 
 	b.logger.Debugf("Browser:Close", "")
+
+	// The browser context might not have been closed by the user, so save
+	// the HAR of its requests before the browser goes away.
+	if bctx := b.Context(); bctx != nil {
+		if err := bctx.saveHAR(); err != nil {
+			b.logger.Errorf("Browser:Close", "%v", err)
+		}
+	}
+
+	// The pages might still be open, so stop recording their videos and
+	// persist the ones that should be kept.
+	b.saveVideos()
+
 	atomic.CompareAndSwapInt64(&b.state, b.state, BrowserStateClosed)
 
 	// Signal to the connection and the process that we're gracefully closing.
@@ -557,6 +652,41 @@ func (b *Browser) CloseContext() error {
 	return b.context.Close()
 }
 
+// MarkIterationFailed marks the iteration that the browser runs in as
+// failed. The videos that are recorded in the retain-on-failure mode are
+// only persisted if the iteration fails.
+func (b *Browser) MarkIterationFailed() {
+	b.videosMu.Lock()
+	defer b.videosMu.Unlock()
+
+	b.iterationFailed = true
+}
+
+func (b *Browser) addVideo(v *Video) {
+	b.videosMu.Lock()
+	defer b.videosMu.Unlock()
+
+	b.videos = append(b.videos, v)
+}
+
+// saveVideos stops the video recordings and persists the videos that
+// should be kept.
+func (b *Browser) saveVideos() {
+	b.videosMu.Lock()
+	defer b.videosMu.Unlock()
+
+	sp := GetFilePersister(b.ctx)
+	for _, v := range b.videos {
+		v.stop()
+		if v.mode == VideoModeRetainOnFailure && !b.iterationFailed {
+			continue
+		}
+		if err := v.persist(sp); err != nil {
+			b.logger.Errorf("Browser:saveVideos", "%v", err)
+		}
+	}
+}
+
 // Context returns the current browser context or nil.
 func (b *Browser) Context() *BrowserContext {
 	return b.context
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	cdpbrowser "github.com/chromedp/cdproto/browser"
@@ -84,6 +85,21 @@ type BrowserContext struct {
 	vu              k6modules.VU
 
 	evaluateOnNewDocumentSources []string
+
+	routes   routes
+	bindings bindings
+
+	harRecordersMu sync.RWMutex
+	harRecorders   []*harRecorder
+
+	tracing *Tracing
+
+	// downloadsDir is where the browser saves the downloaded files
+	// if downloads are accepted.
+	downloadsDir string
+
+	pageHandlersMu sync.RWMutex
+	pageHandlers   []func(*Page)
 }
 
 // NewBrowserContext creates a new browser context.
@@ -99,6 +115,17 @@ func NewBrowserContext(
 		logger:           logger,
 		vu:               k6ext.GetVU(ctx),
 		timeoutSettings:  NewTimeoutSettings(nil),
+		tracing:          NewTracing(ctx, logger),
+	}
+
+	if opts != nil && opts.RecordHAR != nil {
+		b.addHARRecorder(newHARRecorder(opts.RecordHAR))
+	}
+
+	if opts != nil && opts.AcceptDownloads {
+		if err := b.acceptDownloads(); err != nil {
+			return nil, err
+		}
 	}
 
 	if opts != nil && len(opts.Permissions) > 0 {
@@ -114,6 +141,11 @@ func NewBrowserContext(
 	if err := b.AddInitScript(js.WebVitalInitScript); err != nil {
 		return nil, fmt.Errorf("adding web vital init script to new browser context: %w", err)
 	}
+	if opts != nil && opts.UserMetrics != nil {
+		if err := b.AddInitScript(js.UserMetricsScript); err != nil {
+			return nil, fmt.Errorf("adding user metrics script to new browser context: %w", err)
+		}
+	}
 
 	return &b, nil
 }
@@ -167,12 +199,106 @@ func (b *BrowserContext) Close() error {
 	if b.id == "" {
 		return fmt.Errorf("default browser context can't be closed")
 	}
+	if err := b.saveHAR(); err != nil {
+		return err
+	}
 	if err := b.browser.disposeContext(b.id); err != nil {
 		return fmt.Errorf("disposing browser context: %w", err)
 	}
 	return nil
 }
 
+// acceptDownloads makes the browser save the downloaded files into a
+// temporary directory and report the download progress.
+func (b *BrowserContext) acceptDownloads() error {
+	dir, err := b.browser.browserProc.makeTempDir()
+	if err != nil {
+		return fmt.Errorf("making downloads directory: %w", err)
+	}
+
+	action := cdpbrowser.SetDownloadBehavior(cdpbrowser.SetDownloadBehaviorBehaviorAllowAndName).
+		WithDownloadPath(dir).
+		WithEventsEnabled(true)
+	if b.id != "" {
+		action = action.WithBrowserContextID(b.id)
+	}
+	if err := action.Do(cdp.WithExecutor(b.ctx, b.browser.conn)); err != nil {
+		return fmt.Errorf("setting download behavior: %w", err)
+	}
+	b.downloadsDir = dir
+
+	return nil
+}
+
+// callPageHandlers calls the handlers registered with browserContext.on
+// for the page event in the order that they were registered.
+func (b *BrowserContext) callPageHandlers(p *Page) {
+	b.pageHandlersMu.RLock()
+	defer b.pageHandlersMu.RUnlock()
+
+	for _, h := range b.pageHandlers {
+		h(p)
+	}
+}
+
+func (b *BrowserContext) addHARRecorder(r *harRecorder) {
+	b.harRecordersMu.Lock()
+	defer b.harRecordersMu.Unlock()
+
+	b.harRecorders = append(b.harRecorders, r)
+}
+
+// recordHAR records the request of the page into the HARs of the
+// browser context, if any.
+func (b *BrowserContext) recordHAR(p *Page, req *Request) {
+	b.harRecordersMu.RLock()
+	defer b.harRecordersMu.RUnlock()
+
+	for _, r := range b.harRecorders {
+		r.record(p, req)
+	}
+	if rec := b.tracing.recorder(); rec != nil {
+		rec.har.record(p, req)
+	}
+}
+
+// saveHAR persists the HARs of the recorded requests, if any.
+func (b *BrowserContext) saveHAR() error {
+	b.harRecordersMu.RLock()
+	defer b.harRecordersMu.RUnlock()
+
+	for _, r := range b.harRecorders {
+		if err := r.save(b.ctx, GetFilePersister(b.ctx), b.browser.Version()); err != nil {
+			return fmt.Errorf("saving HAR: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ExposeBinding is like Page.ExposeBinding but adds the function to every
+// page of the browser context.
+func (b *BrowserContext) ExposeBinding(name string, fn BindingFunc) error {
+	b.logger.Debugf("BrowserContext:ExposeBinding", "bctxid:%v name:%q", b.id, name)
+
+	pages := b.Pages()
+	for _, p := range pages {
+		if _, ok := p.bindings.get(name); ok {
+			return fmt.Errorf("function %q has been already registered in one of the pages", name)
+		}
+	}
+	if !b.bindings.add(name, fn) {
+		return fmt.Errorf("function %q has been already registered", name)
+	}
+	for _, p := range pages {
+		if err := p.installBinding(name); err != nil {
+			return fmt.Errorf("exposing binding %q to page: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
 // GrantPermissions enables the specified permissions, all others will be disabled.
 func (b *BrowserContext) GrantPermissions(permissions []string, opts *GrantPermissionsOptions) error {
 	b.logger.Debugf("BrowserContext:GrantPermissions", "bctxid:%v", b.id)
@@ -240,11 +366,59 @@ func (b *BrowserContext) NewPage() (*Page, error) {
 	return p, nil
 }
 
+// On subscribes to a browser context event for which the given handler
+// will be executed passing in the page of the event. The only accepted
+// event value is 'page'.
+func (b *BrowserContext) On(event string, handler func(*Page)) error {
+	if event != EventBrowserContextPage {
+		return fmt.Errorf("unknown browser context event: %q", event)
+	}
+
+	b.pageHandlersMu.Lock()
+	defer b.pageHandlersMu.Unlock()
+
+	b.pageHandlers = append(b.pageHandlers, handler)
+
+	return nil
+}
+
 // Pages returns a list of pages inside this browser context.
 func (b *BrowserContext) Pages() []*Page {
 	return append([]*Page{}, b.browser.getPages()...)
 }
 
+// Route registers a handler that intercepts the requests of all the
+// browser context pages whose URL matches the given matcher.
+func (b *BrowserContext) Route(matcher *URLMatcher, handler RouteHandler) error {
+	b.logger.Debugf("BrowserContext:Route", "bctxid:%v url:%s", b.id, matcher)
+
+	b.routes.add(matcher, handler)
+
+	return b.updateRequestInterception()
+}
+
+// RouteFromHAR serves the requests from the entries of the HAR file.
+// If update is set, it records the requests into the HAR file instead.
+func (b *BrowserContext) RouteFromHAR(path string, opts *RouteFromHAROptions) error {
+	b.logger.Debugf("BrowserContext:RouteFromHAR", "bctxid:%v path:%s", b.id, path)
+
+	if opts.Update {
+		b.addHARRecorder(newHARRecorder(&RecordHAROptions{
+			Path:      path,
+			Content:   HARContentEmbed,
+			URLFilter: opts.URL,
+		}))
+		return nil
+	}
+
+	router, err := newHARRouter(b.logger, path, opts.NotFound)
+	if err != nil {
+		return err
+	}
+
+	return b.Route(opts.URL, router.handle)
+}
+
 // SetDefaultNavigationTimeout sets the default navigation timeout in milliseconds.
 func (b *BrowserContext) SetDefaultNavigationTimeout(timeout int64) {
 	b.logger.Debugf("BrowserContext:SetDefaultNavigationTimeout", "bctxid:%v timeout:%d", b.id, timeout)
@@ -326,6 +500,31 @@ func (b *BrowserContext) Timeout() time.Duration {
 	return b.timeoutSettings.timeout()
 }
 
+// Tracing returns the tracing of the browser context, which records the
+// trace archives of its actions.
+func (b *BrowserContext) Tracing() *Tracing {
+	return b.tracing
+}
+
+// Unroute removes the route handlers registered with the given URL matcher.
+func (b *BrowserContext) Unroute(matcher *URLMatcher) error {
+	b.logger.Debugf("BrowserContext:Unroute", "bctxid:%v url:%s", b.id, matcher)
+
+	b.routes.remove(matcher)
+
+	return b.updateRequestInterception()
+}
+
+func (b *BrowserContext) updateRequestInterception() error {
+	for _, p := range b.browser.getPages() {
+		if err := p.updateRequestInterception(); err != nil {
+			return fmt.Errorf("updating request interception in target ID %s: %w", p.targetID, err)
+		}
+	}
+
+	return nil
+}
+
 // WaitForEvent waits for event.
 func (b *BrowserContext) WaitForEvent(event string, f func(p *Page) (bool, error), timeout time.Duration) (any, error) {
 	b.logger.Debugf("BrowserContext:WaitForEvent", "bctxid:%v event:%q", b.id, event)
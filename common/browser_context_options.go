@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/grafana/sobek"
+	"go.opentelemetry.io/otel/baggage"
 
 	"github.com/grafana/xk6-browser/k6ext"
 )
@@ -61,28 +64,287 @@ func (g *Geolocation) Parse(ctx context.Context, opts sobek.Value) error { //nol
 	return nil
 }
 
+// RecordHAROptions are the options to record the requests of a browser
+// context into a HAR file.
+type RecordHAROptions struct {
+	Path      string           `js:"path"`
+	Content   HARContentPolicy `js:"content"`
+	URLFilter *URLMatcher      `js:"urlFilter"`
+}
+
+// NewRecordHAROptions returns a new RecordHAROptions that embeds the
+// response bodies into the HAR file.
+func NewRecordHAROptions() *RecordHAROptions {
+	return &RecordHAROptions{
+		Content: HARContentEmbed,
+	}
+}
+
+// Parse parses the HAR recording options.
+func (r *RecordHAROptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	o := opts.ToObject(rt)
+	for _, k := range o.Keys() {
+		v := o.Get(k)
+		switch k {
+		case "path":
+			r.Path = v.String()
+		case "content":
+			switch c := HARContentPolicy(v.String()); c {
+			case HARContentOmit, HARContentEmbed, HARContentAttach:
+				r.Content = c
+			default:
+				return fmt.Errorf("invalid content %q, must be one of 'omit', 'embed' or 'attach'", c)
+			}
+		case "urlFilter":
+			m, err := NewURLMatcher(ctx, v)
+			if err != nil {
+				return fmt.Errorf("parsing urlFilter: %w", err)
+			}
+			r.URLFilter = m
+		}
+	}
+	if r.Path == "" {
+		return errors.New("path is required")
+	}
+
+	return nil
+}
+
+// RecordVideoOptions are the options to record videos of the pages of a
+// browser context.
+type RecordVideoOptions struct {
+	Dir  string    `js:"dir"`
+	Size *Viewport `js:"size"`
+	Mode VideoMode `js:"mode"`
+}
+
+// NewRecordVideoOptions returns a new RecordVideoOptions that keeps all
+// the videos.
+func NewRecordVideoOptions() *RecordVideoOptions {
+	return &RecordVideoOptions{
+		Mode: VideoModeOn,
+	}
+}
+
+// Parse parses the video recording options.
+func (r *RecordVideoOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	o := opts.ToObject(rt)
+	for _, k := range o.Keys() {
+		v := o.Get(k)
+		switch k {
+		case "dir":
+			r.Dir = v.String()
+		case "size":
+			size := &Viewport{}
+			if err := size.Parse(ctx, v); err != nil {
+				return fmt.Errorf("parsing size: %w", err)
+			}
+			if size.Width <= 0 || size.Height <= 0 {
+				return fmt.Errorf("invalid size %s, width and height must be positive", size)
+			}
+			r.Size = size
+		case "mode":
+			switch m := VideoMode(v.String()); m {
+			case VideoModeOn, VideoModeRetainOnFailure:
+				r.Mode = m
+			default:
+				return fmt.Errorf("invalid mode %q, must be one of 'on' or 'retain-on-failure'", m)
+			}
+		}
+	}
+	if r.Dir == "" {
+		return errors.New("dir is required")
+	}
+
+	return nil
+}
+
+// TraceContextOptions are the options to propagate the W3C trace context
+// of the active span to the requests of the pages of a browser context,
+// so that the traces of the backend services can be linked to it.
+type TraceContextOptions struct {
+	// Origins are the origins, such as https://api.example.com, of the
+	// requests that the trace context is propagated to. "*" matches all
+	// the origins.
+	Origins []string `js:"origins"`
+	// Baggage is propagated with the baggage header if it's not empty.
+	Baggage map[string]string `js:"baggage"`
+
+	baggage baggage.Baggage
+}
+
+// Parse parses the trace context options.
+func (t *TraceContextOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	o := opts.ToObject(rt)
+	for _, k := range o.Keys() {
+		switch k {
+		case "origins":
+			var origins []string
+			if err := rt.ExportTo(o.Get(k), &origins); err != nil {
+				return fmt.Errorf("parsing origins: %w", err)
+			}
+			for _, origin := range origins {
+				if origin == "*" {
+					t.Origins = append(t.Origins, origin)
+					continue
+				}
+				u, err := url.Parse(origin)
+				if err != nil || u.Scheme == "" || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+					return fmt.Errorf("invalid origin %q, must be a scheme and a host such as https://example.com", origin)
+				}
+				t.Origins = append(t.Origins, strings.ToLower(u.Scheme+"://"+u.Host))
+			}
+		case "baggage":
+			if err := rt.ExportTo(o.Get(k), &t.Baggage); err != nil {
+				return fmt.Errorf("parsing baggage: %w", err)
+			}
+		}
+	}
+	if len(t.Origins) == 0 {
+		return errors.New("origins is required")
+	}
+
+	members := make([]baggage.Member, 0, len(t.Baggage))
+	for k, v := range t.Baggage {
+		m, err := baggage.NewMemberRaw(k, v)
+		if err != nil {
+			return fmt.Errorf("invalid baggage member %q: %w", k, err)
+		}
+		members = append(members, m)
+	}
+	b, err := baggage.New(members...)
+	if err != nil {
+		return fmt.Errorf("invalid baggage: %w", err)
+	}
+	t.baggage = b
+
+	return nil
+}
+
+// propagatesTo returns true if the trace context is propagated to the
+// requests to the URL.
+func (t *TraceContextOptions) propagatesTo(u *url.URL) bool {
+	origin := strings.ToLower(u.Scheme + "://" + u.Host)
+	for _, o := range t.Origins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DefaultUserMetricsPrefix is the prefix of the names of the metrics of
+// the performance marks and measures.
+const DefaultUserMetricsPrefix = "browser_user_timing_"
+
+// DefaultUserMetricsPagePrefix is the prefix of the names of the metrics
+// that the pages emit with window.k6.
+const DefaultUserMetricsPagePrefix = "browser_page_"
+
+// UserMetricsOptions are the options to emit the metrics that the pages of
+// a browser context define: the performance marks and measures, and the
+// metrics that are emitted with window.k6.
+type UserMetricsOptions struct {
+	// Prefix is prepended to the names of the marks and measures to
+	// name their metrics.
+	Prefix string `js:"prefix"`
+	// PagePrefix is prepended to the names of the metrics that are
+	// emitted with window.k6, so that the pages can't emit samples
+	// of the other metrics of the test.
+	PagePrefix string `js:"pagePrefix"`
+}
+
+// NewUserMetricsOptions returns the default user metrics options.
+func NewUserMetricsOptions() *UserMetricsOptions {
+	return &UserMetricsOptions{
+		Prefix:     DefaultUserMetricsPrefix,
+		PagePrefix: DefaultUserMetricsPagePrefix,
+	}
+}
+
+// Parse parses the user metrics options.
+func (u *UserMetricsOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	o := opts.ToObject(rt)
+	for _, k := range o.Keys() {
+		switch k {
+		case "prefix":
+			u.Prefix = o.Get(k).String()
+		case "pagePrefix":
+			u.PagePrefix = o.Get(k).String()
+		}
+	}
+	for _, prefix := range []string{u.Prefix, u.PagePrefix} {
+		if !isMetricNamePrefix(prefix) {
+			return fmt.Errorf("invalid prefix %q, must only include ASCII letters, numbers, or underscores "+
+				"and start with a letter or an underscore", prefix)
+		}
+	}
+
+	return nil
+}
+
+func isMetricNamePrefix(s string) bool {
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return s != ""
+}
+
 // BrowserContextOptions stores browser context options.
 type BrowserContextOptions struct {
-	AcceptDownloads   bool              `js:"acceptDownloads"`
-	BypassCSP         bool              `js:"bypassCSP"`
-	ColorScheme       ColorScheme       `js:"colorScheme"`
-	DeviceScaleFactor float64           `js:"deviceScaleFactor"`
-	ExtraHTTPHeaders  map[string]string `js:"extraHTTPHeaders"`
-	Geolocation       *Geolocation      `js:"geolocation"`
-	HasTouch          bool              `js:"hasTouch"`
-	HttpCredentials   *Credentials      `js:"httpCredentials"`
-	IgnoreHTTPSErrors bool              `js:"ignoreHTTPSErrors"`
-	IsMobile          bool              `js:"isMobile"`
-	JavaScriptEnabled bool              `js:"javaScriptEnabled"`
-	Locale            string            `js:"locale"`
-	Offline           bool              `js:"offline"`
-	Permissions       []string          `js:"permissions"`
-	ReducedMotion     ReducedMotion     `js:"reducedMotion"`
-	Screen            *Screen           `js:"screen"`
-	TimezoneID        string            `js:"timezoneID"`
-	UserAgent         string            `js:"userAgent"`
-	VideosPath        string            `js:"videosPath"`
-	Viewport          *Viewport         `js:"viewport"`
+	AcceptDownloads   bool                  `js:"acceptDownloads"`
+	BypassCSP         bool                  `js:"bypassCSP"`
+	ColorScheme       ColorScheme           `js:"colorScheme"`
+	DeviceScaleFactor float64               `js:"deviceScaleFactor"`
+	ExtraHTTPHeaders  map[string]string     `js:"extraHTTPHeaders"`
+	Geolocation       *Geolocation          `js:"geolocation"`
+	HasTouch          bool                  `js:"hasTouch"`
+	HttpCredentials   *Credentials          `js:"httpCredentials"`
+	IgnoreHTTPSErrors bool                  `js:"ignoreHTTPSErrors"`
+	IsMobile          bool                  `js:"isMobile"`
+	JavaScriptEnabled bool                  `js:"javaScriptEnabled"`
+	Locale            string                `js:"locale"`
+	Offline           bool                  `js:"offline"`
+	Permissions       []string              `js:"permissions"`
+	RecordHAR         *RecordHAROptions     `js:"recordHar"`
+	RecordVideo       *RecordVideoOptions   `js:"recordVideo"`
+	ReducedMotion     ReducedMotion         `js:"reducedMotion"`
+	RuntimeMetrics    bool                  `js:"runtimeMetrics"`
+	Screen            *Screen               `js:"screen"`
+	TimezoneID        string                `js:"timezoneID"`
+	TraceContext      *TraceContextOptions  `js:"traceContext"`
+	UserAgent         string                `js:"userAgent"`
+	UserMetrics       *UserMetricsOptions   `js:"userMetrics"`
+	VideosPath        string                `js:"videosPath"`
+	Viewport          *Viewport             `js:"viewport"`
+	VisualMetrics     *VisualMetricsOptions `js:"visualMetrics"`
 }
 
 // NewBrowserContextOptions creates a default set of browser context options.
@@ -159,6 +421,18 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts sobek.Value) err
 					b.Permissions = append(b.Permissions, fmt.Sprintf("%v", p))
 				}
 			}
+		case "recordHar":
+			recordHAR := NewRecordHAROptions()
+			if err := recordHAR.Parse(ctx, o.Get(k)); err != nil {
+				return fmt.Errorf("parsing recordHar options: %w", err)
+			}
+			b.RecordHAR = recordHAR
+		case "recordVideo":
+			recordVideo := NewRecordVideoOptions()
+			if err := recordVideo.Parse(ctx, o.Get(k)); err != nil {
+				return fmt.Errorf("parsing recordVideo options: %w", err)
+			}
+			b.RecordVideo = recordVideo
 		case "reducedMotion":
 			switch ReducedMotion(o.Get(k).String()) { //nolint:exhaustive
 			case "reduce":
@@ -166,6 +440,8 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts sobek.Value) err
 			default:
 				b.ReducedMotion = ReducedMotionNoPreference
 			}
+		case "runtimeMetrics":
+			b.RuntimeMetrics = o.Get(k).ToBoolean()
 		case "screen":
 			screen := &Screen{}
 			if err := screen.Parse(ctx, o.Get(k).ToObject(rt)); err != nil {
@@ -174,16 +450,48 @@ func (b *BrowserContextOptions) Parse(ctx context.Context, opts sobek.Value) err
 			b.Screen = screen
 		case "timezoneID":
 			b.TimezoneID = o.Get(k).String()
+		case "traceContext":
+			traceContext := &TraceContextOptions{}
+			if err := traceContext.Parse(ctx, o.Get(k)); err != nil {
+				return fmt.Errorf("parsing traceContext options: %w", err)
+			}
+			b.TraceContext = traceContext
 		case "userAgent":
 			b.UserAgent = o.Get(k).String()
+		case "userMetrics":
+			// userMetrics can be true to use the default options.
+			if enabled, ok := o.Get(k).Export().(bool); ok {
+				if enabled {
+					b.UserMetrics = NewUserMetricsOptions()
+				}
+				continue
+			}
+			userMetrics := NewUserMetricsOptions()
+			if err := userMetrics.Parse(ctx, o.Get(k)); err != nil {
+				return fmt.Errorf("parsing userMetrics options: %w", err)
+			}
+			b.UserMetrics = userMetrics
+		case "videosPath":
+			b.VideosPath = o.Get(k).String()
 		case "viewport":
 			viewport := &Viewport{}
 			if err := viewport.Parse(ctx, o.Get(k).ToObject(rt)); err != nil {
 				return err
 			}
 			b.Viewport = viewport
+		case "visualMetrics":
+			visualMetrics, err := parseVisualMetricsOptions(ctx, o.Get(k))
+			if err != nil {
+				return fmt.Errorf("parsing visualMetrics options: %w", err)
+			}
+			b.VisualMetrics = visualMetrics
 		}
 	}
+	// videosPath is a shorthand for recordVideo with only a directory.
+	if b.VideosPath != "" && b.RecordVideo == nil {
+		b.RecordVideo = NewRecordVideoOptions()
+		b.RecordVideo.Dir = b.VideosPath
+	}
 
 	return nil
 }
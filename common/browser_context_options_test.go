@@ -1,6 +1,7 @@
 package common
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/grafana/xk6-browser/k6ext/k6test"
@@ -21,3 +22,191 @@ func TestBrowserContextOptionsPermissions(t *testing.T) {
 	assert.Len(t, opts.Permissions, 2)
 	assert.Equal(t, opts.Permissions, []string{"camera", "microphone"})
 }
+
+func TestBrowserContextOptionsRecordHAR(t *testing.T) {
+	vu := k6test.NewVU(t)
+
+	var opts BrowserContextOptions
+	err := opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		RecordHAR map[string]any `js:"recordHar"`
+	}{
+		RecordHAR: map[string]any{
+			"path":      "requests.har",
+			"content":   "attach",
+			"urlFilter": "**/api/**",
+		},
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, "requests.har", opts.RecordHAR.Path)
+	assert.Equal(t, HARContentAttach, opts.RecordHAR.Content)
+	assert.True(t, opts.RecordHAR.URLFilter.Match("https://test.k6.io/api/v1"))
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		RecordHAR map[string]any `js:"recordHar"`
+	}{
+		RecordHAR: map[string]any{"content": "embed"},
+	})))
+	assert.ErrorContains(t, err, "path is required")
+}
+
+func TestBrowserContextOptionsRecordVideo(t *testing.T) {
+	vu := k6test.NewVU(t)
+
+	var opts BrowserContextOptions
+	err := opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		RecordVideo map[string]any `js:"recordVideo"`
+	}{
+		RecordVideo: map[string]any{
+			"dir":  "videos",
+			"size": map[string]any{"width": 640, "height": 480},
+			"mode": "retain-on-failure",
+		},
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, "videos", opts.RecordVideo.Dir)
+	assert.Equal(t, &Viewport{Width: 640, Height: 480}, opts.RecordVideo.Size)
+	assert.Equal(t, VideoModeRetainOnFailure, opts.RecordVideo.Mode)
+
+	opts = BrowserContextOptions{}
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		VideosPath string `js:"videosPath"`
+	}{
+		VideosPath: "videos",
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, "videos", opts.RecordVideo.Dir)
+	assert.Nil(t, opts.RecordVideo.Size)
+	assert.Equal(t, VideoModeOn, opts.RecordVideo.Mode)
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		RecordVideo map[string]any `js:"recordVideo"`
+	}{
+		RecordVideo: map[string]any{"dir": "videos", "mode": "off"},
+	})))
+	assert.ErrorContains(t, err, `invalid mode "off"`)
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		RecordVideo map[string]any `js:"recordVideo"`
+	}{
+		RecordVideo: map[string]any{"mode": "on"},
+	})))
+	assert.ErrorContains(t, err, "dir is required")
+}
+
+func TestBrowserContextOptionsUserMetrics(t *testing.T) {
+	vu := k6test.NewVU(t)
+
+	var opts BrowserContextOptions
+	err := opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		UserMetrics bool `js:"userMetrics"`
+	}{
+		UserMetrics: true,
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultUserMetricsPrefix, opts.UserMetrics.Prefix)
+	assert.Equal(t, DefaultUserMetricsPagePrefix, opts.UserMetrics.PagePrefix)
+
+	opts = BrowserContextOptions{}
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		UserMetrics bool `js:"userMetrics"`
+	}{
+		UserMetrics: false,
+	})))
+	assert.NoError(t, err)
+	assert.Nil(t, opts.UserMetrics)
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		UserMetrics map[string]any `js:"userMetrics"`
+	}{
+		UserMetrics: map[string]any{"prefix": "app_", "pagePrefix": "app_page_"},
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, "app_", opts.UserMetrics.Prefix)
+	assert.Equal(t, "app_page_", opts.UserMetrics.PagePrefix)
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		UserMetrics map[string]any `js:"userMetrics"`
+	}{
+		UserMetrics: map[string]any{"prefix": "app-"},
+	})))
+	assert.ErrorContains(t, err, `invalid prefix "app-"`)
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		UserMetrics map[string]any `js:"userMetrics"`
+	}{
+		UserMetrics: map[string]any{"pagePrefix": ""},
+	})))
+	assert.ErrorContains(t, err, `invalid prefix ""`)
+}
+
+func TestBrowserContextOptionsTraceContext(t *testing.T) {
+	vu := k6test.NewVU(t)
+
+	var opts BrowserContextOptions
+	err := opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		TraceContext map[string]any `js:"traceContext"`
+	}{
+		TraceContext: map[string]any{
+			"origins": []any{"https://API.example.com/", "http://localhost:8080"},
+			"baggage": map[string]any{"tenant": "k6"},
+		},
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://api.example.com", "http://localhost:8080"}, opts.TraceContext.Origins)
+	assert.Equal(t, "tenant=k6", opts.TraceContext.baggage.String())
+
+	for rawURL, want := range map[string]bool{
+		"https://api.example.com/v1/users": true,
+		"http://api.example.com/v1/users":  false,
+		"https://example.com":              false,
+		"http://localhost:8080/health":     true,
+		"http://localhost:8081/health":     false,
+	} {
+		u, err := url.Parse(rawURL)
+		assert.NoError(t, err)
+		assert.Equal(t, want, opts.TraceContext.propagatesTo(u), rawURL)
+	}
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		TraceContext map[string]any `js:"traceContext"`
+	}{
+		TraceContext: map[string]any{"origins": []any{"example.com"}},
+	})))
+	assert.ErrorContains(t, err, `invalid origin "example.com"`)
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		TraceContext map[string]any `js:"traceContext"`
+	}{
+		TraceContext: map[string]any{"baggage": map[string]any{"tenant": "k6"}},
+	})))
+	assert.ErrorContains(t, err, "origins is required")
+}
+
+func TestBrowserContextOptionsVisualMetrics(t *testing.T) {
+	vu := k6test.NewVU(t)
+
+	var opts BrowserContextOptions
+	err := opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		VisualMetrics bool `js:"visualMetrics"`
+	}{
+		VisualMetrics: true,
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, &VisualMetricsOptions{}, opts.VisualMetrics)
+
+	opts = BrowserContextOptions{}
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		VisualMetrics map[string]any `js:"visualMetrics"`
+	}{
+		VisualMetrics: map[string]any{"filmstripDir": "filmstrip"},
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, &VisualMetricsOptions{FilmstripDir: "filmstrip"}, opts.VisualMetrics)
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue((struct {
+		VisualMetrics string `js:"visualMetrics"`
+	}{
+		VisualMetrics: "yes",
+	})))
+	assert.ErrorContains(t, err, "parsing visualMetrics options")
+}
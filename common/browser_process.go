@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/grafana/xk6-browser/log"
 	"github.com/grafana/xk6-browser/storage"
@@ -21,6 +22,11 @@ type BrowserProcess struct {
 
 	meta browserProcessMeta
 
+	// tempDirs are removed in Cleanup, e.g. the downloads directories
+	// of the browser contexts.
+	tempDirsMu sync.Mutex
+	tempDirs   []*storage.Dir
+
 	// Channels for managing termination.
 	lostConnection             chan struct{}
 	processIsGracefullyClosing chan struct{}
@@ -139,9 +145,34 @@ func (p *BrowserProcess) Pid() int {
 }
 
 // Cleanup cleans up the metadata associated with the browser
-// process, mainly the browser data directory.
+// process, mainly the browser data directory, and the temporary
+// directories made by the browser process.
 func (p *BrowserProcess) Cleanup() error {
-	return p.meta.Cleanup() //nolint:wrapcheck
+	p.tempDirsMu.Lock()
+	defer p.tempDirsMu.Unlock()
+
+	errs := []error{p.meta.Cleanup()}
+	for _, d := range p.tempDirs {
+		errs = append(errs, d.Cleanup())
+	}
+	p.tempDirs = nil
+
+	return errors.Join(errs...)
+}
+
+// makeTempDir makes a temporary directory that is removed on Cleanup.
+func (p *BrowserProcess) makeTempDir() (string, error) {
+	var d storage.Dir
+	if err := d.Make("", nil); err != nil {
+		return "", fmt.Errorf("making temporary directory: %w", err)
+	}
+
+	p.tempDirsMu.Lock()
+	defer p.tempDirsMu.Unlock()
+
+	p.tempDirs = append(p.tempDirs, &d)
+
+	return d.Dir, nil
 }
 
 type command struct {
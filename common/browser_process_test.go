@@ -185,3 +185,15 @@ func TestParseDevToolsURL(t *testing.T) {
 		})
 	}
 }
+
+func TestBrowserProcessCleanupTempDirs(t *testing.T) {
+	t.Parallel()
+
+	p := &BrowserProcess{meta: newRemoteBrowserProcessMeta()}
+	dir, err := p.makeTempDir()
+	require.NoError(t, err)
+	assert.DirExists(t, dir)
+
+	require.NoError(t, p.Cleanup())
+	assert.NoDirExists(t, dir)
+}
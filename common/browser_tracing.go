@@ -0,0 +1,261 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/cdp"
+	cdpio "github.com/chromedp/cdproto/io"
+	"github.com/chromedp/cdproto/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// browserTracing is a Chromium performance trace that is being captured.
+type browserTracing struct {
+	// target is the page session if the trace is captured for a page,
+	// or the browser connection otherwise.
+	target executorEmitter
+	page   *Page
+	opts   *TracingOptions
+
+	// poorWebVitals is set if a web vital is rated poor while tracing.
+	poorWebVitals atomic.Bool
+}
+
+// StartTracing starts capturing a performance trace of the browser, which
+// can be opened in the DevTools performance panel. The screenshots are
+// recorded for the page if it's set.
+func (b *Browser) StartTracing(page *Page, opts *TracingOptions) error {
+	b.logger.Debugf("Browser:StartTracing", "categories:%v screenshots:%t", opts.Categories, opts.Screenshots)
+
+	spanCtx, span := TraceAPICall(b.ctx, "", "browser.startTracing")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tracing.path", opts.Path))
+
+	b.tracingMu.Lock()
+	defer b.tracingMu.Unlock()
+
+	if b.tracing != nil {
+		err := errors.New("starting tracing: tracing is already started")
+		spanRecordError(span, err)
+		return err
+	}
+
+	bt := &browserTracing{
+		target: b.conn,
+		page:   page,
+		opts:   opts,
+	}
+	if page != nil {
+		bt.target = page.session
+	}
+	action := tracing.Start().
+		WithTransferMode(tracing.TransferModeReturnAsStream).
+		WithTraceConfig(opts.traceConfig())
+	if err := action.Do(cdp.WithExecutor(spanCtx, bt.target)); err != nil {
+		err := fmt.Errorf("starting tracing: %w", err)
+		spanRecordError(span, err)
+		return err
+	}
+	b.tracing = bt
+
+	return nil
+}
+
+// StopTracing stops capturing the performance trace and returns it. The
+// trace is also saved if the path is set. The trace is discarded, and
+// nil is returned, if it's only kept on poor web vitals and none is
+// rated poor.
+func (b *Browser) StopTracing(sp ScreenshotPersister) ([]byte, error) {
+	b.logger.Debugf("Browser:StopTracing", "")
+
+	spanCtx, span := TraceAPICall(b.ctx, "", "browser.stopTracing")
+	defer span.End()
+
+	errNotStarted := errors.New("stopping tracing: tracing is not started")
+	b.tracingMu.Lock()
+	bt := b.tracing
+	b.tracingMu.Unlock()
+	if bt == nil {
+		spanRecordError(span, errNotStarted)
+		return nil, errNotStarted
+	}
+	// LCP, CLS, INP, and TBT are only reported when the page is hidden,
+	// so they must be reported while tracing to decide whether to keep
+	// the trace.
+	if bt.opts.OnlyOnPoorWebVitals {
+		b.reportWebVitals(spanCtx, bt)
+	}
+	b.tracingMu.Lock()
+	if b.tracing != bt {
+		b.tracingMu.Unlock()
+		spanRecordError(span, errNotStarted)
+		return nil, errNotStarted
+	}
+	b.tracing = nil
+	b.tracingMu.Unlock()
+
+	data, err := bt.stop(spanCtx, b.browserOpts.Timeout)
+	if err != nil {
+		err := fmt.Errorf("stopping tracing: %w", err)
+		spanRecordError(span, err)
+		return nil, err
+	}
+	if bt.opts.OnlyOnPoorWebVitals && !bt.poorWebVitals.Load() {
+		return nil, nil
+	}
+
+	if bt.opts.Path != "" {
+		if err := sp.Persist(spanCtx, bt.opts.Path, bytes.NewReader(data)); err != nil {
+			err := fmt.Errorf("persisting trace: %w", err)
+			spanRecordError(span, err)
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// onPoorWebVital marks the trace that is captured for the page, or for
+// all pages, as having a web vital that is rated poor.
+func (b *Browser) onPoorWebVital(p *Page) {
+	b.tracingMu.Lock()
+	defer b.tracingMu.Unlock()
+
+	if b.tracing != nil && (b.tracing.page == nil || b.tracing.page == p) {
+		b.tracing.poorWebVitals.Store(true)
+	}
+}
+
+// reportWebVitals forces the web vitals of the page that is traced, or of
+// all pages, to be reported.
+func (b *Browser) reportWebVitals(ctx context.Context, bt *browserTracing) {
+	pages := []*Page{bt.page}
+	if bt.page == nil {
+		pages = b.getPages()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.browserOpts.Timeout)
+	defer cancel()
+	for _, p := range pages {
+		if p.IsClosed() || p.backgroundPage {
+			continue
+		}
+		if err := p.reportWebVitals(ctx); err != nil {
+			b.logger.Warnf("Browser:StopTracing", "sid:%v err:%v", p.sessionID(), err)
+		}
+	}
+}
+
+// webVitalsReported is the name of the web vital that a page reports
+// after the web vitals that are forced to be reported.
+const webVitalsReported = "k6:reported"
+
+// reportWebVitals forces the page to report the web vitals that are only
+// reported when the page is hidden by dispatching the pagehide event, as
+// Page.Close does. It returns once the reports are processed, which is
+// when the page reports webVitalsReported after them.
+func (p *Page) reportWebVitals(ctx context.Context) error {
+	p.webVitalsReportsMu.Lock()
+	if p.webVitalsReports == nil {
+		p.webVitalsReports = make(map[string]chan struct{})
+	}
+	p.webVitalsReportsN++
+	id := strconv.Itoa(p.webVitalsReportsN)
+	done := make(chan struct{})
+	p.webVitalsReports[id] = done
+	p.webVitalsReportsMu.Unlock()
+
+	defer func() {
+		p.webVitalsReportsMu.Lock()
+		delete(p.webVitalsReports, id)
+		p.webVitalsReportsMu.Unlock()
+	}()
+
+	js := `(binding, name, id) => {
+		window.dispatchEvent(new Event('pagehide'));
+		window[binding](JSON.stringify({ name, id }));
+	}`
+	if _, err := p.MainFrame().EvaluateWithContext(ctx, js, webVitalBinding, webVitalsReported, id); err != nil {
+		return fmt.Errorf("reporting web vitals: %w", err)
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("reporting web vitals: %w", ctx.Err())
+	}
+}
+
+// onWebVitalsReported is called when the page reports webVitalsReported.
+func (p *Page) onWebVitalsReported(id string) {
+	p.webVitalsReportsMu.Lock()
+	defer p.webVitalsReportsMu.Unlock()
+
+	if done, ok := p.webVitalsReports[id]; ok {
+		close(done)
+		delete(p.webVitalsReports, id)
+	}
+}
+
+// stop ends the tracing and reads the trace from the stream that the
+// browser returns it in.
+func (bt *browserTracing) stop(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ch := make(chan Event)
+	bt.target.on(ctx, []string{cdproto.EventTracingTracingComplete}, ch)
+
+	if err := tracing.End().Do(cdp.WithExecutor(ctx, bt.target)); err != nil {
+		return nil, fmt.Errorf("ending tracing: %w", err)
+	}
+
+	var ev *tracing.EventTracingComplete
+	for ev == nil {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for the trace: %w", ctx.Err())
+		case event := <-ch:
+			ev, _ = event.data.(*tracing.EventTracingComplete)
+		}
+	}
+
+	return readStream(cdp.WithExecutor(ctx, bt.target), ev.Stream)
+}
+
+// readStream reads the stream to the end and closes it.
+func readStream(ctx context.Context, h cdpio.StreamHandle) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		var res cdpio.ReadReturns
+		if err := cdp.Execute(ctx, cdpio.CommandRead, cdpio.Read(h), &res); err != nil {
+			return nil, fmt.Errorf("reading stream: %w", err)
+		}
+		if res.Base64encoded {
+			b, err := base64.StdEncoding.DecodeString(res.Data)
+			if err != nil {
+				return nil, fmt.Errorf("decoding stream: %w", err)
+			}
+			buf.Write(b)
+		} else {
+			buf.WriteString(res.Data)
+		}
+		if res.EOF {
+			break
+		}
+	}
+	if err := cdpio.Close(h).Do(ctx); err != nil {
+		return nil, fmt.Errorf("closing stream: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
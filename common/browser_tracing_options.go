@@ -0,0 +1,102 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/tracing"
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// defaultTracingCategories are the trace categories that the DevTools
+// performance panel records.
+var defaultTracingCategories = []string{ //nolint:gochecknoglobals
+	"-*",
+	"devtools.timeline",
+	"v8.execute",
+	"disabled-by-default-devtools.timeline",
+	"disabled-by-default-devtools.timeline.frame",
+	"toplevel",
+	"blink.console",
+	"blink.user_timing",
+	"latencyInfo",
+	"disabled-by-default-devtools.timeline.stack",
+	"disabled-by-default-v8.cpu_profiler",
+	"disabled-by-default-v8.cpu_profiler.hires",
+}
+
+// tracingScreenshotsCategory is the trace category of the screenshots of
+// the page.
+const tracingScreenshotsCategory = "disabled-by-default-devtools.screenshot"
+
+// TracingOptions are the options of Browser.StartTracing.
+type TracingOptions struct {
+	// Path is where the trace is saved to. The trace is not saved if
+	// it's empty.
+	Path string `js:"path"`
+	// Categories are the trace categories to record. The categories
+	// that start with a '-' are excluded.
+	Categories []string `js:"categories"`
+	// Screenshots records the screenshots of the page in the trace.
+	Screenshots bool `js:"screenshots"`
+	// OnlyOnPoorWebVitals keeps the trace only if a web vital is rated
+	// poor while tracing, and discards it otherwise. The web vitals that
+	// are only reported when a page is hidden, such as LCP and CLS, are
+	// reported when the tracing stops, as when the page is closed.
+	OnlyOnPoorWebVitals bool `js:"onlyOnPoorWebVitals"`
+}
+
+// NewTracingOptions returns the default tracing options.
+func NewTracingOptions() *TracingOptions {
+	return &TracingOptions{
+		Categories: defaultTracingCategories,
+	}
+}
+
+// Parse parses the tracing options.
+func (o *TracingOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	obj := opts.ToObject(rt)
+	for _, k := range obj.Keys() {
+		switch k {
+		case "path":
+			o.Path = obj.Get(k).String()
+		case "categories":
+			var categories []string
+			if err := rt.ExportTo(obj.Get(k), &categories); err != nil {
+				return fmt.Errorf("parsing tracing categories: %w", err)
+			}
+			o.Categories = categories
+		case "screenshots":
+			o.Screenshots = obj.Get(k).ToBoolean()
+		case "onlyOnPoorWebVitals":
+			o.OnlyOnPoorWebVitals = obj.Get(k).ToBoolean()
+		}
+	}
+
+	return nil
+}
+
+// traceConfig returns the trace config that records the categories.
+func (o *TracingOptions) traceConfig() *tracing.TraceConfig {
+	tc := &tracing.TraceConfig{}
+	for _, c := range o.Categories {
+		if strings.HasPrefix(c, "-") {
+			tc.ExcludedCategories = append(tc.ExcludedCategories, c[1:])
+			continue
+		}
+		tc.IncludedCategories = append(tc.IncludedCategories, c)
+	}
+	if o.Screenshots {
+		tc.IncludedCategories = append(tc.IncludedCategories, tracingScreenshotsCategory)
+	}
+
+	return tc
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/xk6-browser/k6ext/k6test"
+)
+
+func TestTracingOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewTracingOptions()
+		require.NoError(t, opts.Parse(vu.Context(), nil))
+
+		assert.Empty(t, opts.Path)
+		assert.False(t, opts.OnlyOnPoorWebVitals)
+
+		tc := opts.traceConfig()
+		assert.Equal(t, []string{"*"}, tc.ExcludedCategories)
+		assert.Contains(t, tc.IncludedCategories, "devtools.timeline")
+		assert.NotContains(t, tc.IncludedCategories, tracingScreenshotsCategory)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewTracingOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"path":                "trace.json",
+			"categories":          []string{"-*", "v8", "-blink"},
+			"screenshots":         true,
+			"onlyOnPoorWebVitals": true,
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "trace.json", opts.Path)
+		assert.True(t, opts.OnlyOnPoorWebVitals)
+
+		tc := opts.traceConfig()
+		assert.Equal(t, []string{"*", "blink"}, tc.ExcludedCategories)
+		assert.Equal(t, []string{"v8", tracingScreenshotsCategory}, tc.IncludedCategories)
+	})
+}
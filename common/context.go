@@ -2,6 +2,8 @@ package common
 
 import (
 	"context"
+
+	"github.com/grafana/xk6-browser/storage"
 )
 
 type ctxKey int
@@ -11,6 +13,8 @@ const (
 	ctxKeyHooks
 	ctxKeyIterationID
 	ctxKeyTracer
+	ctxKeyFilePersister
+	ctxKeyBrowser
 )
 
 func WithHooks(ctx context.Context, hooks *Hooks) context.Context {
@@ -70,6 +74,32 @@ func GetTracer(ctx context.Context) Tracer {
 	return nil
 }
 
+// WithFilePersister adds the file persister to the context.
+func WithFilePersister(ctx context.Context, fp ScreenshotPersister) context.Context {
+	return context.WithValue(ctx, ctxKeyFilePersister, fp)
+}
+
+// GetFilePersister returns the file persister attached to the context.
+// It defaults to persisting the files to the local disk if not found.
+func GetFilePersister(ctx context.Context) ScreenshotPersister {
+	if fp, ok := ctx.Value(ctxKeyFilePersister).(ScreenshotPersister); ok && fp != nil {
+		return fp
+	}
+	return &storage.LocalFilePersister{}
+}
+
+// withBrowser adds the browser to the context, so that the pages of the
+// browser can be found by their target ID.
+func withBrowser(ctx context.Context, b *Browser) context.Context {
+	return context.WithValue(ctx, ctxKeyBrowser, b)
+}
+
+// getBrowser returns the browser attached to the context, or nil if not found.
+func getBrowser(ctx context.Context) *Browser {
+	b, _ := ctx.Value(ctxKeyBrowser).(*Browser)
+	return b
+}
+
 // contextWithDoneChan returns a new context that is canceled either
 // when the done channel is closed or ctx is canceled.
 func contextWithDoneChan(ctx context.Context, done chan struct{}) context.Context {
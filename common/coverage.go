@@ -0,0 +1,601 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/debugger"
+	"github.com/chromedp/cdproto/profiler"
+	cdpruntime "github.com/chromedp/cdproto/runtime"
+
+	"github.com/grafana/xk6-browser/log"
+)
+
+// Coverage collects which parts of the JS and CSS of a page are used.
+// Each Page has a publicly accessible Coverage.
+type Coverage struct {
+	ctx     context.Context
+	session session
+	logger  *log.Logger
+
+	mu  sync.Mutex
+	js  *coverageCollector
+	css *coverageCollector
+}
+
+// NewCoverage returns a new Coverage for the page session.
+func NewCoverage(ctx context.Context, s session, logger *log.Logger) *Coverage {
+	return &Coverage{
+		ctx:     ctx,
+		session: s,
+		logger:  logger,
+	}
+}
+
+// CoverageRange is a range of a script or a stylesheet text. The start is
+// inclusive and the end is exclusive.
+type CoverageRange struct {
+	Start int64 `js:"start" json:"start"`
+	End   int64 `js:"end" json:"end"`
+}
+
+// CoverageBlock is a range of a function with the number of times it ran.
+type CoverageBlock struct {
+	StartOffset int64 `js:"startOffset" json:"startOffset"`
+	EndOffset   int64 `js:"endOffset" json:"endOffset"`
+	Count       int64 `js:"count" json:"count"`
+}
+
+// CoverageFunction is the V8 coverage of a JS function.
+type CoverageFunction struct {
+	FunctionName    string          `js:"functionName" json:"functionName"`
+	Ranges          []CoverageBlock `js:"ranges" json:"ranges"`
+	IsBlockCoverage bool            `js:"isBlockCoverage" json:"isBlockCoverage"`
+}
+
+// JSCoverageEntry is the coverage of a script. The ranges are the parts of
+// the text that ran, and the functions are the raw V8 coverage.
+type JSCoverageEntry struct {
+	URL       string             `js:"url" json:"url"`
+	ScriptID  string             `js:"scriptId" json:"scriptId"`
+	Text      string             `js:"text" json:"text"`
+	Ranges    []CoverageRange    `js:"ranges" json:"ranges"`
+	Functions []CoverageFunction `js:"functions" json:"functions"`
+}
+
+// CSSCoverageEntry is the coverage of a stylesheet. The ranges are the
+// parts of the text whose rules are used.
+type CSSCoverageEntry struct {
+	URL    string          `js:"url" json:"url"`
+	Text   string          `js:"text" json:"text"`
+	Ranges []CoverageRange `js:"ranges" json:"ranges"`
+}
+
+// coverageSource is a script or a stylesheet that the coverage is
+// collected for.
+type coverageSource struct {
+	url  string
+	text string
+}
+
+// coverageCollector collects the scripts or the stylesheets of a page
+// while the coverage is running.
+type coverageCollector struct {
+	resetOnNavigation bool
+	includeAnonymous  bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	sources map[string]*coverageSource
+}
+
+func newCoverageCollector(resetOnNavigation, includeAnonymous bool) *coverageCollector {
+	return &coverageCollector{
+		resetOnNavigation: resetOnNavigation,
+		includeAnonymous:  includeAnonymous,
+		done:              make(chan struct{}),
+		sources:           make(map[string]*coverageSource),
+	}
+}
+
+// start handles the events of the session until the collector is stopped.
+func (cc *coverageCollector) start(ctx context.Context, s session, events []string, handle func(Event)) {
+	ctx, cc.cancel = context.WithCancel(ctx)
+	ch := make(chan Event)
+	s.on(ctx, append(events, cdproto.EventRuntimeExecutionContextsCleared), ch)
+
+	go func() {
+		defer close(cc.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.Done():
+				return
+			case event := <-ch:
+				if _, ok := event.data.(*cdpruntime.EventExecutionContextsCleared); ok {
+					if cc.resetOnNavigation {
+						cc.reset()
+					}
+					continue
+				}
+				handle(event)
+			}
+		}
+	}()
+}
+
+// stop stops handling the events and waits for the event handler to return.
+func (cc *coverageCollector) stop() {
+	cc.cancel()
+	<-cc.done
+}
+
+func (cc *coverageCollector) add(id, url, text string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.sources[id] = &coverageSource{url: url, text: text}
+}
+
+func (cc *coverageCollector) get(id string) (*coverageSource, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	s, ok := cc.sources[id]
+
+	return s, ok
+}
+
+func (cc *coverageCollector) reset() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.sources = make(map[string]*coverageSource)
+}
+
+// StartJSCoverage starts collecting which parts of the scripts of the page
+// run.
+func (c *Coverage) StartJSCoverage(opts *JSCoverageOptions) error {
+	c.logger.Debugf("Coverage:StartJSCoverage", "sid:%v resetOnNavigation:%t", c.session.ID(), opts.ResetOnNavigation)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.js != nil {
+		return errors.New("starting JS coverage: JS coverage is already started")
+	}
+
+	cc := newCoverageCollector(opts.ResetOnNavigation, opts.ReportAnonymousScripts)
+	cc.start(c.ctx, c.session, []string{cdproto.EventDebuggerScriptParsed}, func(event Event) {
+		if ev, ok := event.data.(*debugger.EventScriptParsed); ok {
+			c.onScriptParsed(cc, ev)
+		}
+	})
+
+	ctx := cdp.WithExecutor(c.ctx, c.session)
+	err := profiler.Enable().Do(ctx)
+	if err == nil {
+		_, err = profiler.StartPreciseCoverage().WithCallCount(true).WithDetailed(true).Do(ctx)
+	}
+	if err == nil {
+		// The scripts that are already parsed are reported when the
+		// debugger is enabled.
+		_, err = debugger.Enable().Do(ctx)
+	}
+	if err == nil {
+		err = debugger.SetSkipAllPauses(true).Do(ctx)
+	}
+	if err != nil {
+		cc.stop()
+		return fmt.Errorf("starting JS coverage: %w", err)
+	}
+	c.js = cc
+
+	return nil
+}
+
+func (c *Coverage) onScriptParsed(cc *coverageCollector, ev *debugger.EventScriptParsed) {
+	// The scripts that are evaluated by the browser module itself are
+	// not a part of the page.
+	if ev.URL == evaluationScriptURL {
+		return
+	}
+	if ev.URL == "" && !cc.includeAnonymous {
+		return
+	}
+	// The source is fetched right away, because it's not available after
+	// the script is garbage collected, e.g. after a navigation.
+	src, _, err := debugger.GetScriptSource(ev.ScriptID).Do(cdp.WithExecutor(c.ctx, c.session))
+	if err != nil {
+		c.logger.Debugf("Coverage:onScriptParsed", "sid:%v url:%q getting source: %v", c.session.ID(), ev.URL, err)
+		return
+	}
+	cc.add(string(ev.ScriptID), ev.URL, src)
+}
+
+// StopJSCoverage stops collecting the JS coverage and returns the coverage
+// of the scripts, which is also exported if the path is set.
+func (c *Coverage) StopJSCoverage(opts *StopCoverageOptions, sp ScreenshotPersister) ([]*JSCoverageEntry, error) {
+	c.logger.Debugf("Coverage:StopJSCoverage", "sid:%v path:%q", c.session.ID(), opts.Path)
+
+	c.mu.Lock()
+	cc := c.js
+	c.js = nil
+	c.mu.Unlock()
+	if cc == nil {
+		return nil, errors.New("stopping JS coverage: JS coverage is not started")
+	}
+
+	ctx := cdp.WithExecutor(c.ctx, c.session)
+	result, _, err := profiler.TakePreciseCoverage().Do(ctx)
+	cc.stop()
+	if err != nil {
+		return nil, fmt.Errorf("stopping JS coverage: %w", err)
+	}
+	for _, action := range []Action{profiler.StopPreciseCoverage(), profiler.Disable(), debugger.Disable()} {
+		if err := action.Do(ctx); err != nil {
+			return nil, fmt.Errorf("stopping JS coverage: %w", err)
+		}
+	}
+
+	entries := make([]*JSCoverageEntry, 0, len(result))
+	for _, sc := range result {
+		src, ok := cc.get(string(sc.ScriptID))
+		if !ok {
+			continue
+		}
+		entries = append(entries, newJSCoverageEntry(sc, src))
+	}
+
+	if opts.Path == "" {
+		return entries, nil
+	}
+	var export any = v8Coverage(entries)
+	if opts.Format == CoverageFormatIstanbul {
+		export = istanbulCoverage(entries)
+	}
+	if err := persistJSON(c.ctx, sp, opts.Path, export); err != nil {
+		return nil, fmt.Errorf("exporting JS coverage: %w", err)
+	}
+
+	return entries, nil
+}
+
+func newJSCoverageEntry(sc *profiler.ScriptCoverage, src *coverageSource) *JSCoverageEntry {
+	e := &JSCoverageEntry{
+		URL:       src.url,
+		ScriptID:  string(sc.ScriptID),
+		Text:      src.text,
+		Functions: make([]CoverageFunction, 0, len(sc.Functions)),
+	}
+	var blocks []CoverageBlock
+	for _, fn := range sc.Functions {
+		cf := CoverageFunction{
+			FunctionName:    fn.FunctionName,
+			IsBlockCoverage: fn.IsBlockCoverage,
+			Ranges:          make([]CoverageBlock, 0, len(fn.Ranges)),
+		}
+		for _, r := range fn.Ranges {
+			cf.Ranges = append(cf.Ranges, CoverageBlock{StartOffset: r.StartOffset, EndOffset: r.EndOffset, Count: r.Count})
+		}
+		e.Functions = append(e.Functions, cf)
+		blocks = append(blocks, cf.Ranges...)
+	}
+	e.Ranges = usedRanges(blocks)
+
+	return e
+}
+
+// StartCSSCoverage starts collecting which rules of the stylesheets of the
+// page are used.
+func (c *Coverage) StartCSSCoverage(opts *CSSCoverageOptions) error {
+	c.logger.Debugf("Coverage:StartCSSCoverage", "sid:%v resetOnNavigation:%t", c.session.ID(), opts.ResetOnNavigation)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.css != nil {
+		return errors.New("starting CSS coverage: CSS coverage is already started")
+	}
+
+	cc := newCoverageCollector(opts.ResetOnNavigation, false)
+	cc.start(c.ctx, c.session, []string{cdproto.EventCSSStyleSheetAdded}, func(event Event) {
+		if ev, ok := event.data.(*css.EventStyleSheetAdded); ok {
+			c.onStyleSheetAdded(cc, ev)
+		}
+	})
+
+	// The stylesheets that are already added are reported when the CSS
+	// domain is enabled. The DOM domain, that it depends on, is enabled
+	// by the frame session.
+	ctx := cdp.WithExecutor(c.ctx, c.session)
+	err := css.Enable().Do(ctx)
+	if err == nil {
+		err = css.StartRuleUsageTracking().Do(ctx)
+	}
+	if err != nil {
+		cc.stop()
+		return fmt.Errorf("starting CSS coverage: %w", err)
+	}
+	c.css = cc
+
+	return nil
+}
+
+func (c *Coverage) onStyleSheetAdded(cc *coverageCollector, ev *css.EventStyleSheetAdded) {
+	h := ev.Header
+	// The stylesheets without a URL are created by scripts.
+	if h == nil || h.SourceURL == "" {
+		return
+	}
+	text, err := css.GetStyleSheetText(h.StyleSheetID).Do(cdp.WithExecutor(c.ctx, c.session))
+	if err != nil {
+		c.logger.Debugf("Coverage:onStyleSheetAdded", "sid:%v url:%q getting text: %v", c.session.ID(), h.SourceURL, err)
+		return
+	}
+	cc.add(string(h.StyleSheetID), h.SourceURL, text)
+}
+
+// StopCSSCoverage stops collecting the CSS coverage and returns the
+// coverage of the stylesheets, which is also exported if the path is set.
+func (c *Coverage) StopCSSCoverage(opts *StopCoverageOptions, sp ScreenshotPersister) ([]*CSSCoverageEntry, error) {
+	c.logger.Debugf("Coverage:StopCSSCoverage", "sid:%v path:%q", c.session.ID(), opts.Path)
+
+	if opts.Format == CoverageFormatIstanbul {
+		return nil, errors.New("stopping CSS coverage: istanbul format is only supported for JS coverage")
+	}
+
+	c.mu.Lock()
+	cc := c.css
+	c.css = nil
+	c.mu.Unlock()
+	if cc == nil {
+		return nil, errors.New("stopping CSS coverage: CSS coverage is not started")
+	}
+
+	ctx := cdp.WithExecutor(c.ctx, c.session)
+	usage, err := css.StopRuleUsageTracking().Do(ctx)
+	cc.stop()
+	if err != nil {
+		return nil, fmt.Errorf("stopping CSS coverage: %w", err)
+	}
+	if err := css.Disable().Do(ctx); err != nil {
+		return nil, fmt.Errorf("stopping CSS coverage: %w", err)
+	}
+
+	used := make(map[string][]CoverageBlock)
+	for _, u := range usage {
+		if !u.Used {
+			continue
+		}
+		id := string(u.StyleSheetID)
+		used[id] = append(used[id], CoverageBlock{StartOffset: int64(u.StartOffset), EndOffset: int64(u.EndOffset), Count: 1})
+	}
+	cc.mu.Lock()
+	ids := make([]string, 0, len(cc.sources))
+	for id := range cc.sources {
+		ids = append(ids, id)
+	}
+	cc.mu.Unlock()
+	sort.Strings(ids)
+
+	entries := make([]*CSSCoverageEntry, 0, len(ids))
+	for _, id := range ids {
+		src, _ := cc.get(id)
+		entries = append(entries, &CSSCoverageEntry{
+			URL:    src.url,
+			Text:   src.text,
+			Ranges: usedRanges(used[id]),
+		})
+	}
+
+	if opts.Path != "" {
+		if err := persistJSON(c.ctx, sp, opts.Path, entries); err != nil {
+			return nil, fmt.Errorf("exporting CSS coverage: %w", err)
+		}
+	}
+
+	return entries, nil
+}
+
+// usedRanges returns the disjoint ranges that are used, i.e. whose
+// innermost block has a positive count. The blocks are either disjoint or
+// nested, as V8 reports them.
+func usedRanges(blocks []CoverageBlock) []CoverageRange {
+	type point struct {
+		offset int64
+		start  bool
+		block  CoverageBlock
+	}
+	points := make([]point, 0, 2*len(blocks))
+	for _, b := range blocks {
+		points = append(points,
+			point{offset: b.StartOffset, start: true, block: b},
+			point{offset: b.EndOffset, start: false, block: b},
+		)
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		a, b := points[i], points[j]
+		if a.offset != b.offset {
+			return a.offset < b.offset
+		}
+		// The blocks that end are closed before the ones that start.
+		if a.start != b.start {
+			return !a.start
+		}
+		la := a.block.EndOffset - a.block.StartOffset
+		lb := b.block.EndOffset - b.block.StartOffset
+		// The outer blocks start first and end last.
+		if a.start {
+			return la > lb
+		}
+		return la < lb
+	})
+
+	var (
+		ranges     []CoverageRange
+		counts     []int64
+		lastOffset int64
+	)
+	for _, p := range points {
+		if len(counts) > 0 && lastOffset < p.offset && counts[len(counts)-1] > 0 {
+			if n := len(ranges); n > 0 && ranges[n-1].End == lastOffset {
+				ranges[n-1].End = p.offset
+			} else {
+				ranges = append(ranges, CoverageRange{Start: lastOffset, End: p.offset})
+			}
+		}
+		lastOffset = p.offset
+		if p.start {
+			counts = append(counts, p.block.Count)
+		} else if len(counts) > 0 {
+			counts = counts[:len(counts)-1]
+		}
+	}
+	if ranges == nil {
+		ranges = []CoverageRange{}
+	}
+
+	return ranges
+}
+
+// v8Coverage returns the coverage in the format of the coverage files
+// that V8 writes with NODE_V8_COVERAGE.
+func v8Coverage(entries []*JSCoverageEntry) any {
+	type scriptCoverage struct {
+		ScriptID  string             `json:"scriptId"`
+		URL       string             `json:"url"`
+		Source    string             `json:"source"`
+		Functions []CoverageFunction `json:"functions"`
+	}
+	result := make([]scriptCoverage, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, scriptCoverage{
+			ScriptID:  e.ScriptID,
+			URL:       e.URL,
+			Source:    e.Text,
+			Functions: e.Functions,
+		})
+	}
+
+	return map[string]any{"result": result}
+}
+
+type istanbulPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type istanbulLocation struct {
+	Start istanbulPosition `json:"start"`
+	End   istanbulPosition `json:"end"`
+}
+
+type istanbulFunction struct {
+	Name string           `json:"name"`
+	Decl istanbulLocation `json:"decl"`
+	Loc  istanbulLocation `json:"loc"`
+	Line int              `json:"line"`
+}
+
+type istanbulFileCoverage struct {
+	Path         string                      `json:"path"`
+	StatementMap map[string]istanbulLocation `json:"statementMap"`
+	FnMap        map[string]istanbulFunction `json:"fnMap"`
+	BranchMap    map[string]any              `json:"branchMap"`
+	S            map[string]int64            `json:"s"`
+	F            map[string]int64            `json:"f"`
+	B            map[string][]int64          `json:"b"`
+}
+
+// istanbulCoverage converts the coverage to the Istanbul format. Each
+// function is a function of Istanbul, and each block of a function is a
+// statement.
+func istanbulCoverage(entries []*JSCoverageEntry) map[string]*istanbulFileCoverage {
+	files := make(map[string]*istanbulFileCoverage, len(entries))
+	for _, e := range entries {
+		path := e.URL
+		if _, ok := files[path]; ok || path == "" {
+			// Inline scripts of a document share the URL of the document.
+			path += "#" + e.ScriptID
+		}
+		fc := &istanbulFileCoverage{
+			Path:         path,
+			StatementMap: make(map[string]istanbulLocation),
+			FnMap:        make(map[string]istanbulFunction),
+			BranchMap:    make(map[string]any),
+			S:            make(map[string]int64),
+			F:            make(map[string]int64),
+			B:            make(map[string][]int64),
+		}
+		lines := newLineIndex(e.Text)
+		for i, fn := range e.Functions {
+			if len(fn.Ranges) == 0 {
+				continue
+			}
+			id := strconv.Itoa(i)
+			loc := lines.location(fn.Ranges[0].StartOffset, fn.Ranges[0].EndOffset)
+			fc.FnMap[id] = istanbulFunction{Name: fn.FunctionName, Decl: loc, Loc: loc, Line: loc.Start.Line}
+			fc.F[id] = fn.Ranges[0].Count
+			for _, r := range fn.Ranges {
+				id := strconv.Itoa(len(fc.StatementMap))
+				fc.StatementMap[id] = lines.location(r.StartOffset, r.EndOffset)
+				fc.S[id] = r.Count
+			}
+		}
+		files[path] = fc
+	}
+
+	return files
+}
+
+// lineIndex converts the offsets of a text, which are in UTF-16 code
+// units as in JS, to lines and columns.
+type lineIndex []int64
+
+func newLineIndex(text string) lineIndex {
+	starts := lineIndex{0}
+	for i, c := range utf16.Encode([]rune(text)) {
+		if c == '\n' {
+			starts = append(starts, int64(i+1))
+		}
+	}
+
+	return starts
+}
+
+func (li lineIndex) position(offset int64) istanbulPosition {
+	i := sort.Search(len(li), func(i int) bool { return li[i] > offset }) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	// The lines of Istanbul start from 1, and the columns from 0.
+	return istanbulPosition{Line: i + 1, Column: int(offset - li[i])}
+}
+
+func (li lineIndex) location(start, end int64) istanbulLocation {
+	return istanbulLocation{Start: li.position(start), End: li.position(end)}
+}
+
+func persistJSON(ctx context.Context, sp ScreenshotPersister, path string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling %q: %w", path, err)
+	}
+
+	return sp.Persist(ctx, path, bytes.NewReader(b)) //nolint:wrapcheck
+}
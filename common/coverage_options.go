@@ -0,0 +1,130 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// JSCoverageOptions are the options of Coverage.StartJSCoverage.
+type JSCoverageOptions struct {
+	// ResetOnNavigation drops the coverage of the scripts of the previous
+	// documents on navigation.
+	ResetOnNavigation bool `js:"resetOnNavigation"`
+	// ReportAnonymousScripts reports the coverage of the scripts without
+	// a URL, such as the scripts created with eval.
+	ReportAnonymousScripts bool `js:"reportAnonymousScripts"`
+}
+
+// NewJSCoverageOptions returns the default JS coverage options.
+func NewJSCoverageOptions() *JSCoverageOptions {
+	return &JSCoverageOptions{
+		ResetOnNavigation: true,
+	}
+}
+
+// Parse parses the JS coverage options.
+func (o *JSCoverageOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	obj := opts.ToObject(k6ext.Runtime(ctx))
+	for _, k := range obj.Keys() {
+		switch k {
+		case "resetOnNavigation":
+			o.ResetOnNavigation = obj.Get(k).ToBoolean()
+		case "reportAnonymousScripts":
+			o.ReportAnonymousScripts = obj.Get(k).ToBoolean()
+		}
+	}
+
+	return nil
+}
+
+// CSSCoverageOptions are the options of Coverage.StartCSSCoverage.
+type CSSCoverageOptions struct {
+	// ResetOnNavigation drops the coverage of the stylesheets of the
+	// previous documents on navigation.
+	ResetOnNavigation bool `js:"resetOnNavigation"`
+}
+
+// NewCSSCoverageOptions returns the default CSS coverage options.
+func NewCSSCoverageOptions() *CSSCoverageOptions {
+	return &CSSCoverageOptions{
+		ResetOnNavigation: true,
+	}
+}
+
+// Parse parses the CSS coverage options.
+func (o *CSSCoverageOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	obj := opts.ToObject(k6ext.Runtime(ctx))
+	for _, k := range obj.Keys() {
+		if k == "resetOnNavigation" {
+			o.ResetOnNavigation = obj.Get(k).ToBoolean()
+		}
+	}
+
+	return nil
+}
+
+// CoverageFormat is the format that the coverage is exported in.
+type CoverageFormat string
+
+const (
+	// CoverageFormatV8 is the raw V8 coverage format, which tools such
+	// as c8 and v8-to-istanbul read. CSS coverage is exported as the
+	// coverage entries.
+	CoverageFormatV8 CoverageFormat = "v8"
+
+	// CoverageFormatIstanbul is the Istanbul coverage format, which tools
+	// such as nyc read. It is only supported for JS coverage.
+	CoverageFormatIstanbul CoverageFormat = "istanbul"
+)
+
+// StopCoverageOptions are the options of Coverage.StopJSCoverage and
+// Coverage.StopCSSCoverage.
+type StopCoverageOptions struct {
+	// Path is where the coverage is exported to as JSON. The coverage is
+	// not exported if it's empty.
+	Path   string         `js:"path"`
+	Format CoverageFormat `js:"format"`
+}
+
+// NewStopCoverageOptions returns the default stop coverage options.
+func NewStopCoverageOptions() *StopCoverageOptions {
+	return &StopCoverageOptions{
+		Format: CoverageFormatV8,
+	}
+}
+
+// Parse parses the stop coverage options.
+func (o *StopCoverageOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	obj := opts.ToObject(k6ext.Runtime(ctx))
+	for _, k := range obj.Keys() {
+		switch k {
+		case "path":
+			o.Path = obj.Get(k).String()
+		case "format":
+			switch f := CoverageFormat(obj.Get(k).String()); f {
+			case CoverageFormatV8, CoverageFormatIstanbul:
+				o.Format = f
+			default:
+				return fmt.Errorf("invalid coverage format %q, must be one of 'v8' or 'istanbul'", f)
+			}
+		}
+	}
+
+	return nil
+}
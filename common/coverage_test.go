@@ -0,0 +1,139 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/xk6-browser/k6ext/k6test"
+)
+
+func TestUsedRanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		blocks []CoverageBlock
+		want   []CoverageRange
+	}{
+		{
+			name: "empty",
+			want: []CoverageRange{},
+		},
+		{
+			name:   "unused",
+			blocks: []CoverageBlock{{StartOffset: 0, EndOffset: 10, Count: 0}},
+			want:   []CoverageRange{},
+		},
+		{
+			name: "nested_unused_block",
+			blocks: []CoverageBlock{
+				{StartOffset: 0, EndOffset: 100, Count: 1},
+				{StartOffset: 20, EndOffset: 30, Count: 0},
+			},
+			want: []CoverageRange{{Start: 0, End: 20}, {Start: 30, End: 100}},
+		},
+		{
+			name: "nested_used_block_in_unused_block",
+			blocks: []CoverageBlock{
+				{StartOffset: 0, EndOffset: 100, Count: 1},
+				{StartOffset: 10, EndOffset: 50, Count: 0},
+				{StartOffset: 20, EndOffset: 30, Count: 2},
+			},
+			want: []CoverageRange{{Start: 0, End: 10}, {Start: 20, End: 30}, {Start: 50, End: 100}},
+		},
+		{
+			name: "adjacent_blocks_are_merged",
+			blocks: []CoverageBlock{
+				{StartOffset: 0, EndOffset: 10, Count: 1},
+				{StartOffset: 10, EndOffset: 20, Count: 1},
+				{StartOffset: 30, EndOffset: 40, Count: 1},
+			},
+			want: []CoverageRange{{Start: 0, End: 20}, {Start: 30, End: 40}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, usedRanges(tt.blocks))
+		})
+	}
+}
+
+func TestIstanbulCoverage(t *testing.T) {
+	t.Parallel()
+
+	entries := []*JSCoverageEntry{
+		{
+			URL:      "https://test.k6.io/app.js",
+			ScriptID: "1",
+			// The emoji is two UTF-16 code units long.
+			Text: "const s = '😀';\nfunction used() {}\nfunction unused() {}\n",
+			Functions: []CoverageFunction{
+				{Ranges: []CoverageBlock{{StartOffset: 0, EndOffset: 57, Count: 1}}},
+				{FunctionName: "used", Ranges: []CoverageBlock{{StartOffset: 16, EndOffset: 34, Count: 3}}},
+				{FunctionName: "unused", Ranges: []CoverageBlock{{StartOffset: 35, EndOffset: 55, Count: 0}}},
+			},
+		},
+		{URL: "https://test.k6.io/app.js", ScriptID: "2"},
+	}
+	files := istanbulCoverage(entries)
+	require.Len(t, files, 2)
+
+	fc := files["https://test.k6.io/app.js"]
+	require.NotNil(t, fc)
+	assert.Equal(t, "https://test.k6.io/app.js", fc.Path)
+	assert.Equal(t, map[string]int64{"0": 1, "1": 3, "2": 0}, fc.F)
+	assert.Equal(t, map[string]int64{"0": 1, "1": 3, "2": 0}, fc.S)
+	assert.Equal(t, "used", fc.FnMap["1"].Name)
+	assert.Equal(t, istanbulLocation{
+		Start: istanbulPosition{Line: 2, Column: 0},
+		End:   istanbulPosition{Line: 2, Column: 18},
+	}, fc.FnMap["1"].Loc)
+	assert.Equal(t, 3, fc.FnMap["2"].Line)
+
+	assert.Contains(t, files, "https://test.k6.io/app.js#2", "scripts of the same URL should not be merged")
+}
+
+func TestV8Coverage(t *testing.T) {
+	t.Parallel()
+
+	fns := []CoverageFunction{{FunctionName: "f", Ranges: []CoverageBlock{{EndOffset: 5, Count: 1}}}}
+	got := v8Coverage([]*JSCoverageEntry{{URL: "app.js", ScriptID: "7", Text: "f();", Functions: fns}})
+
+	b, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"result": [{
+		"scriptId": "7",
+		"url": "app.js",
+		"source": "f();",
+		"functions": [{
+			"functionName": "f",
+			"ranges": [{"startOffset": 0, "endOffset": 5, "count": 1}],
+			"isBlockCoverage": false
+		}]
+	}]}`, string(b))
+}
+
+func TestStopCoverageOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewStopCoverageOptions()
+	require.NoError(t, opts.Parse(vu.Context(), nil))
+	assert.Equal(t, CoverageFormatV8, opts.Format)
+
+	err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+		"path":   "coverage/app.json",
+		"format": "istanbul",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, &StopCoverageOptions{Path: "coverage/app.json", Format: CoverageFormatIstanbul}, opts)
+
+	err = opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{"format": "lcov"}))
+	assert.ErrorContains(t, err, `invalid coverage format "lcov"`)
+}
@@ -0,0 +1,150 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdppage "github.com/chromedp/cdproto/page"
+
+	"github.com/grafana/xk6-browser/log"
+)
+
+// ErrDialogAlreadyHandled is returned when a dialog is accepted or
+// dismissed more than once.
+var ErrDialogAlreadyHandled = errors.New("dialog is already handled")
+
+// Dialog represents a JavaScript dialog opened by a page, such as an
+// alert, confirm, prompt or beforeunload dialog.
+//
+// The page is blocked until the dialog is either accepted or dismissed.
+type Dialog struct {
+	ctx          context.Context
+	logger       *log.Logger
+	session      session
+	typ          cdppage.DialogType
+	message      string
+	defaultValue string
+
+	handledMu sync.Mutex
+	handled   bool
+	// defaultTimer handles the dialog by default if it's not handled in
+	// time.
+	defaultTimer *time.Timer
+}
+
+func newDialog(
+	ctx context.Context, logger *log.Logger, s session, event *cdppage.EventJavascriptDialogOpening,
+) *Dialog {
+	return &Dialog{
+		ctx:          ctx,
+		logger:       logger,
+		session:      s,
+		typ:          event.Type,
+		message:      event.Message,
+		defaultValue: event.DefaultPrompt,
+	}
+}
+
+// Accept accepts the dialog. The prompt text is only used by prompt dialogs.
+func (d *Dialog) Accept(promptText string) error {
+	d.logger.Debugf("Dialog:Accept", "sid:%v type:%s", d.session.ID(), d.typ)
+
+	action := cdppage.HandleJavaScriptDialog(true)
+	if d.typ == cdppage.DialogTypePrompt {
+		action = action.WithPromptText(promptText)
+	}
+	if err := d.handle(action); err != nil {
+		return fmt.Errorf("accepting dialog: %w", err)
+	}
+
+	return nil
+}
+
+// DefaultValue returns the default value of a prompt dialog, or an empty
+// string for the other dialog types.
+func (d *Dialog) DefaultValue() string {
+	return d.defaultValue
+}
+
+// Dismiss dismisses the dialog.
+func (d *Dialog) Dismiss() error {
+	d.logger.Debugf("Dialog:Dismiss", "sid:%v type:%s", d.session.ID(), d.typ)
+
+	if err := d.handle(cdppage.HandleJavaScriptDialog(false)); err != nil {
+		return fmt.Errorf("dismissing dialog: %w", err)
+	}
+
+	return nil
+}
+
+// Message returns the message displayed in the dialog.
+func (d *Dialog) Message() string {
+	return d.message
+}
+
+// Type returns the type of the dialog, which is one of alert, confirm,
+// prompt or beforeunload.
+func (d *Dialog) Type() string {
+	return d.typ.String()
+}
+
+// HandlerFailed handles the dialog by default if the dialog handler failed
+// before handling it, since the page would otherwise stay blocked.
+func (d *Dialog) HandlerFailed(err error) {
+	d.logger.Errorf("Dialog:HandlerFailed", "sid:%v type:%s err:%v", d.session.ID(), d.typ, err)
+
+	d.handleByDefault()
+}
+
+// handleByDefaultAfter handles the dialog by default if it's not handled
+// within the timeout, e.g. since the event loop that runs the dialog
+// handlers is blocked. The timer is stopped once the dialog is handled.
+func (d *Dialog) handleByDefaultAfter(timeout time.Duration) {
+	d.handledMu.Lock()
+	defer d.handledMu.Unlock()
+
+	if d.handled {
+		return
+	}
+	d.defaultTimer = time.AfterFunc(timeout, func() {
+		if d.ctx.Err() != nil {
+			return
+		}
+		d.handleByDefault()
+	})
+}
+
+// handleByDefault dismisses the dialog, or accepts it if it's a
+// beforeunload dialog, unless it's already handled.
+//
+// We're unable to dismiss beforeunload dialog boxes at the moment as it
+// seems to pause the exec of any other action on the page. I believe this
+// is an issue in Chromium.
+func (d *Dialog) handleByDefault() {
+	action := cdppage.HandleJavaScriptDialog(d.typ == cdppage.DialogTypeBeforeunload)
+	if err := d.handle(action); err != nil && !errors.Is(err, ErrDialogAlreadyHandled) {
+		d.logger.Errorf("Dialog:handleByDefault", "failed to dismiss dialog box: %v", err)
+	}
+}
+
+func (d *Dialog) handle(action *cdppage.HandleJavaScriptDialogParams) error {
+	d.handledMu.Lock()
+	defer d.handledMu.Unlock()
+
+	if d.handled {
+		return ErrDialogAlreadyHandled
+	}
+	if err := action.Do(cdp.WithExecutor(d.ctx, d.session)); err != nil {
+		return err //nolint:wrapcheck
+	}
+	d.handled = true
+	if d.defaultTimer != nil {
+		d.defaultTimer.Stop()
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cdppage "github.com/chromedp/cdproto/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/xk6-browser/k6ext/k6test"
+	"github.com/grafana/xk6-browser/log"
+)
+
+func TestDialogHandleByDefault(t *testing.T) {
+	t.Parallel()
+
+	newTestDialog := func(t *testing.T) (*Dialog, *notifyingSession) {
+		t.Helper()
+
+		s := &notifyingSession{session: &Session{id: "1234"}, cdpCalls: make(chan string, 2)}
+		vu := k6test.NewVU(t)
+		event := &cdppage.EventJavascriptDialogOpening{Type: cdppage.DialogTypeAlert}
+		return newDialog(vu.Context(), log.NewNullLogger(), s, event), s
+	}
+	assertNoCalls := func(t *testing.T, s *notifyingSession) {
+		t.Helper()
+
+		select {
+		case call := <-s.cdpCalls:
+			t.Errorf("unexpected call %q after the dialog is handled", call)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	t.Run("handler_failed", func(t *testing.T) {
+		t.Parallel()
+
+		d, s := newTestDialog(t)
+		d.HandlerFailed(errors.New("handler failed"))
+		assert.Equal(t, "Page.handleJavaScriptDialog", <-s.cdpCalls)
+	})
+
+	t.Run("not_handled_in_time", func(t *testing.T) {
+		t.Parallel()
+
+		d, s := newTestDialog(t)
+		d.handleByDefaultAfter(time.Millisecond)
+		assert.Equal(t, "Page.handleJavaScriptDialog", <-s.cdpCalls)
+		assert.ErrorIs(t, d.Dismiss(), ErrDialogAlreadyHandled)
+	})
+
+	t.Run("handled", func(t *testing.T) {
+		t.Parallel()
+
+		d, s := newTestDialog(t)
+		d.handleByDefaultAfter(10 * time.Millisecond)
+		require.NoError(t, d.Accept(""))
+		assert.Equal(t, "Page.handleJavaScriptDialog", <-s.cdpCalls)
+		d.HandlerFailed(errors.New("handler failed"))
+		assertNoCalls(t, s)
+	})
+}
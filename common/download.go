@@ -0,0 +1,148 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/grafana/xk6-browser/log"
+)
+
+// Download represents a file downloaded by a page.
+//
+// The browser saves the file into the downloads directory of the browser
+// context, which is removed when the browser process is cleaned up.
+type Download struct {
+	ctx               context.Context
+	logger            *log.Logger
+	page              *Page
+	guid              string
+	url               string
+	suggestedFilename string
+	path              string
+
+	done     chan struct{}
+	doneOnce sync.Once
+	failure  string
+}
+
+func newDownload(
+	ctx context.Context, logger *log.Logger, p *Page, guid, url, suggestedFilename, path string,
+) *Download {
+	return &Download{
+		ctx:               ctx,
+		logger:            logger,
+		page:              p,
+		guid:              guid,
+		url:               url,
+		suggestedFilename: suggestedFilename,
+		path:              path,
+		done:              make(chan struct{}),
+	}
+}
+
+// finish marks the download as finished. The failure is empty if the
+// download succeeded.
+func (d *Download) finish(failure string) {
+	d.doneOnce.Do(func() {
+		d.failure = failure
+		close(d.done)
+	})
+}
+
+// wait waits for the download to finish.
+func (d *Download) wait() error {
+	select {
+	case <-d.done:
+		return nil
+	case <-d.ctx.Done():
+		return fmt.Errorf("waiting for download of %q: %w", d.url, d.ctx.Err())
+	}
+}
+
+// CreateReadStream waits for the download to finish and returns a reader
+// of the downloaded file.
+func (d *Download) CreateReadStream() (io.ReadCloser, error) {
+	d.logger.Debugf("Download:CreateReadStream", "guid:%s url:%s", d.guid, d.url)
+
+	if err := d.wait(); err != nil {
+		return nil, err
+	}
+	if d.failure != "" {
+		return nil, fmt.Errorf("download of %q failed: %s", d.url, d.failure)
+	}
+	f, err := os.Open(d.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening downloaded file: %w", err)
+	}
+
+	return f, nil
+}
+
+// Failure waits for the download to finish and returns the error message
+// of the download, or an empty string if the download succeeded.
+func (d *Download) Failure() (string, error) {
+	if err := d.wait(); err != nil {
+		return "", err
+	}
+
+	return d.failure, nil
+}
+
+// Page returns the page that the download belongs to.
+func (d *Download) Page() *Page {
+	return d.page
+}
+
+// Path waits for the download to finish and returns the path of the
+// downloaded file. It returns an empty path if the download failed.
+func (d *Download) Path() (string, error) {
+	if err := d.wait(); err != nil {
+		return "", err
+	}
+	if d.failure != "" {
+		return "", nil
+	}
+
+	return d.path, nil
+}
+
+// SaveAs waits for the download to finish and persists the downloaded
+// file to the path.
+func (d *Download) SaveAs(path string, sp ScreenshotPersister) error {
+	d.logger.Debugf("Download:SaveAs", "guid:%s url:%s path:%s", d.guid, d.url, path)
+
+	if path == "" {
+		return errors.New("path is required")
+	}
+	r, err := d.CreateReadStream()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			d.logger.Debugf("Download:SaveAs", "closing downloaded file: %v", err)
+		}
+	}()
+
+	if err := sp.Persist(d.ctx, path, r); err != nil {
+		return fmt.Errorf("saving download to %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// SuggestedFilename returns the file name suggested by the browser,
+// which is usually based on the Content-Disposition response header
+// or the URL.
+func (d *Download) SuggestedFilename() string {
+	return d.suggestedFilename
+}
+
+// URL returns the URL of the download.
+func (d *Download) URL() string {
+	return d.url
+}
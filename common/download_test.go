@@ -0,0 +1,95 @@
+package common
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/xk6-browser/log"
+	"github.com/grafana/xk6-browser/storage"
+)
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+
+	newTestDownload := func(t *testing.T) *Download {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "guid")
+		require.NoError(t, os.WriteFile(path, []byte("hello"), 0o600))
+
+		return newDownload(
+			context.Background(), log.NewNullLogger(), nil,
+			"guid", "https://test.k6.io/file.txt", "file.txt", path,
+		)
+	}
+
+	t.Run("completed", func(t *testing.T) {
+		t.Parallel()
+
+		d := newTestDownload(t)
+		d.finish("")
+
+		assert.Equal(t, "https://test.k6.io/file.txt", d.URL())
+		assert.Equal(t, "file.txt", d.SuggestedFilename())
+
+		failure, err := d.Failure()
+		require.NoError(t, err)
+		assert.Empty(t, failure)
+
+		path, err := d.Path()
+		require.NoError(t, err)
+		assert.Equal(t, d.path, path)
+
+		r, err := d.CreateReadStream()
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		assert.Equal(t, "hello", string(b))
+
+		saveAs := filepath.Join(t.TempDir(), "saved.txt")
+		require.NoError(t, d.SaveAs(saveAs, &storage.LocalFilePersister{}))
+		b, err = os.ReadFile(saveAs) //nolint:gosec
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(b))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		d := newTestDownload(t)
+		d.finish("canceled")
+		d.finish("") // only the first call has an effect
+
+		failure, err := d.Failure()
+		require.NoError(t, err)
+		assert.Equal(t, "canceled", failure)
+
+		path, err := d.Path()
+		require.NoError(t, err)
+		assert.Empty(t, path)
+
+		_, err = d.CreateReadStream()
+		assert.ErrorContains(t, err, "canceled")
+		err = d.SaveAs(filepath.Join(t.TempDir(), "saved.txt"), &storage.LocalFilePersister{})
+		assert.ErrorContains(t, err, "canceled")
+	})
+
+	t.Run("context_done", func(t *testing.T) {
+		t.Parallel()
+
+		d := newTestDownload(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		d.ctx = ctx
+
+		_, err := d.Path()
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"github.com/grafana/sobek"
+)
+
+// FileChooser represents a file chooser dialog that is opened by a page,
+// e.g. by clicking on a file input element or a button that clicks on it.
+//
+// The dialog is not shown while the page has file chooser event handlers,
+// and the files are set on the file input element instead.
+type FileChooser struct {
+	page       *Page
+	element    *ElementHandle
+	isMultiple bool
+}
+
+func newFileChooser(p *Page, element *ElementHandle, isMultiple bool) *FileChooser {
+	return &FileChooser{
+		page:       p,
+		element:    element,
+		isMultiple: isMultiple,
+	}
+}
+
+// Element returns the file input element of the file chooser.
+func (fc *FileChooser) Element() *ElementHandle {
+	return fc.element
+}
+
+// IsMultiple returns true if the file chooser accepts multiple files.
+func (fc *FileChooser) IsMultiple() bool {
+	return fc.isMultiple
+}
+
+// Page returns the page that opened the file chooser.
+func (fc *FileChooser) Page() *Page {
+	return fc.page
+}
+
+// SetFiles sets the files of the file input element of the file chooser.
+func (fc *FileChooser) SetFiles(files sobek.Value, opts sobek.Value) error {
+	return fc.element.SetInputFiles(files, opts)
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -575,6 +577,112 @@ func (f *Frame) waitFor(selector string, opts *FrameWaitForSelectorOptions) erro
 	return err
 }
 
+// AddScriptTag adds a script tag with the URL or the content of a script
+// to the frame, and returns the tag once the script is loaded.
+func (f *Frame) AddScriptTag(opts *FrameAddScriptTagOptions) (*ElementHandle, error) {
+	f.log.Debugf("Frame:AddScriptTag", "fid:%s furl:%q url:%q path:%q", f.ID(), f.URL(), opts.URL, opts.Path)
+
+	content, err := tagContent(opts.Path, opts.Content, "\n//# sourceURL=%s")
+	if err != nil {
+		return nil, fmt.Errorf("adding script tag: %w", err)
+	}
+	js := `async (params) => {
+		const script = document.createElement('script');
+		if (params.url) {
+			script.src = params.url;
+		}
+		if (params.content) {
+			script.text = params.content;
+		}
+		if (params.type) {
+			script.type = params.type;
+		}
+		const loaded = new Promise((resolve, reject) => {
+			script.onload = resolve;
+			script.onerror = () => reject(new Error('failed to load script at ' + script.src));
+		});
+		document.head.appendChild(script);
+		if (params.url) {
+			await loaded;
+		}
+		return script;
+	}`
+	params := FrameAddScriptTagOptions{URL: opts.URL, Content: content, Type: opts.Type}
+	eh, err := f.addTag(js, params)
+	if err != nil {
+		return nil, fmt.Errorf("adding script tag: %w", err)
+	}
+
+	return eh, nil
+}
+
+// AddStyleTag adds a link tag with the URL of a stylesheet, or a style tag
+// with its content, to the frame, and returns the tag once the stylesheet
+// is loaded.
+func (f *Frame) AddStyleTag(opts *FrameAddStyleTagOptions) (*ElementHandle, error) {
+	f.log.Debugf("Frame:AddStyleTag", "fid:%s furl:%q url:%q path:%q", f.ID(), f.URL(), opts.URL, opts.Path)
+
+	content, err := tagContent(opts.Path, opts.Content, "\n/*# sourceURL=%s*/")
+	if err != nil {
+		return nil, fmt.Errorf("adding style tag: %w", err)
+	}
+	js := `async (params) => {
+		let tag;
+		if (params.url) {
+			tag = document.createElement('link');
+			tag.rel = 'stylesheet';
+			tag.href = params.url;
+		} else {
+			tag = document.createElement('style');
+			tag.type = 'text/css';
+			tag.appendChild(document.createTextNode(params.content));
+		}
+		const loaded = new Promise((resolve, reject) => {
+			tag.onload = resolve;
+			tag.onerror = () => reject(new Error('failed to load stylesheet at ' + params.url));
+		});
+		document.head.appendChild(tag);
+		await loaded;
+		return tag;
+	}`
+	params := FrameAddStyleTagOptions{URL: opts.URL, Content: content}
+	eh, err := f.addTag(js, params)
+	if err != nil {
+		return nil, fmt.Errorf("adding style tag: %w", err)
+	}
+
+	return eh, nil
+}
+
+// addTag evaluates the function that adds a tag in the main world of the
+// frame, and returns the added tag.
+func (f *Frame) addTag(js string, params any) (*ElementHandle, error) {
+	h, err := f.EvaluateHandle(js, params)
+	if err != nil {
+		return nil, err
+	}
+	eh := h.AsElement()
+	if eh == nil {
+		return nil, errors.New("added tag is not an element")
+	}
+
+	return eh, nil
+}
+
+// tagContent returns the content of the file at the path with a source
+// URL comment in the format, or the content if the path is empty.
+func tagContent(path, content, sourceURLFormat string) (string, error) {
+	if path == "" {
+		return content, nil
+	}
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	return string(b) + fmt.Sprintf(sourceURLFormat, strings.ReplaceAll(path, "\n", "")), nil
+}
+
 // ChildFrames returns a list of child frames.
 func (f *Frame) ChildFrames() []*Frame {
 	f.childFramesMu.RLock()
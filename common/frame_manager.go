@@ -469,6 +469,7 @@ func (m *FrameManager) requestFailed(req *Request, canceled bool) {
 	m.logger.Debugf("FrameManager:requestFailed", "fmid:%d rurl:%s", m.ID(), req.URL())
 
 	defer m.page.emit(EventPageRequestFailed, req)
+	defer m.page.callEventHandlers(EventPageRequestFailed, PageOnEvent{Request: req})
 
 	frame := req.getFrame()
 	if frame == nil {
@@ -500,6 +501,7 @@ func (m *FrameManager) requestFinished(req *Request) {
 		m.ID(), req.URL())
 
 	defer m.page.emit(EventPageRequestFinished, req)
+	defer m.page.callEventHandlers(EventPageRequestFinished, PageOnEvent{Request: req})
 
 	frame := req.getFrame()
 	if frame == nil {
@@ -521,6 +523,7 @@ func (m *FrameManager) requestReceivedResponse(res *Response) {
 	m.logger.Debugf("FrameManager:requestReceivedResponse", "fmid:%d rurl:%s", m.ID(), res.URL())
 
 	m.page.emit(EventPageResponse, res)
+	m.page.callEventHandlers(EventPageResponse, PageOnEvent{Response: res})
 }
 
 func (m *FrameManager) requestStarted(req *Request) {
@@ -529,6 +532,7 @@ func (m *FrameManager) requestStarted(req *Request) {
 	m.framesMu.Lock()
 	defer m.framesMu.Unlock()
 	defer m.page.emit(EventPageRequest, req)
+	defer m.page.callEventHandlers(EventPageRequest, PageOnEvent{Request: req})
 
 	frame := req.getFrame()
 	if frame == nil {
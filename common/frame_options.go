@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -13,6 +14,30 @@ import (
 	"github.com/grafana/xk6-browser/k6ext"
 )
 
+// FrameAddScriptTagOptions are the options of addScriptTag.
+// At least one of URL, Path or Content is required.
+type FrameAddScriptTagOptions struct {
+	// URL is the URL of the script.
+	URL string `json:"url"`
+	// Path is the path of a local file that has the content of the script.
+	Path string `json:"path"`
+	// Content is the content of the script.
+	Content string `json:"content"`
+	// Type is the type of the script, e.g. module.
+	Type string `json:"type"`
+}
+
+// FrameAddStyleTagOptions are the options of addStyleTag.
+// At least one of URL, Path or Content is required.
+type FrameAddStyleTagOptions struct {
+	// URL is the URL of the stylesheet.
+	URL string `json:"url"`
+	// Path is the path of a local file that has the content of the stylesheet.
+	Path string `json:"path"`
+	// Content is the content of the stylesheet.
+	Content string `json:"content"`
+}
+
 type FrameBaseOptions struct {
 	Timeout time.Duration `json:"timeout"`
 	Strict  bool          `json:"strict"`
@@ -198,6 +223,64 @@ type FrameWaitForSelectorOptions struct {
 	Timeout time.Duration   `json:"timeout"`
 }
 
+// NewFrameAddScriptTagOptions returns a new FrameAddScriptTagOptions.
+func NewFrameAddScriptTagOptions() *FrameAddScriptTagOptions {
+	return &FrameAddScriptTagOptions{}
+}
+
+// Parse parses the frame addScriptTag options.
+func (o *FrameAddScriptTagOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if sobekValueExists(opts) {
+		rt := k6ext.Runtime(ctx)
+		obj := opts.ToObject(rt)
+		for _, k := range obj.Keys() {
+			switch k {
+			case "content":
+				o.Content = obj.Get(k).String()
+			case "path":
+				o.Path = obj.Get(k).String()
+			case "type":
+				o.Type = obj.Get(k).String()
+			case "url":
+				o.URL = obj.Get(k).String()
+			}
+		}
+	}
+	if o.URL == "" && o.Path == "" && o.Content == "" {
+		return errors.New("provide an object with a url, path or content property")
+	}
+
+	return nil
+}
+
+// NewFrameAddStyleTagOptions returns a new FrameAddStyleTagOptions.
+func NewFrameAddStyleTagOptions() *FrameAddStyleTagOptions {
+	return &FrameAddStyleTagOptions{}
+}
+
+// Parse parses the frame addStyleTag options.
+func (o *FrameAddStyleTagOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if sobekValueExists(opts) {
+		rt := k6ext.Runtime(ctx)
+		obj := opts.ToObject(rt)
+		for _, k := range obj.Keys() {
+			switch k {
+			case "content":
+				o.Content = obj.Get(k).String()
+			case "path":
+				o.Path = obj.Get(k).String()
+			case "url":
+				o.URL = obj.Get(k).String()
+			}
+		}
+	}
+	if o.URL == "" && o.Path == "" && o.Content == "" {
+		return errors.New("provide an object with a url, path or content property")
+	}
+
+	return nil
+}
+
 func NewFrameBaseOptions(defaultTimeout time.Duration) *FrameBaseOptions {
 	return &FrameBaseOptions{
 		Timeout: defaultTimeout,
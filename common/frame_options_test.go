@@ -119,3 +119,50 @@ func TestFrameWaitForNavigationOptionsParse(t *testing.T) {
 				`load, domcontentloaded, networkidle`)
 	})
 }
+
+func TestFrameAddScriptTagOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := vu.ToSobekValue(map[string]any{
+			"content": "window.k6 = true",
+			"type":    "module",
+		})
+		tagOpts := NewFrameAddScriptTagOptions()
+		require.NoError(t, tagOpts.Parse(vu.Context(), opts))
+
+		assert.Equal(t, "window.k6 = true", tagOpts.Content)
+		assert.Equal(t, "module", tagOpts.Type)
+		assert.Empty(t, tagOpts.URL)
+		assert.Empty(t, tagOpts.Path)
+	})
+
+	t.Run("err/no_source", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := vu.ToSobekValue(map[string]any{
+			"type": "module",
+		})
+		err := NewFrameAddScriptTagOptions().Parse(vu.Context(), opts)
+		assert.EqualError(t, err, "provide an object with a url, path or content property")
+	})
+}
+
+func TestFrameAddStyleTagOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := vu.ToSobekValue(map[string]any{
+		"url": "https://test.k6.io/style.css",
+	})
+	tagOpts := NewFrameAddStyleTagOptions()
+	require.NoError(t, tagOpts.Parse(vu.Context(), opts))
+	assert.Equal(t, "https://test.k6.io/style.css", tagOpts.URL)
+
+	err := NewFrameAddStyleTagOptions().Parse(vu.Context(), nil)
+	assert.EqualError(t, err, "provide an object with a url, path or content property")
+}
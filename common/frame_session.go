@@ -2,10 +2,12 @@ package common
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -252,6 +254,8 @@ func (fs *FrameSession) initEvents() {
 					fs.onTargetCrashed(ev)
 				case *cdplog.EventEntryAdded:
 					fs.onLogEntryAdded(ev)
+				case *cdppage.EventFileChooserOpened:
+					fs.onFileChooserOpened(ev)
 				case *cdppage.EventFrameAttached:
 					fs.onFrameAttached(ev.FrameID, ev.ParentFrameID)
 				case *cdppage.EventFrameDetached:
@@ -269,6 +273,8 @@ func (fs *FrameSession) initEvents() {
 					fs.onPageLifecycle(ev)
 				case *cdppage.EventNavigatedWithinDocument:
 					fs.onPageNavigatedWithinDocument(ev)
+				case *cdppage.EventScreencastFrame:
+					fs.onScreencastFrame(ev)
 				case *cdpruntime.EventConsoleAPICalled:
 					fs.onConsoleAPICalled(ev)
 				case *cdpruntime.EventExceptionThrown:
@@ -298,12 +304,30 @@ func (fs *FrameSession) onEventBindingCalled(event *cdpruntime.EventBindingCalle
 		"sid:%v tid:%v name:%s payload:%s",
 		fs.session.ID(), fs.targetID, event.Name, event.Payload)
 
+	if event.Name == exposedBinding {
+		// The exposed function may take a while, e.g. if it waits for
+		// the page, so it shouldn't block the processing of the events.
+		go fs.page.onBindingCalled(event)
+		return
+	}
+
+	if event.Name == userMetricBinding {
+		if err := fs.parseAndEmitUserMetric(event.Payload); err != nil {
+			fs.logger.Errorf("FrameSession:onEventBindingCalled", "failed to emit user metric: %v", err)
+		}
+		return
+	}
+
 	err := fs.parseAndEmitWebVitalMetric(event.Payload)
 	if err != nil {
 		fs.logger.Errorf("FrameSession:onEventBindingCalled", "failed to emit web vital metric: %v", err)
 	}
 }
 
+// longTaskWebVital is the name of the long tasks that the web vitals
+// script reports alongside the web vitals.
+const longTaskWebVital = "LongTask"
+
 func (fs *FrameSession) parseAndEmitWebVitalMetric(object string) error {
 	fs.logger.Debugf("FrameSession:parseAndEmitWebVitalMetric", "object:%s", object)
 
@@ -315,6 +339,7 @@ func (fs *FrameSession) parseAndEmitWebVitalMetric(object string) error {
 		Delta          json.Number
 		NumEntries     json.Number
 		NavigationType string
+		EntryType      string
 		URL            string
 		SpanID         string
 	}{}
@@ -323,8 +348,13 @@ func (fs *FrameSession) parseAndEmitWebVitalMetric(object string) error {
 		return fmt.Errorf("json couldn't be parsed: %w", err)
 	}
 
+	if wv.Name == webVitalsReported {
+		fs.page.onWebVitalsReported(wv.ID)
+		return nil
+	}
+
 	metric, ok := fs.k6Metrics.WebVitals[wv.Name]
-	if !ok {
+	if !ok && wv.Name != longTaskWebVital {
 		return fmt.Errorf("metric not registered %q", wv.Name)
 	}
 
@@ -339,18 +369,38 @@ func (fs *FrameSession) parseAndEmitWebVitalMetric(object string) error {
 		tags = tags.With("url", wv.URL)
 	}
 
-	tags = tags.With("rating", wv.Rating)
-
 	now := time.Now()
-	k6metrics.PushIfNotDone(fs.vu.Context(), state.Samples, k6metrics.ConnectedSamples{
-		Samples: []k6metrics.Sample{
-			{
-				TimeSeries: k6metrics.TimeSeries{Metric: metric, Tags: tags},
-				Value:      value,
-				Time:       now,
+	sample := func(m *k6metrics.Metric, v float64) k6metrics.Sample {
+		return k6metrics.Sample{
+			TimeSeries: k6metrics.TimeSeries{Metric: m, Tags: tags},
+			Value:      v,
+			Time:       now,
+		}
+	}
+
+	if wv.Name == longTaskWebVital {
+		// The long tasks are not rated, and they're either long
+		// tasks or long animation frames, depending on which one
+		// the browser supports. They're not traced as web vitals.
+		tags = tags.With("type", wv.EntryType)
+		k6metrics.PushIfNotDone(fs.vu.Context(), state.Samples, k6metrics.ConnectedSamples{
+			Samples: []k6metrics.Sample{
+				sample(fs.k6Metrics.LongTaskDuration, value),
+				sample(fs.k6Metrics.LongTaskCount, 1),
 			},
-		},
+		})
+		return nil
+	}
+
+	tags = tags.With("rating", wv.Rating)
+	k6metrics.PushIfNotDone(fs.vu.Context(), state.Samples, k6metrics.ConnectedSamples{
+		Samples: []k6metrics.Sample{sample(metric, value)},
 	})
+	// The performance trace might only be kept if a web vital
+	// breaches its poor threshold.
+	if wv.Rating == "poor" {
+		fs.page.browserCtx.browser.onPoorWebVital(fs.page)
+	}
 
 	_, span := TraceEvent(
 		fs.ctx, fs.targetID.String(), "web_vital", wv.SpanID, trace.WithAttributes(
@@ -363,24 +413,159 @@ func (fs *FrameSession) parseAndEmitWebVitalMetric(object string) error {
 	return nil
 }
 
+// parseAndEmitUserMetric emits the performance marks and measures of a
+// page, and the metrics that it emits with window.k6.
+func (fs *FrameSession) parseAndEmitUserMetric(object string) error {
+	fs.logger.Debugf("FrameSession:parseAndEmitUserMetric", "object:%s", object)
+
+	um := struct {
+		Type  string
+		Name  string
+		Value json.Number
+		Tags  map[string]string
+		URL   string
+	}{}
+
+	if err := json.Unmarshal([]byte(object), &um); err != nil {
+		return fmt.Errorf("json couldn't be parsed: %w", err)
+	}
+
+	value, err := um.Value.Float64()
+	if err != nil {
+		return fmt.Errorf("value couldn't be parsed %q", um.Value)
+	}
+
+	var (
+		name = um.Name
+		typ  = k6metrics.Trend
+		vt   = k6metrics.Default
+	)
+	switch um.Type {
+	case "mark", "measure":
+		name = fs.page.browserCtx.opts.UserMetrics.Prefix + userTimingMetricName(um.Name)
+		vt = k6metrics.Time
+	case "counter":
+		name = fs.page.browserCtx.opts.UserMetrics.PagePrefix + um.Name
+		typ = k6metrics.Counter
+	case "trend":
+		name = fs.page.browserCtx.opts.UserMetrics.PagePrefix + um.Name
+	default:
+		return fmt.Errorf("unknown user metric type %q", um.Type)
+	}
+	userMetrics := k6ext.GetUserMetrics(fs.ctx)
+	if userMetrics == nil {
+		return fmt.Errorf("emitting user metric %q: user metrics are not available", name)
+	}
+	metric, err := userMetrics.Metric(name, typ, vt)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	state := fs.vu.State()
+	tags := state.Tags.GetCurrentValues().Tags
+	if state.Options.SystemTags.Has(k6metrics.TagURL) {
+		tags = tags.With("url", um.URL)
+	}
+	if um.Type == "mark" || um.Type == "measure" {
+		tags = tags.With("type", um.Type)
+	}
+	tags, dropped := withUserMetricTags(tags, um.Tags)
+	if len(dropped) > 0 {
+		fs.logger.Warnf("FrameSession:parseAndEmitUserMetric",
+			"dropping the tags %v of the metric %q as they clash with the system tags", dropped, name)
+	}
+
+	k6metrics.PushIfNotDone(fs.vu.Context(), state.Samples, k6metrics.ConnectedSamples{
+		Samples: []k6metrics.Sample{
+			{
+				TimeSeries: k6metrics.TimeSeries{Metric: metric, Tags: tags},
+				Value:      value,
+				Time:       time.Now(),
+			},
+		},
+	})
+
+	return nil
+}
+
+// withUserMetricTags adds the tags that a page sets on a metric to tags.
+// The page can't override the system tags, or the tags that are already
+// set, so these are dropped and returned instead.
+func withUserMetricTags(
+	tags *k6metrics.TagSet, userTags map[string]string,
+) (_ *k6metrics.TagSet, dropped []string) {
+	for k, v := range userTags {
+		if _, err := k6metrics.SystemTagString(k); err == nil {
+			dropped = append(dropped, k)
+			continue
+		}
+		if _, ok := tags.Get(k); ok {
+			dropped = append(dropped, k)
+			continue
+		}
+		tags = tags.With(k, v)
+	}
+	sort.Strings(dropped)
+
+	return tags, dropped
+}
+
+// userTimingMetricName turns the name of a performance mark or measure,
+// such as checkout-render, into a valid metric name by replacing the
+// characters that are not allowed in the metric names with underscores.
+func userTimingMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
 func (fs *FrameSession) onEventJavascriptDialogOpening(event *cdppage.EventJavascriptDialogOpening) {
 	fs.logger.Debugf("FrameSession:onEventJavascriptDialogOpening",
 		"sid:%v tid:%v url:%v dialogType:%s",
 		fs.session.ID(), fs.targetID, event.URL, event.Type)
 
-	// Dialog type of beforeunload needs to accept the
-	// dialog, instead of dismissing it. We're unable to
-	// dismiss beforeunload dialog boxes at the moment as
-	// it seems to pause the exec of any other action on
-	// the page. I believe this is an issue in Chromium.
-	action := cdppage.HandleJavaScriptDialog(false)
-	if event.Type == cdppage.DialogTypeBeforeunload {
-		action = cdppage.HandleJavaScriptDialog(true)
+	// Let the page.on('dialog') handlers decide whether to accept or
+	// dismiss the dialog. It's handled by default if they don't handle
+	// it in time, so that the page doesn't stay blocked.
+	d := newDialog(fs.ctx, fs.logger, fs.session, event)
+	if fs.page.hasEventHandlers(EventPageDialog) {
+		d.handleByDefaultAfter(fs.page.Timeout())
+		fs.page.callEventHandlers(EventPageDialog, PageOnEvent{Dialog: d})
+		return
 	}
 
-	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
-		fs.logger.Errorf("FrameSession:onEventJavascriptDialogOpening", "failed to dismiss dialog box: %v", err)
+	d.handleByDefault()
+}
+
+func (fs *FrameSession) onFileChooserOpened(event *cdppage.EventFileChooserOpened) {
+	fs.logger.Debugf("FrameSession:onFileChooserOpened",
+		"sid:%v tid:%v fid:%v mode:%s",
+		fs.session.ID(), fs.targetID, event.FrameID, event.Mode)
+
+	if !fs.page.hasEventHandlers(EventPageFilechooser) {
+		return
 	}
+	frame, ok := fs.manager.getFrameByID(event.FrameID)
+	if !ok {
+		fs.logger.Debugf("FrameSession:onFileChooserOpened:return",
+			"sid:%v tid:%v fid:%v cannot find frame",
+			fs.session.ID(), fs.targetID, event.FrameID)
+		return
+	}
+	element, err := frame.adoptBackendNodeID(mainWorld, event.BackendNodeID)
+	if err != nil {
+		fs.logger.Errorf("FrameSession:onFileChooserOpened", "adopting file input element: %v", err)
+		return
+	}
+
+	isMultiple := event.Mode == cdppage.FileChooserOpenedModeSelectMultiple
+	fc := newFileChooser(fs.page, element, isMultiple)
+	fs.page.callEventHandlers(EventPageFilechooser, PageOnEvent{FileChooser: fc})
 }
 
 func (fs *FrameSession) initFrameTree() error {
@@ -473,7 +658,6 @@ func (fs *FrameSession) initOptions() error {
 	var (
 		opts       = fs.manager.page.browserCtx.opts
 		optActions = []Action{}
-		state      = fs.vu.State()
 	)
 
 	if fs.isMainFrame() {
@@ -517,12 +701,10 @@ func (fs *FrameSession) initOptions() error {
 		return err
 	}
 
-	var reqIntercept bool
-	if state.Options.BlockedHostnames.Trie != nil ||
-		len(state.Options.BlacklistIPs) > 0 {
-		reqIntercept = true
+	if err := fs.updateRequestInterception(); err != nil {
+		return err
 	}
-	if err := fs.updateRequestInterception(reqIntercept); err != nil {
+	if err := fs.updateFileChooserInterception(true); err != nil {
 		return err
 	}
 
@@ -536,8 +718,11 @@ func (fs *FrameSession) initOptions() error {
 		return err
 	}
 
-	// if (screencastOptions)
-	//   promises.push(this._startVideoRecording(screencastOptions));
+	if fs.isMainFrame() && fs.page.video != nil {
+		if err := fs.startScreencast(fs.page.video.size); err != nil {
+			return fmt.Errorf("starting video recording: %w", err)
+		}
+	}
 
 	/*for (const source of this._crPage._browserContext._evaluateOnNewDocumentSources)
 	      promises.push(this._evaluateOnNewDocument(source, 'main'));
@@ -569,6 +754,7 @@ func (fs *FrameSession) initRendererEvents() {
 		cdproto.EventPageJavascriptDialogOpening,
 		cdproto.EventPageLifecycleEvent,
 		cdproto.EventPageNavigatedWithinDocument,
+		cdproto.EventPageScreencastFrame,
 		cdproto.EventRuntimeConsoleAPICalled,
 		cdproto.EventRuntimeExceptionThrown,
 		cdproto.EventRuntimeExecutionContextCreated,
@@ -656,7 +842,34 @@ func (fs *FrameSession) onConsoleAPICalled(event *cdpruntime.EventConsoleAPICall
 }
 
 func (fs *FrameSession) onExceptionThrown(event *cdpruntime.EventExceptionThrown) {
-	fs.page.emit(EventPageError, event.ExceptionDetails)
+	pe := newPageError(event.ExceptionDetails)
+
+	fs.logger.Debugf("FrameSession:onExceptionThrown",
+		"sid:%v tid:%v name:%s message:%q",
+		fs.session.ID(), fs.targetID, pe.Name, pe.Message)
+
+	fs.emitJSErrorMetric()
+	fs.page.emit(EventPageError, pe)
+	fs.page.callEventHandlers(EventPageError, PageOnEvent{PageError: pe})
+}
+
+// emitJSErrorMetric counts an uncaught exception thrown in the page.
+func (fs *FrameSession) emitJSErrorMetric() {
+	state := fs.vu.State()
+	tags := state.Tags.GetCurrentValues().Tags
+	if state.Options.SystemTags.Has(k6metrics.TagURL) {
+		tags = tags.With("url", fs.page.MainFrame().URL())
+	}
+
+	k6metrics.PushIfNotDone(fs.vu.Context(), state.Samples, k6metrics.ConnectedSamples{
+		Samples: []k6metrics.Sample{
+			{
+				TimeSeries: k6metrics.TimeSeries{Metric: fs.k6Metrics.BrowserJSErrors, Tags: tags},
+				Value:      1,
+				Time:       time.Now(),
+			},
+		},
+	})
 }
 
 func (fs *FrameSession) onExecutionContextCreated(event *cdpruntime.EventExecutionContextCreated) {
@@ -898,6 +1111,31 @@ func (fs *FrameSession) onPageNavigatedWithinDocument(event *cdppage.EventNaviga
 	fs.manager.frameNavigatedWithinDocument(event.FrameID, event.URL)
 }
 
+// onScreencastFrame adds the screencast frame to the video and to the
+// filmstrip of the page.
+// The browser doesn't send the next frame until the frame is acknowledged.
+func (fs *FrameSession) onScreencastFrame(event *cdppage.EventScreencastFrame) {
+	action := cdppage.ScreencastFrameAck(event.SessionID)
+	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		fs.logger.Debugf("FrameSession:onScreencastFrame", "sid:%v tid:%v acknowledging frame: %v",
+			fs.session.ID(), fs.targetID, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(event.Data)
+	if err != nil {
+		fs.logger.Errorf("FrameSession:onScreencastFrame", "decoding frame: %v", err)
+		return
+	}
+	timestamp := time.Now()
+	if event.Metadata != nil && event.Metadata.Timestamp != nil {
+		timestamp = event.Metadata.Timestamp.Time()
+	}
+	if v := fs.page.video; v != nil {
+		v.addFrame(data, timestamp)
+	}
+	fs.page.addFilmstripFrame(data, timestamp)
+}
+
 func (fs *FrameSession) onAttachedToTarget(event *target.EventAttachedToTarget) {
 	var (
 		ti  = event.TargetInfo
@@ -1036,6 +1274,34 @@ func (fs *FrameSession) onTargetCrashed(event *inspector.EventTargetCrashed) {
 	fs.page.didCrash()
 }
 
+// startScreencast starts sending the frames of the page that fit into
+// the size to onScreencastFrame.
+func (fs *FrameSession) startScreencast(size Viewport) error {
+	fs.logger.Debugf("NewFrameSession:startScreencast",
+		"sid:%v tid:%v size:%s", fs.session.ID(), fs.targetID, size)
+
+	action := cdppage.StartScreencast().
+		WithFormat(cdppage.ScreencastFormatJpeg).
+		WithQuality(90).
+		WithMaxWidth(size.Width).
+		WithMaxHeight(size.Height)
+	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("starting screencast: %w", err)
+	}
+
+	return nil
+}
+
+func (fs *FrameSession) stopScreencast() error {
+	fs.logger.Debugf("NewFrameSession:stopScreencast", "sid:%v tid:%v", fs.session.ID(), fs.targetID)
+
+	if err := cdppage.StopScreencast().Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("stopping screencast: %w", err)
+	}
+
+	return nil
+}
+
 func (fs *FrameSession) updateEmulateMedia(initial bool) error {
 	fs.logger.Debugf("NewFrameSession:updateEmulateMedia", "sid:%v tid:%v", fs.session.ID(), fs.targetID)
 
@@ -1086,6 +1352,25 @@ func (fs *FrameSession) updateExtraHTTPHeaders(initial bool) error {
 	return nil
 }
 
+// updateFileChooserInterception intercepts the file chooser dialogs if
+// the page has any filechooser event handlers.
+func (fs *FrameSession) updateFileChooserInterception(initial bool) error {
+	enable := fs.page.hasEventHandlers(EventPageFilechooser)
+	if initial && !enable {
+		return nil
+	}
+
+	fs.logger.Debugf("NewFrameSession:updateFileChooserInterception",
+		"sid:%v tid:%v on:%v", fs.session.ID(), fs.targetID, enable)
+
+	action := cdppage.SetInterceptFileChooserDialog(enable)
+	if err := action.Do(cdp.WithExecutor(fs.ctx, fs.session)); err != nil {
+		return fmt.Errorf("setting file chooser interception: %w", err)
+	}
+
+	return nil
+}
+
 func (fs *FrameSession) updateGeolocation(initial bool) error {
 	fs.logger.Debugf("NewFrameSession:updateGeolocation", "sid:%v tid:%v", fs.session.ID(), fs.targetID)
 
@@ -1142,13 +1427,22 @@ func (fs *FrameSession) throttleCPU(cpuProfile CPUProfile) error {
 	return nil
 }
 
-func (fs *FrameSession) updateRequestInterception(enable bool) error {
+// updateRequestInterception enables request interception if there are
+// blocked hosts or IPs, if the page has any routes, or if the trace
+// context is propagated to the requests.
+func (fs *FrameSession) updateRequestInterception() error {
+	state := fs.vu.State()
+	enable := state.Options.BlockedHostnames.Trie != nil ||
+		len(state.Options.BlacklistIPs) > 0 ||
+		fs.page.hasRoutes() ||
+		fs.page.browserCtx.opts.TraceContext != nil
+
 	fs.logger.Debugf("NewFrameSession:updateRequestInterception",
 		"sid:%v tid:%v on:%v",
 		fs.session.ID(),
 		fs.targetID, enable)
 
-	return fs.networkManager.setRequestInterception(enable || fs.page.hasRoutes())
+	return fs.networkManager.setRequestInterception(enable)
 }
 
 func (fs *FrameSession) updateViewport() error {
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k6metrics "go.k6.io/k6/metrics"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+func TestUserTimingMetricName(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{
+		"checkout":           "checkout",
+		"checkout-render":    "checkout_render",
+		"checkout render #2": "checkout_render__2",
+		"app:cart.add_item":  "app_cart_add_item",
+		"ünicode":            "_nicode",
+	} {
+		assert.Equal(t, want, userTimingMetricName(name), name)
+	}
+}
+
+func TestWithUserMetricTags(t *testing.T) {
+	t.Parallel()
+
+	registry := k6metrics.NewRegistry()
+	tags := registry.RootTagSet().With("scenario", "default").With("team", "checkout")
+
+	got, dropped := withUserMetricTags(tags, map[string]string{
+		"step":     "payment",
+		"url":      "https://evil.test",
+		"scenario": "other",
+		"team":     "other",
+	})
+	assert.Equal(t, []string{"scenario", "team", "url"}, dropped)
+	assert.Equal(t, map[string]string{
+		"scenario": "default",
+		"team":     "checkout",
+		"step":     "payment",
+	}, got.Map())
+}
+
+func TestUserMetric(t *testing.T) {
+	t.Parallel()
+
+	registry := k6metrics.NewRegistry()
+	registry.MustNewMetric("checks_total", k6metrics.Counter)
+	k6ext.RegisterCustomMetrics(registry)
+	um := k6ext.NewUserMetrics(registry)
+
+	_, err := um.Metric("checks_total", k6metrics.Counter, k6metrics.Default)
+	assert.ErrorContains(t, err, `metric "checks_total" is already registered`)
+	_, err = um.Metric("browser_web_vital_lcp", k6metrics.Trend, k6metrics.Time)
+	assert.ErrorContains(t, err, `metric "browser_web_vital_lcp" is already registered`)
+
+	// The metrics that the pages registered can be emitted again,
+	// including by the pages of the other VUs of the module instance.
+	m, err := um.Metric("browser_page_cart_items", k6metrics.Counter, k6metrics.Default)
+	require.NoError(t, err)
+	got, err := um.Metric("browser_page_cart_items", k6metrics.Counter, k6metrics.Default)
+	require.NoError(t, err)
+	assert.Same(t, m, got)
+
+	// The names are not kept across module instances.
+	_, err = k6ext.NewUserMetrics(registry).Metric("browser_page_cart_items", k6metrics.Counter, k6metrics.Default)
+	assert.ErrorContains(t, err, `metric "browser_page_cart_items" is already registered`)
+}
@@ -2,6 +2,8 @@ package common
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -110,3 +112,20 @@ func (e executionContextTestStub) eval(
 ) (res any, err error) {
 	return e.evalFn(apiCtx, opts, js, args...)
 }
+
+func TestTagContent(t *testing.T) {
+	t.Parallel()
+
+	got, err := tagContent("", "window.k6 = true", "\n//# sourceURL=%s")
+	require.NoError(t, err)
+	require.Equal(t, "window.k6 = true", got)
+
+	path := filepath.Join(t.TempDir(), "polyfill.js")
+	require.NoError(t, os.WriteFile(path, []byte("window.k6 = true"), 0o600))
+	got, err = tagContent(path, "ignored", "\n//# sourceURL=%s")
+	require.NoError(t, err)
+	require.Equal(t, "window.k6 = true\n//# sourceURL="+path, got)
+
+	_, err = tagContent(filepath.Join(t.TempDir(), "missing.js"), "", "\n//# sourceURL=%s")
+	require.ErrorContains(t, err, "reading file")
+}
@@ -0,0 +1,561 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/target"
+
+	k6consts "go.k6.io/k6/lib/consts"
+)
+
+// HARContentPolicy controls how the response bodies are stored in a HAR.
+type HARContentPolicy string
+
+const (
+	// HARContentOmit doesn't store the response bodies.
+	HARContentOmit HARContentPolicy = "omit"
+
+	// HARContentEmbed stores the response bodies inside the HAR file.
+	HARContentEmbed HARContentPolicy = "embed"
+
+	// HARContentAttach stores the response bodies in separate files next to
+	// the HAR file.
+	HARContentAttach HARContentPolicy = "attach"
+)
+
+// HAR is an HTTP Archive 1.2 document.
+//
+// http://www.softwareishard.com/blog/har-12-spec/.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of the exported HAR data.
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator HARCreator  `json:"creator"`
+	Browser *HARCreator `json:"browser,omitempty"`
+	Pages   []*HARPage  `json:"pages"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+// HARCreator is the name and version of the application that created the
+// HAR, or of the browser that made the requests.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HARPage is a page that the recorded requests belong to.
+type HARPage struct {
+	StartedDateTime time.Time      `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     HARPageTimings `json:"pageTimings"`
+}
+
+// HARPageTimings are the page load timings. The timings are -1 when
+// they're not available.
+type HARPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+// HAREntry is a single recorded request and its response.
+type HAREntry struct {
+	PageRef         string           `json:"pageref,omitempty"`
+	StartedDateTime time.Time        `json:"startedDateTime"`
+	Time            float64          `json:"time"`
+	Request         HARRequest       `json:"request"`
+	Response        HARResponse      `json:"response"`
+	Cache           struct{}         `json:"cache"`
+	Timings         HARTimings       `json:"timings"`
+	ServerIPAddress string           `json:"serverIPAddress,omitempty"`
+	ServerPort      int64            `json:"_serverPort,omitempty"`
+	SecurityDetails *SecurityDetails `json:"_securityDetails,omitempty"`
+	ResourceType    string           `json:"_resourceType,omitempty"`
+}
+
+// HARRequest is the recorded request of an entry.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse is the recorded response of an entry.
+type HARResponse struct {
+	Status      int64          `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	FailureText string         `json:"_failureText,omitempty"`
+}
+
+// HARContent is the response body of an entry.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	// File is the name of the file that holds the body when the
+	// response bodies are attached.
+	File string `json:"_file,omitempty"`
+	// SHA1 is the name of the resource that holds the body in a trace
+	// archive.
+	SHA1 string `json:"_sha1,omitempty"`
+}
+
+// HARCookie is a cookie sent with a request or set by a response.
+type HARCookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+	SameSite string     `json:"sameSite,omitempty"`
+}
+
+// HARNameValue is a header or a query string parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the posted data of a request.
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []HARNameValue `json:"params"`
+	Text     string         `json:"text"`
+}
+
+// HARTimings are the durations in milliseconds of the request phases.
+// The durations are -1 when a phase doesn't apply to the request.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// total returns the total time of the request, which excludes the
+// SSL time since it's already a part of the connect time.
+func (t HARTimings) total() float64 {
+	var total float64
+	for _, d := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if d > 0 {
+			total += d
+		}
+	}
+	return total
+}
+
+// newHARTimings calculates the request phase durations from the resource
+// timing of a response. responseEnd is the time in milliseconds when the
+// response finished loading relative to the start of the request.
+func newHARTimings(t *network.ResourceTiming, responseEnd float64) HARTimings {
+	if t == nil {
+		return HARTimings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			SSL:     -1,
+			Receive: math.Max(0, responseEnd),
+		}
+	}
+
+	timings := HARTimings{
+		Blocked: -1,
+		DNS:     -1,
+		Connect: -1,
+		SSL:     -1,
+		Send:    math.Max(0, t.SendEnd-t.SendStart),
+		Wait:    math.Max(0, t.ReceiveHeadersEnd-t.SendEnd),
+		Receive: math.Max(0, responseEnd-t.ReceiveHeadersEnd),
+	}
+	for _, start := range []float64{t.DNSStart, t.ConnectStart, t.SendStart} {
+		if start >= 0 {
+			timings.Blocked = start
+			break
+		}
+	}
+	if t.DNSStart >= 0 {
+		timings.DNS = t.DNSEnd - t.DNSStart
+	}
+	if t.ConnectStart >= 0 {
+		timings.Connect = t.ConnectEnd - t.ConnectStart
+	}
+	if t.SslStart >= 0 {
+		timings.SSL = t.SslEnd - t.SslStart
+	}
+
+	return timings
+}
+
+// harRecorder records the requests of a browser context into a HAR.
+type harRecorder struct {
+	opts *RecordHAROptions
+
+	mu          sync.Mutex
+	pages       []*HARPage
+	pageRefs    map[target.ID]*HARPage
+	entries     []*HAREntry
+	attachments map[string][]byte
+
+	saveOnce sync.Once
+}
+
+func newHARRecorder(opts *RecordHAROptions) *harRecorder {
+	return &harRecorder{
+		opts:        opts,
+		pageRefs:    make(map[target.ID]*HARPage),
+		attachments: make(map[string][]byte),
+	}
+}
+
+// record adds the request of the page and its response, if any, to the HAR.
+func (r *harRecorder) record(p *Page, req *Request) {
+	if r.opts.URLFilter != nil && !r.opts.URLFilter.Match(req.URL()) {
+		return
+	}
+
+	req.responseMu.RLock()
+	resp := req.response
+	req.responseMu.RUnlock()
+
+	entry := newHAREntry(req, resp)
+	body := r.responseBody(resp)
+	if body != nil {
+		entry.Response.Content.Size = int64(len(body))
+		entry.Response.BodySize = int64(len(body))
+	}
+	switch r.opts.Content {
+	case HARContentOmit:
+	case HARContentAttach:
+		if len(body) > 0 {
+			entry.Response.Content.File = harAttachmentName(body, entry.Response.Content.MimeType)
+		}
+	default:
+		if utf8.Valid(body) {
+			entry.Response.Content.Text = string(body)
+		} else {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+			entry.Response.Content.Encoding = "base64"
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	page, ok := r.pageRefs[p.targetID]
+	if !ok {
+		page = &HARPage{
+			StartedDateTime: req.wallTime,
+			ID:              fmt.Sprintf("page_%d", len(r.pages)+1),
+			PageTimings:     HARPageTimings{OnContentLoad: -1, OnLoad: -1},
+		}
+		r.pages = append(r.pages, page)
+		r.pageRefs[p.targetID] = page
+	}
+	if req.isNavigationRequest && (page.Title == "" || req.frame == nil || req.frame.ParentFrame() == nil) {
+		page.Title = req.URL()
+	}
+	entry.PageRef = page.ID
+	if entry.Response.Content.File != "" {
+		r.attachments[entry.Response.Content.File] = body
+	}
+	r.entries = append(r.entries, entry)
+}
+
+// responseBody returns the response body if the content of the
+// responses is recorded.
+func (r *harRecorder) responseBody(resp *Response) []byte {
+	if resp == nil || r.opts.Content == HARContentOmit || (resp.status >= 300 && resp.status <= 399) {
+		return nil
+	}
+	body, err := resp.Body()
+	if err != nil {
+		resp.logger.Debugf("harRecorder:responseBody", "url:%s err:%v", resp.url, err)
+		return nil
+	}
+
+	return body
+}
+
+// har returns the recorded HAR.
+func (r *harRecorder) har(browserVersion string) *HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*HAREntry, len(r.entries))
+	copy(entries, r.entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	return &HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "k6 browser", Version: k6consts.Version},
+			Browser: &HARCreator{Name: "chromium", Version: browserVersion},
+			Pages:   append([]*HARPage{}, r.pages...),
+			Entries: entries,
+		},
+	}
+}
+
+// save persists the HAR and the attached response bodies. It only saves
+// them once, since a browser context can be closed by the user and then
+// again when the browser closes.
+func (r *harRecorder) save(ctx context.Context, sp ScreenshotPersister, browserVersion string) error {
+	var err error
+	r.saveOnce.Do(func() {
+		err = r.persist(ctx, sp, browserVersion)
+	})
+
+	return err
+}
+
+func (r *harRecorder) persist(ctx context.Context, sp ScreenshotPersister, browserVersion string) error {
+	b, err := json.MarshalIndent(r.har(browserVersion), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling HAR: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dir := filepath.Dir(r.opts.Path)
+	for name, body := range r.attachments {
+		if err := sp.Persist(ctx, filepath.Join(dir, name), bytes.NewReader(body)); err != nil {
+			return fmt.Errorf("persisting HAR attachment %q: %w", name, err)
+		}
+	}
+	if err := sp.Persist(ctx, r.opts.Path, bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("persisting HAR to %q: %w", r.opts.Path, err)
+	}
+
+	return nil
+}
+
+// newHAREntry returns a HAR entry for the request and its response.
+// The response is nil if the request failed before receiving one.
+func newHAREntry(req *Request, resp *Response) *HAREntry {
+	entry := &HAREntry{
+		StartedDateTime: req.wallTime,
+		Request: HARRequest{
+			Method:      req.method,
+			URL:         req.URL(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     harRequestCookies(req.headers),
+			Headers:     harHeaders(req.headers),
+			QueryString: harQueryString(req),
+			HeadersSize: -1,
+			BodySize:    int64(len(req.postData)),
+		},
+		Response: HARResponse{
+			Cookies:     []HARCookie{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+			FailureText: req.errorText,
+		},
+		ResourceType: strings.ToLower(req.resourceType),
+	}
+	if req.postData != "" {
+		entry.Request.PostData = &HARPostData{
+			MimeType: harHeaderValue(req.headers, "content-type"),
+			Params:   []HARNameValue{},
+			Text:     req.postData,
+		}
+	}
+	if resp == nil {
+		entry.Timings = newHARTimings(nil, req.responseEndTiming)
+		entry.Time = entry.Timings.total()
+		return entry
+	}
+
+	httpVersion := harHTTPVersion(resp.protocol)
+	entry.Request.HTTPVersion = httpVersion
+	entry.Request.HeadersSize = req.headersSize()
+	entry.Response = HARResponse{
+		Status:      resp.status,
+		StatusText:  resp.statusText,
+		HTTPVersion: httpVersion,
+		Cookies:     harResponseCookies(resp.headers),
+		Headers:     harHeaders(resp.headers),
+		Content: HARContent{
+			MimeType: harHeaderValue(resp.headers, "content-type"),
+		},
+		RedirectURL: harHeaderValue(resp.headers, "location"),
+		HeadersSize: resp.headersSize(),
+		BodySize:    -1,
+		FailureText: req.errorText,
+	}
+	if resp.remoteAddress != nil {
+		entry.ServerIPAddress = strings.Trim(resp.remoteAddress.IPAddress, "[]")
+		entry.ServerPort = resp.remoteAddress.Port
+	}
+	entry.SecurityDetails = resp.securityDetails
+	entry.Timings = newHARTimings(resp.timing, req.responseEndTiming)
+	entry.Time = entry.Timings.total()
+
+	return entry
+}
+
+// harHTTPVersion returns the HTTP version of the CDP network protocol.
+func harHTTPVersion(protocol string) string {
+	switch p := strings.ToLower(protocol); {
+	case p == "h2":
+		return "HTTP/2.0"
+	case p == "h3" || strings.HasPrefix(p, "h3-"):
+		return "HTTP/3.0"
+	case strings.HasPrefix(p, "http/"):
+		return strings.ToUpper(p)
+	case p == "":
+		return "HTTP/1.1"
+	default:
+		return protocol
+	}
+}
+
+// harHeaders returns the headers sorted by name. CDP joins the values of
+// headers that are sent more than once with new lines.
+func harHeaders(headers map[string][]string) []HARNameValue {
+	nvs := make([]HARNameValue, 0, len(headers))
+	for n, vs := range headers {
+		for _, v := range vs {
+			for _, line := range strings.Split(v, "\n") {
+				nvs = append(nvs, HARNameValue{Name: n, Value: line})
+			}
+		}
+	}
+	sort.SliceStable(nvs, func(i, j int) bool {
+		return nvs[i].Name < nvs[j].Name
+	})
+
+	return nvs
+}
+
+// harHeaderValue returns the value of the header with a case
+// insensitive name lookup.
+func harHeaderValue(headers map[string][]string, name string) string {
+	for n, vs := range headers {
+		if strings.EqualFold(n, name) {
+			return strings.Join(vs, ",")
+		}
+	}
+	return ""
+}
+
+func harQueryString(req *Request) []HARNameValue {
+	nvs := []HARNameValue{}
+	for n, vs := range req.url.Query() {
+		for _, v := range vs {
+			nvs = append(nvs, HARNameValue{Name: n, Value: v})
+		}
+	}
+	sort.SliceStable(nvs, func(i, j int) bool {
+		return nvs[i].Name < nvs[j].Name
+	})
+
+	return nvs
+}
+
+func harRequestCookies(headers map[string][]string) []HARCookie {
+	hr := http.Request{Header: http.Header{}}
+	if c := harHeaderValue(headers, "cookie"); c != "" {
+		hr.Header.Set("Cookie", c)
+	}
+	cookies := []HARCookie{}
+	for _, c := range hr.Cookies() {
+		cookies = append(cookies, HARCookie{Name: c.Name, Value: c.Value})
+	}
+
+	return cookies
+}
+
+func harResponseCookies(headers map[string][]string) []HARCookie {
+	hr := http.Response{Header: http.Header{}}
+	if c := harHeaderValue(headers, "set-cookie"); c != "" {
+		for _, line := range strings.Split(c, "\n") {
+			hr.Header.Add("Set-Cookie", line)
+		}
+	}
+	cookies := []HARCookie{}
+	for _, c := range hr.Cookies() {
+		hc := HARCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			expires := c.Expires
+			hc.Expires = &expires
+		}
+		switch c.SameSite { //nolint:exhaustive
+		case http.SameSiteLaxMode:
+			hc.SameSite = "Lax"
+		case http.SameSiteStrictMode:
+			hc.SameSite = "Strict"
+		case http.SameSiteNoneMode:
+			hc.SameSite = "None"
+		}
+		cookies = append(cookies, hc)
+	}
+
+	return cookies
+}
+
+// harAttachmentName returns the file name of an attached response body,
+// which is the SHA1 of the body with an extension based on the MIME type.
+func harAttachmentName(body []byte, mimeType string) string {
+	sum := sha1.Sum(body) //nolint:gosec
+	name := hex.EncodeToString(sum[:])
+
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return name + ".dat"
+	}
+	exts, err := mime.ExtensionsByType(mediaType)
+	if err != nil || len(exts) == 0 {
+		return name + ".dat"
+	}
+
+	return name + exts[0]
+}
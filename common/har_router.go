@@ -0,0 +1,142 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/xk6-browser/log"
+)
+
+// HARNotFoundPolicy controls what happens to the requests that are not in
+// the HAR file that the requests are served from.
+type HARNotFoundPolicy string
+
+const (
+	// HARNotFoundAbort aborts the requests that are not in the HAR.
+	HARNotFoundAbort HARNotFoundPolicy = "abort"
+
+	// HARNotFoundFallback sends the requests that are not in the HAR
+	// to the network.
+	HARNotFoundFallback HARNotFoundPolicy = "fallback"
+)
+
+// harRouter serves the requests from the entries of a HAR file.
+type harRouter struct {
+	logger   *log.Logger
+	dir      string
+	entries  []*HAREntry
+	notFound HARNotFoundPolicy
+}
+
+// newHARRouter reads the HAR file at path and returns a router that serves
+// the requests from its entries.
+func newHARRouter(logger *log.Logger, path string, notFound HARNotFoundPolicy) (*harRouter, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("reading HAR file: %w", err)
+	}
+	var har HAR
+	if err := json.Unmarshal(b, &har); err != nil {
+		return nil, fmt.Errorf("parsing HAR file %q: %w", path, err)
+	}
+
+	return &harRouter{
+		logger:   logger,
+		dir:      filepath.Dir(path),
+		entries:  har.Log.Entries,
+		notFound: notFound,
+	}, nil
+}
+
+// handle fulfills the route with the response of the matching HAR entry.
+// The route is either aborted or continued if there is no matching entry.
+func (r *harRouter) handle(route *Route) {
+	req := route.Request()
+
+	var err error
+	if entry := r.findEntry(req.Method(), req.URL(), req.PostData()); entry != nil {
+		err = r.fulfill(route, entry)
+	} else if r.notFound == HARNotFoundFallback {
+		err = route.Continue(NewRouteContinueOptions())
+	} else {
+		err = route.Abort("failed")
+	}
+	if err != nil {
+		r.logger.Errorf("harRouter:handle", "url:%s method:%s err:%v", req.URL(), req.Method(), err)
+	}
+}
+
+// findEntry returns the first entry that matches the method, the URL and
+// the post data of a request, or nil if none matches.
+func (r *harRouter) findEntry(method, url, postData string) *HAREntry {
+	for _, e := range r.entries {
+		if !strings.EqualFold(e.Request.Method, method) || e.Request.URL != url {
+			continue
+		}
+		var entryPostData string
+		if e.Request.PostData != nil {
+			entryPostData = e.Request.PostData.Text
+		}
+		if entryPostData == postData {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func (r *harRouter) fulfill(route *Route, entry *HAREntry) error {
+	body, err := r.body(entry.Response.Content)
+	if err != nil {
+		return err
+	}
+
+	opts := NewRouteFulfillOptions()
+	opts.Status = entry.Response.Status
+	opts.Body = body
+	opts.Headers = make(map[string]string)
+	for _, h := range entry.Response.Headers {
+		n := strings.ToLower(h.Name)
+		switch {
+		// The body is already decoded and its length is recalculated.
+		case n == "content-encoding", n == "content-length", n == "transfer-encoding":
+			continue
+		// HTTP/2 pseudo headers are not valid response headers.
+		case strings.HasPrefix(n, ":"):
+			continue
+		}
+		// CDP expects the values of repeated headers to be separated by new lines.
+		if v, ok := opts.Headers[n]; ok {
+			opts.Headers[n] = v + "\n" + h.Value
+		} else {
+			opts.Headers[n] = h.Value
+		}
+	}
+
+	return route.Fulfill(opts)
+}
+
+// body returns the response body of an entry, which is either embedded
+// into the HAR file or attached in a separate file next to it.
+func (r *harRouter) body(c HARContent) ([]byte, error) {
+	if c.File != "" {
+		b, err := os.ReadFile(filepath.Join(r.dir, filepath.Base(c.File)))
+		if err != nil {
+			return nil, fmt.Errorf("reading HAR attachment: %w", err)
+		}
+		return b, nil
+	}
+	if c.Encoding == "base64" {
+		b, err := base64.StdEncoding.DecodeString(c.Text)
+		if err != nil {
+			return nil, fmt.Errorf("decoding HAR content: %w", err)
+		}
+		return b, nil
+	}
+
+	return []byte(c.Text), nil
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHARRouter(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	har := HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Entries: []*HAREntry{
+				{
+					Request:  HARRequest{Method: "GET", URL: "https://test.k6.io/"},
+					Response: HARResponse{Status: 200, Content: HARContent{Text: "index"}},
+				},
+				{
+					Request: HARRequest{
+						Method:   "POST",
+						URL:      "https://test.k6.io/login",
+						PostData: &HARPostData{Text: "user=a"},
+					},
+					Response: HARResponse{Status: 200, Content: HARContent{Text: "aGVsbG8gYQ==", Encoding: "base64"}},
+				},
+				{
+					Request: HARRequest{
+						Method:   "POST",
+						URL:      "https://test.k6.io/login",
+						PostData: &HARPostData{Text: "user=b"},
+					},
+					Response: HARResponse{Status: 200, Content: HARContent{File: "b.txt"}},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(har)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "test.har")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("hello b"), 0o600))
+
+	r, err := newHARRouter(nil, path, HARNotFoundAbort)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name, method, url, postData string
+		wantBody                    string
+		wantNotFound                bool
+	}{
+		{name: "embedded", method: "GET", url: "https://test.k6.io/", wantBody: "index"},
+		{name: "method_case", method: "get", url: "https://test.k6.io/", wantBody: "index"},
+		{name: "base64", method: "POST", url: "https://test.k6.io/login", postData: "user=a", wantBody: "hello a"},
+		{name: "attached", method: "POST", url: "https://test.k6.io/login", postData: "user=b", wantBody: "hello b"},
+		{name: "post_data_mismatch", method: "POST", url: "https://test.k6.io/login", postData: "user=c", wantNotFound: true},
+		{name: "method_mismatch", method: "POST", url: "https://test.k6.io/", wantNotFound: true},
+		{name: "url_mismatch", method: "GET", url: "https://test.k6.io/news", wantNotFound: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entry := r.findEntry(tt.method, tt.url, tt.postData)
+			if tt.wantNotFound {
+				assert.Nil(t, entry)
+				return
+			}
+			require.NotNil(t, entry)
+			body, err := r.body(entry.Response.Content)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body))
+		})
+	}
+}
+
+func TestHARRouterMissingFile(t *testing.T) {
+	t.Parallel()
+
+	_, err := newHARRouter(nil, filepath.Join(t.TempDir(), "missing.har"), HARNotFoundAbort)
+	assert.ErrorContains(t, err, "reading HAR file")
+}
@@ -0,0 +1,175 @@
+package common
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHARTimings(t *testing.T) {
+	t.Parallel()
+
+	t.Run("new_connection", func(t *testing.T) {
+		t.Parallel()
+
+		timing := &network.ResourceTiming{
+			DNSStart:          1,
+			DNSEnd:            3,
+			ConnectStart:      3,
+			ConnectEnd:        10,
+			SslStart:          5,
+			SslEnd:            10,
+			SendStart:         11,
+			SendEnd:           12,
+			ReceiveHeadersEnd: 20,
+		}
+		got := newHARTimings(timing, 25)
+		assert.Equal(t, HARTimings{
+			Blocked: 1,
+			DNS:     2,
+			Connect: 7,
+			SSL:     5,
+			Send:    1,
+			Wait:    8,
+			Receive: 5,
+		}, got)
+		assert.Equal(t, float64(24), got.total())
+	})
+
+	t.Run("reused_connection", func(t *testing.T) {
+		t.Parallel()
+
+		timing := &network.ResourceTiming{
+			DNSStart:          -1,
+			DNSEnd:            -1,
+			ConnectStart:      -1,
+			ConnectEnd:        -1,
+			SslStart:          -1,
+			SslEnd:            -1,
+			SendStart:         2,
+			SendEnd:           3,
+			ReceiveHeadersEnd: 10,
+		}
+		got := newHARTimings(timing, 0)
+		assert.Equal(t, HARTimings{
+			Blocked: 2,
+			DNS:     -1,
+			Connect: -1,
+			SSL:     -1,
+			Send:    1,
+			Wait:    7,
+			Receive: 0,
+		}, got)
+		assert.Equal(t, float64(10), got.total())
+	})
+
+	t.Run("no_timing", func(t *testing.T) {
+		t.Parallel()
+
+		got := newHARTimings(nil, 1000)
+		assert.Equal(t, HARTimings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			SSL:     -1,
+			Receive: 1000,
+		}, got)
+	})
+}
+
+func TestNewHAREntry(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("https://test.k6.io/api?b=2&a=1")
+	require.NoError(t, err)
+
+	wallTime := time.Unix(1700000000, 0)
+	newRequest := func() *Request {
+		return &Request{
+			url:          u,
+			method:       "POST",
+			postData:     `{"k":"v"}`,
+			resourceType: "Fetch",
+			wallTime:     wallTime,
+			headers: map[string][]string{
+				"Content-Type": {"application/json"},
+				"Cookie":       {"c1=v1; c2=v2"},
+			},
+		}
+	}
+
+	t.Run("failed", func(t *testing.T) {
+		t.Parallel()
+
+		req := newRequest()
+		req.errorText = "net::ERR_FAILED"
+		entry := newHAREntry(req, nil)
+
+		assert.Equal(t, wallTime, entry.StartedDateTime)
+		assert.Equal(t, "POST", entry.Request.Method)
+		assert.Equal(t, "https://test.k6.io/api?b=2&a=1", entry.Request.URL)
+		assert.Equal(t, []HARNameValue{{"a", "1"}, {"b", "2"}}, entry.Request.QueryString)
+		assert.Equal(t, []HARCookie{{Name: "c1", Value: "v1"}, {Name: "c2", Value: "v2"}}, entry.Request.Cookies)
+		require.NotNil(t, entry.Request.PostData)
+		assert.Equal(t, "application/json", entry.Request.PostData.MimeType)
+		assert.Equal(t, `{"k":"v"}`, entry.Request.PostData.Text)
+		assert.Equal(t, int64(0), entry.Response.Status)
+		assert.Equal(t, "net::ERR_FAILED", entry.Response.FailureText)
+		assert.Equal(t, "fetch", entry.ResourceType)
+	})
+
+	t.Run("response", func(t *testing.T) {
+		t.Parallel()
+
+		req := newRequest()
+		resp := &Response{
+			request:         req,
+			protocol:        "h2",
+			status:          201,
+			statusText:      "Created",
+			remoteAddress:   &RemoteAddress{IPAddress: "[::1]", Port: 443},
+			securityDetails: &SecurityDetails{Protocol: "TLS 1.3"},
+			headers: map[string][]string{
+				"content-type": {"application/json; charset=utf-8"},
+				"set-cookie":   {"s1=v1; Path=/; HttpOnly\ns2=v2; SameSite=Strict"},
+			},
+			timing: &network.ResourceTiming{
+				DNSStart:     -1,
+				ConnectStart: -1,
+				SslStart:     -1,
+				SendStart:    1,
+				SendEnd:      2,
+			},
+		}
+		entry := newHAREntry(req, resp)
+
+		assert.Equal(t, int64(201), entry.Response.Status)
+		assert.Equal(t, "Created", entry.Response.StatusText)
+		assert.Equal(t, "HTTP/2.0", entry.Request.HTTPVersion)
+		assert.Equal(t, "HTTP/2.0", entry.Response.HTTPVersion)
+		assert.Equal(t, "application/json; charset=utf-8", entry.Response.Content.MimeType)
+		assert.Equal(t, []HARCookie{
+			{Name: "s1", Value: "v1", Path: "/", HTTPOnly: true},
+			{Name: "s2", Value: "v2", SameSite: "Strict"},
+		}, entry.Response.Cookies)
+		assert.Len(t, entry.Response.Headers, 3)
+		assert.Equal(t, "::1", entry.ServerIPAddress)
+		assert.Equal(t, int64(443), entry.ServerPort)
+		assert.Equal(t, "TLS 1.3", entry.SecurityDetails.Protocol)
+		assert.Equal(t, float64(2), entry.Time)
+	})
+}
+
+func TestHARAttachmentName(t *testing.T) {
+	t.Parallel()
+
+	body := []byte("hello")
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d.json",
+		harAttachmentName(body, "application/json"))
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d.dat",
+		harAttachmentName(body, ""))
+}
@@ -0,0 +1,217 @@
+package common
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// The image comparison is a port of the pixelmatch library:
+// https://github.com/mapbox/pixelmatch
+
+// maxYIQDelta is the maximum possible value of the YIQ color difference
+// of two pixels.
+const maxYIQDelta = 35215
+
+// imageDiff is the result of comparing two images of the same size.
+type imageDiff struct {
+	// Pixels is the number of the different pixels, which doesn't include
+	// the anti-aliased pixels if they are ignored.
+	Pixels int
+	// Image highlights the different pixels with red, and the ignored
+	// anti-aliased pixels with yellow, over a faded grayscale copy of the
+	// first image.
+	Image *image.NRGBA
+}
+
+// diffImages compares two images of the same size pixel by pixel.
+//
+// The threshold is the maximum color difference of two pixels between 0
+// and 1 that is considered the same color. The anti-aliased pixels are
+// counted as different only if includeAA is true.
+func diffImages(img1, img2 image.Image, threshold float64, includeAA bool) *imageDiff {
+	a, b := toNRGBA(img1), toNRGBA(img2)
+	bounds := a.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	var (
+		diff     = &imageDiff{Image: image.NewNRGBA(image.Rect(0, 0, w, h))}
+		maxDelta = maxYIQDelta * threshold * threshold
+	)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			delta := colorDelta(a, b, x, y, x, y, false)
+			switch {
+			case abs64(delta) <= maxDelta:
+				diff.Image.SetNRGBA(x, y, fadedGray(a, x, y))
+			case !includeAA && (antialiased(a, b, x, y) || antialiased(b, a, x, y)):
+				diff.Image.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 0, A: 255})
+			default:
+				diff.Image.SetNRGBA(x, y, color.NRGBA{R: 255, G: 0, B: 0, A: 255})
+				diff.Pixels++
+			}
+		}
+	}
+
+	return diff
+}
+
+// toNRGBA returns the image as an NRGBA image whose bounds start at 0, 0.
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Bounds().Min == (image.Point{}) {
+		return n
+	}
+	b := img.Bounds()
+	n := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(n, n.Bounds(), img, b.Min, draw.Src)
+
+	return n
+}
+
+// colorDelta returns the squared YIQ difference of two pixels, which is
+// negative if the first pixel is brighter. It only returns the difference
+// of the brightness if yOnly is true.
+func colorDelta(img1, img2 *image.NRGBA, x1, y1, x2, y2 int, yOnly bool) float64 {
+	c1, c2 := img1.NRGBAAt(x1, y1), img2.NRGBAAt(x2, y2)
+	if c1 == c2 {
+		return 0
+	}
+
+	r1, g1, b1 := blendWhite(c1)
+	r2, g2, b2 := blendWhite(c2)
+
+	y1v, y2v := rgb2y(r1, g1, b1), rgb2y(r2, g2, b2)
+	y := y1v - y2v
+	if yOnly {
+		return y
+	}
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+
+	delta := 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+	if y1v > y2v {
+		return -delta
+	}
+
+	return delta
+}
+
+// antialiased reports whether the pixel of img1 is likely a part of an
+// anti-aliased edge, which is the case if it's between the darkest and
+// the brightest of its neighbors, and these have many equal siblings in
+// both images.
+func antialiased(img1, img2 *image.NRGBA, x1, y1 int) bool {
+	var (
+		w, h           = img1.Bounds().Dx(), img1.Bounds().Dy()
+		x0, y0         = maxInt(x1-1, 0), maxInt(y1-1, 0)
+		x2, y2         = minInt(x1+1, w-1), minInt(y1+1, h-1)
+		zeroes         int
+		minD, maxD     float64
+		minX, minY     int
+		maxX, maxY     int
+		isOnImageEdge  = x1 == x0 || x1 == x2 || y1 == y0 || y1 == y2
+		maxEqualPixels = 2
+	)
+	if isOnImageEdge {
+		zeroes = 1
+	}
+	for x := x0; x <= x2; x++ {
+		for y := y0; y <= y2; y++ {
+			if x == x1 && y == y1 {
+				continue
+			}
+			delta := colorDelta(img1, img1, x1, y1, x, y, true)
+			switch {
+			case delta == 0:
+				zeroes++
+				if zeroes > maxEqualPixels {
+					return false
+				}
+			case delta < minD:
+				minD, minX, minY = delta, x, y
+			case delta > maxD:
+				maxD, maxX, maxY = delta, x, y
+			}
+		}
+	}
+	if minD == 0 || maxD == 0 {
+		return false
+	}
+
+	return (hasManySiblings(img1, minX, minY) && hasManySiblings(img2, minX, minY)) ||
+		(hasManySiblings(img1, maxX, maxY) && hasManySiblings(img2, maxX, maxY))
+}
+
+// hasManySiblings reports whether the pixel has more than two neighbors
+// of the same color.
+func hasManySiblings(img *image.NRGBA, x1, y1 int) bool {
+	var (
+		w, h   = img.Bounds().Dx(), img.Bounds().Dy()
+		x0, y0 = maxInt(x1-1, 0), maxInt(y1-1, 0)
+		x2, y2 = minInt(x1+1, w-1), minInt(y1+1, h-1)
+		c      = img.NRGBAAt(x1, y1)
+		zeroes int
+	)
+	if x1 == x0 || x1 == x2 || y1 == y0 || y1 == y2 {
+		zeroes = 1
+	}
+	for x := x0; x <= x2; x++ {
+		for y := y0; y <= y2; y++ {
+			if x == x1 && y == y1 {
+				continue
+			}
+			if img.NRGBAAt(x, y) == c {
+				zeroes++
+			}
+			if zeroes > 2 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// fadedGray returns the pixel in grayscale, blended with white.
+func fadedGray(img *image.NRGBA, x, y int) color.NRGBA {
+	const alpha = 0.1
+
+	c := img.NRGBAAt(x, y)
+	r, g, b := blendWhite(c)
+	v := uint8(255 + (rgb2y(r, g, b)-255)*alpha)
+
+	return color.NRGBA{R: v, G: v, B: v, A: 255}
+}
+
+// blendWhite blends the pixel with a white background.
+func blendWhite(c color.NRGBA) (r, g, b float64) {
+	a := float64(c.A) / 255
+	blend := func(v uint8) float64 { return 255 + (float64(v)-255)*a }
+
+	return blend(c.R), blend(c.G), blend(c.B)
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+func abs64(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
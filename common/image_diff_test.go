@@ -0,0 +1,81 @@
+package common
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFilledImage(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestDiffImages(t *testing.T) {
+	t.Parallel()
+
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	black := color.NRGBA{A: 255}
+
+	t.Run("identical", func(t *testing.T) {
+		t.Parallel()
+
+		a, b := newFilledImage(4, 4, white), newFilledImage(4, 4, white)
+		diff := diffImages(a, b, 0.1, false)
+		assert.Equal(t, 0, diff.Pixels)
+		assert.Equal(t, image.Rect(0, 0, 4, 4), diff.Image.Bounds())
+	})
+
+	t.Run("different", func(t *testing.T) {
+		t.Parallel()
+
+		a, b := newFilledImage(4, 4, white), newFilledImage(4, 4, white)
+		b.SetNRGBA(1, 1, black)
+		b.SetNRGBA(2, 2, black)
+
+		diff := diffImages(a, b, 0.1, false)
+		assert.Equal(t, 2, diff.Pixels)
+		assert.Equal(t, color.NRGBA{R: 255, A: 255}, diff.Image.NRGBAAt(1, 1))
+		assert.NotEqual(t, color.NRGBA{R: 255, A: 255}, diff.Image.NRGBAAt(0, 0))
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		t.Parallel()
+
+		a, b := newFilledImage(4, 4, white), newFilledImage(4, 4, white)
+		b.SetNRGBA(1, 1, color.NRGBA{R: 250, G: 250, B: 250, A: 255})
+
+		assert.Equal(t, 0, diffImages(a, b, 0.1, false).Pixels)
+		assert.Equal(t, 1, diffImages(a, b, 0, false).Pixels)
+	})
+
+	t.Run("antialiased", func(t *testing.T) {
+		t.Parallel()
+
+		// A black and white edge whose pixel in the middle is smoothed
+		// to gray in the second image.
+		a := newFilledImage(6, 6, white)
+		for y := 0; y < 6; y++ {
+			for x := 0; x < 3; x++ {
+				a.SetNRGBA(x, y, black)
+			}
+		}
+		b := image.NewNRGBA(a.Bounds())
+		copy(b.Pix, a.Pix)
+		b.SetNRGBA(2, 3, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+
+		diff := diffImages(a, b, 0.1, false)
+		assert.Equal(t, 0, diff.Pixels)
+		assert.Equal(t, color.NRGBA{R: 255, G: 255, A: 255}, diff.Image.NRGBAAt(2, 3))
+
+		assert.Equal(t, 1, diffImages(a, b, 0.1, true).Pixels)
+	})
+}
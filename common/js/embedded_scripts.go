@@ -13,7 +13,22 @@ var WebVitalIIFEScript string
 
 // WebVitalInitScript uses WebVitalIIFEScript
 // and applies it to the current website that
-// this init script is used against.
+// this init script is used against. It also
+// reports the long tasks and Total Blocking
+// Time, which are not part of web-vitals.
 //
 //go:embed web_vital_init.js
 var WebVitalInitScript string
+
+// UserMetricsScript sends the user timings of the page
+// and the metrics that it emits with window.k6 to the
+// browser module.
+//
+//go:embed user_metrics.js
+var UserMetricsScript string
+
+// TraceSnapshotScript serializes the DOM of the page into
+// a snapshot of a Playwright compatible trace archive.
+//
+//go:embed trace_snapshot.js
+var TraceSnapshotScript string
@@ -0,0 +1,74 @@
+(() => {
+  // The nodes are serialized into the node snapshots of the Playwright
+  // trace viewer: a text node is a string, and an element is an array of
+  // its name, its attributes and its serialized children. The state that
+  // is not in the attributes is kept in the viewer's special attributes.
+  const skipped = new Set(["SCRIPT", "NOSCRIPT"]);
+
+  function serializeElement(el) {
+    const attrs = {};
+    for (const attr of Array.from(el.attributes)) {
+      // The event handlers don't run in the viewer.
+      if (attr.name.startsWith("on")) {
+        continue;
+      }
+      attrs[attr.name] = attr.value;
+    }
+    if (el.nodeName === "INPUT" || el.nodeName === "TEXTAREA") {
+      attrs["__playwright_value_"] = el.value;
+    }
+    if (el.nodeName === "INPUT" && el.checked) {
+      attrs["__playwright_checked_"] = "true";
+    }
+    if (el.nodeName === "OPTION" && el.selected) {
+      attrs["__playwright_selected_"] = "true";
+    }
+    if (el.scrollTop) {
+      attrs["__playwright_scroll_top_"] = String(el.scrollTop);
+    }
+    if (el.scrollLeft) {
+      attrs["__playwright_scroll_left_"] = String(el.scrollLeft);
+    }
+
+    const snapshot = [el.nodeName, attrs];
+    if (el.nodeName === "STYLE" && el.sheet) {
+      // The rules might be inserted with CSSOM, so they are not in the
+      // text of the style element.
+      let text;
+      try {
+        text = Array.from(el.sheet.cssRules).map((r) => r.cssText).join("\n");
+      } catch (e) {
+        text = el.textContent;
+      }
+      snapshot.push(text);
+      return snapshot;
+    }
+    if (el.shadowRoot) {
+      snapshot.push(["TEMPLATE", { "__playwright_shadow_root_": "" },
+        ...serializeChildren(el.shadowRoot)]);
+    }
+    snapshot.push(...serializeChildren(el));
+
+    return snapshot;
+  }
+
+  function serializeChildren(node) {
+    const children = [];
+    for (const child of Array.from(node.childNodes)) {
+      if (child.nodeType === Node.TEXT_NODE) {
+        children.push(child.nodeValue);
+      } else if (child.nodeType === Node.ELEMENT_NODE && !skipped.has(child.nodeName)) {
+        children.push(serializeElement(child));
+      }
+    }
+
+    return children;
+  }
+
+  return {
+    doctype: document.doctype ? document.doctype.name : "",
+    html: serializeElement(document.documentElement),
+    viewport: { width: window.innerWidth, height: window.innerHeight },
+    url: window.location.href,
+  };
+})()
@@ -0,0 +1,45 @@
+(function () {
+  function send(metric) {
+    metric.url = window.location.href;
+    window.k6browserSendUserMetric(JSON.stringify(metric));
+  }
+
+  // The user timings are the performance marks and measures of the page.
+  // The marks are sent with their start time and the measures with their
+  // duration.
+  function observe() {
+    try {
+      const po = new PerformanceObserver((list) => {
+        for (const entry of list.getEntries()) {
+          send({
+            type: entry.entryType,
+            name: entry.name,
+            value: entry.entryType === "mark" ? entry.startTime : entry.duration,
+          });
+        }
+      });
+      po.observe({ entryTypes: ["mark", "measure"] });
+    } catch (e) {}
+  }
+
+  function metric(type) {
+    return (name, value, tags) => {
+      const t = {};
+      for (const [k, v] of Object.entries(tags || {})) {
+        t[k] = String(v);
+      }
+      send({ type, name: String(name), value: Number(value), tags: t });
+    };
+  }
+
+  observe();
+
+  // window.k6 lets the page emit its own metrics.
+  Object.defineProperty(window, "k6", {
+    value: Object.freeze({
+      counter: (name, value = 1, tags) => metric("counter")(name, value, tags),
+      trend: metric("trend"),
+    }),
+    configurable: true,
+  });
+})();
@@ -7,6 +7,8 @@ function print(metric) {
     delta: metric.delta,
     numEntries: metric.entries.length,
     navigationType: metric.navigationType,
+    // The long tasks are either a longtask or a long-animation-frame.
+    entryType: metric.entryType,
     url: window.location.href,
     // To be able to associate a Web Vital measurement to the PageNavigation
     // span, we need to collect the span ID that was previously set in the
@@ -18,6 +20,105 @@ function print(metric) {
   window.k6browserSendWebVitalMetric(JSON.stringify(m))
 }
 
+// The long tasks and Total Blocking Time (TBT) are not part of web-vitals.
+// They measure the main thread blocking during the page load, which INP and
+// FID don't capture as they only measure the latency of the user inputs.
+// The functions are scoped to loadLongTasks, not to clash with the globals
+// of the page.
+function loadLongTasks(callback) {
+  // A task blocks the main thread for its duration over this threshold.
+  const blockingThreshold = 50;
+  const TBTThresholds = [200, 600];
+
+  function supported(type) {
+    try {
+      return PerformanceObserver.supportedEntryTypes.includes(type);
+    } catch (e) {
+      return false;
+    }
+  }
+
+  function observe(type, callback) {
+    try {
+      if (supported(type)) {
+        const po = new PerformanceObserver((list) => callback(list.getEntries()));
+        po.observe({ type: type, buffered: true });
+      }
+    } catch (e) {}
+  }
+
+  function newMetric(name, value, entries) {
+    const nav = performance.getEntriesByType('navigation')[0];
+    return {
+      id: `k6-${Date.now()}-${Math.floor(8999999999999 * Math.random()) + 1e12}`,
+      name: name,
+      value: value,
+      delta: value,
+      entries: entries,
+      navigationType: nav ? nav.type.replace(/_/g, '-') : 'navigate',
+    }
+  }
+
+  function onHidden(callback) {
+    let reported = false;
+    const report = () => {
+      if (!reported) {
+        reported = true;
+        callback();
+      }
+    };
+    addEventListener('visibilitychange', () => {
+      if (document.visibilityState === 'hidden') {
+        report();
+      }
+    }, true);
+    addEventListener('pagehide', report, true);
+  }
+
+  // onLongTask reports each long animation frame that blocks the main
+  // thread. A long animation frame contains the long tasks that run in
+  // it, so the long tasks are only reported where the long animation
+  // frames are not supported, not to count the blocking twice.
+  function onLongTask(callback) {
+    const type = supported('long-animation-frame') ? 'long-animation-frame' : 'longtask';
+    observe(type, (entries) => {
+      entries.forEach((entry) => {
+        const m = newMetric('LongTask', entry.duration, [entry]);
+        m.entryType = entry.entryType;
+        callback(m);
+      });
+    });
+  }
+
+  // onTBT reports the sum of the blocking time of the long tasks after the
+  // first contentful paint once per navigation, when the page is hidden.
+  function onTBT(callback) {
+    let fcp = -1;
+    const tasks = [];
+    observe('paint', (entries) => {
+      entries.forEach((entry) => {
+        if (entry.name === 'first-contentful-paint') {
+          fcp = entry.startTime;
+        }
+      });
+    });
+    observe('longtask', (entries) => tasks.push(...entries));
+    onHidden(() => {
+      if (fcp < 0) {
+        return;
+      }
+      const entries = tasks.filter((task) => task.startTime >= fcp && task.duration > blockingThreshold);
+      const tbt = entries.reduce((sum, task) => sum + task.duration - blockingThreshold, 0);
+      const m = newMetric('TBT', tbt, entries);
+      m.rating = tbt > TBTThresholds[1] ? 'poor' : tbt > TBTThresholds[0] ? 'needs-improvement' : 'good';
+      callback(m);
+    });
+  }
+
+  onLongTask(callback);
+  onTBT(callback);
+}
+
 function load() {
   webVitals.onCLS(print);
   webVitals.onFID(print);
@@ -26,6 +127,8 @@ function load() {
   webVitals.onFCP(print);
   webVitals.onINP(print);
   webVitals.onTTFB(print);
+
+  loadLongTasks(print);
 }
 
 load();
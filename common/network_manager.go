@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +27,8 @@ import (
 	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
 	"github.com/grafana/sobek"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Credentials holds HTTP authentication credentials.
@@ -286,6 +289,12 @@ func (m *NetworkManager) handleRequestRedirect(req *Request, redirectResponse *n
 	req.redirectChain = append(req.redirectChain, req)
 
 	m.emitResponseMetrics(resp, req)
+	m.recordHAR(req)
+	if timestamp != nil {
+		m.traceRequest(req, timestamp.Time().Add(req.offset))
+	} else {
+		m.traceRequest(req, time.Now())
+	}
 	m.deleteRequestByID(req.requestID)
 
 	/*
@@ -296,6 +305,95 @@ func (m *NetworkManager) handleRequestRedirect(req *Request, redirectResponse *n
 	m.emit(cdproto.EventNetworkLoadingFinished, req)
 }
 
+// recordHAR records the request into the HARs of the browser context,
+// if the browser context records any.
+func (m *NetworkManager) recordHAR(req *Request) {
+	if m.frameManager == nil || m.frameManager.page == nil {
+		return
+	}
+	p := m.frameManager.page
+	if p.browserCtx == nil {
+		return
+	}
+	p.browserCtx.recordHAR(p, req)
+}
+
+// traceRequest records the request as a span of the page navigation that
+// covers the request from its start until it ended at the given time.
+func (m *NetworkManager) traceRequest(req *Request, end time.Time) {
+	if m.frameManager == nil || m.frameManager.page == nil || isInternalURL(req.url) {
+		return
+	}
+	req.responseMu.RLock()
+	resp := req.response
+	req.responseMu.RUnlock()
+
+	_, span := TraceRequest(
+		m.ctx, m.frameManager.page.targetID.String(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(req.wallTime),
+		trace.WithAttributes(requestSpanAttributes(req, resp, end)...),
+	)
+	if req.errorText != "" {
+		spanRecordError(span, errors.New(req.errorText))
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// requestSpanAttributes returns the attributes of the span of a request.
+// The timings are the durations in milliseconds of the request phases
+// that apply to the request.
+func requestSpanAttributes(req *Request, resp *Response, end time.Time) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.url", req.URL()),
+		attribute.String("http.method", req.method),
+		attribute.String("http.resource_type", req.resourceType),
+		attribute.Int64("http.request.size", req.Size().Total()),
+	}
+
+	cache := "none"
+	if req.fromMemoryCache {
+		cache = "memory"
+	}
+	var timing *network.ResourceTiming
+	if resp != nil {
+		attrs = append(attrs,
+			attribute.Int64("http.status_code", resp.status),
+			attribute.Int64("http.response.size", resp.Size().Total()),
+		)
+		switch {
+		case resp.fromDiskCache:
+			cache = "disk"
+		case resp.fromPrefetchCache:
+			cache = "prefetch"
+		case resp.fromServiceWorker:
+			cache = "service_worker"
+		}
+		timing = resp.timing
+	}
+	attrs = append(attrs, attribute.String("http.cache", cache))
+
+	timings := newHARTimings(timing, float64(end.Sub(req.wallTime))/float64(time.Millisecond))
+	for _, t := range []struct {
+		phase string
+		d     float64
+	}{
+		{"blocked", timings.Blocked},
+		{"dns", timings.DNS},
+		{"connect", timings.Connect},
+		{"ssl", timings.SSL},
+		{"send", timings.Send},
+		{"wait", timings.Wait},
+		{"receive", timings.Receive},
+	} {
+		if t.d >= 0 {
+			attrs = append(attrs, attribute.Float64("http.timing."+t.phase, t.d))
+		}
+	}
+
+	return attrs
+}
+
 func (m *NetworkManager) initDomains() error {
 	actions := []Action{network.Enable()}
 
@@ -373,6 +471,10 @@ func (m *NetworkManager) onLoadingFailed(event *network.EventLoadingFailed) {
 	req.responseEndTiming = float64(event.Timestamp.Time().Unix()-req.timestamp.Unix()) * 1000
 	m.deleteRequestByID(event.RequestID)
 	m.frameManager.requestFailed(req, event.Canceled)
+	if !isInternalURL(req.url) {
+		m.recordHAR(req)
+		m.traceRequest(req, event.Timestamp.Time().Add(req.offset))
+	}
 }
 
 func (m *NetworkManager) onLoadingFinished(event *network.EventLoadingFinished) {
@@ -390,10 +492,13 @@ func (m *NetworkManager) onLoadingFinished(event *network.EventLoadingFinished)
 	if isInternalURL(req.url) {
 		return
 	}
+	end := event.Timestamp.Time().Add(req.offset)
 	emitResponseMetrics := func() {
 		req.responseMu.RLock()
 		m.emitResponseMetrics(req.response, req)
 		req.responseMu.RUnlock()
+		m.recordHAR(req)
+		m.traceRequest(req, end)
 	}
 	if !req.allowInterception {
 		emitResponseMetrics()
@@ -523,7 +628,18 @@ func (m *NetworkManager) onRequestPaused(event *fetch.EventRequestPaused) { //no
 
 			return
 		}
+		tc := m.traceContextHeaders(event.Request.URL)
+		if m.routeRequest(event, tc) {
+			return
+		}
 		action := fetch.ContinueRequest(event.RequestID)
+		if len(tc) > 0 {
+			headers := make(map[string]string, len(event.Request.Headers))
+			for n, v := range event.Request.Headers {
+				headers[n] = fmt.Sprint(v)
+			}
+			action = action.WithHeaders(toHeaderEntries(mergeHeaders(headers, tc)))
+		}
 		if err := action.Do(cdp.WithExecutor(m.ctx, m.session)); err != nil {
 			// Avoid logging as error when context is canceled.
 			// Most probably this happens when trying to continue a site's background request
@@ -567,6 +683,49 @@ func (m *NetworkManager) onRequestPaused(event *fetch.EventRequestPaused) { //no
 	failErr = checkBlockedIPs(ip, state.Options.BlacklistIPs)
 }
 
+// traceContextHeaders returns the trace context headers of the active
+// span of the page if the browser context propagates the trace context
+// to the request URL. It returns nil if the headers of the request
+// should not be changed.
+func (m *NetworkManager) traceContextHeaders(requestURL string) map[string]string {
+	if m.frameManager == nil || m.frameManager.page == nil {
+		return nil
+	}
+	page := m.frameManager.page
+	if page.browserCtx == nil || page.browserCtx.opts == nil {
+		return nil
+	}
+	opts := page.browserCtx.opts.TraceContext
+	if opts == nil {
+		return nil
+	}
+	u, err := url.Parse(requestURL)
+	if err != nil || !opts.propagatesTo(u) {
+		return nil
+	}
+
+	return traceContextHeaders(m.ctx, page.targetID.String(), opts.baggage)
+}
+
+// mergeHeaders returns the headers with the overrides. The header names
+// are case-insensitive, so an override replaces a header of any case.
+func mergeHeaders(headers, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(headers)+len(overrides))
+	for n, v := range headers {
+		merged[n] = v
+	}
+	for n, v := range overrides {
+		for mn := range merged {
+			if strings.EqualFold(mn, n) {
+				delete(merged, mn)
+			}
+		}
+		merged[n] = v
+	}
+
+	return merged
+}
+
 func checkBlockedHosts(host string, blockedHosts *k6types.HostnameTrie) error {
 	if blockedHosts == nil {
 		return nil
@@ -588,6 +747,72 @@ func checkBlockedIPs(ip net.IP, blockedIPs []*k6lib.IPNet) error {
 	return nil
 }
 
+// routeRequest hands the paused request over to the matching route handler
+// of the page or its browser context. It returns false if there is no
+// matching route, in which case the request should be continued. The route
+// adds the traceContext headers to the request if it continues it.
+func (m *NetworkManager) routeRequest(event *fetch.EventRequestPaused, traceContext map[string]string) bool {
+	if m.frameManager == nil || m.frameManager.page == nil {
+		return false
+	}
+	page := m.frameManager.page
+	if !page.hasRoutes() {
+		return false
+	}
+	handler := page.routeHandler(event.Request.URL)
+	if handler == nil {
+		return false
+	}
+
+	req, ok := m.requestFromID(event.NetworkID)
+	if !ok {
+		var err error
+		if req, err = m.requestFromPausedEvent(event); err != nil {
+			m.logger.Errorf("NetworkManager:routeRequest", "creating request: %s", err)
+			return false
+		}
+	}
+	if req.getFrame() == nil {
+		m.logger.Debugf("NetworkManager:routeRequest",
+			"url:%s method:%s frame is nil, skipping route", event.Request.URL, event.Request.Method)
+		return false
+	}
+
+	route := newRoute(m.ctx, m.logger, m.session, event.RequestID, req)
+	route.traceContext = traceContext
+	route.continueByDefaultAfter(page.Timeout())
+	handler(route)
+
+	return true
+}
+
+// requestFromPausedEvent creates a request from a paused request that has
+// not been seen by the Network domain yet.
+func (m *NetworkManager) requestFromPausedEvent(event *fetch.EventRequestPaused) (*Request, error) {
+	var (
+		now      = time.Now()
+		ts       = cdp.MonotonicTime(now)
+		wallTime = cdp.TimeSinceEpoch(now)
+	)
+	frame, ok := m.frameManager.getFrameByID(event.FrameID)
+	if !ok {
+		frame = m.frameManager.MainFrame()
+	}
+
+	return NewRequest(m.ctx, NewRequestParams{
+		event: &network.EventRequestWillBeSent{
+			RequestID: event.NetworkID,
+			Request:   event.Request,
+			FrameID:   event.FrameID,
+			Type:      event.ResourceType,
+			Timestamp: &ts,
+			WallTime:  &wallTime,
+		},
+		frame:             frame,
+		allowInterception: m.userReqInterceptionEnabled,
+	})
+}
+
 func (m *NetworkManager) onAuthRequired(event *fetch.EventAuthRequired) {
 	var (
 		res = fetch.AuthChallengeResponseResponseDefault
@@ -655,7 +880,9 @@ func (m *NetworkManager) requestFromID(reqID network.RequestID) (*Request, bool)
 }
 
 func (m *NetworkManager) setRequestInterception(value bool) error {
-	m.userReqInterceptionEnabled = value
+	// Keep intercepting requests while there are credentials to
+	// authenticate with, since authentication relies on it.
+	m.userReqInterceptionEnabled = value || m.credentials != nil
 	return m.updateProtocolRequestInterception()
 }
 
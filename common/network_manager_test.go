@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/url"
 	"testing"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/mailru/easyjson"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const mockHostname = "host.test"
@@ -40,6 +42,21 @@ func (s *fakeSession) Execute(
 	return nil
 }
 
+// notifyingSession is like fakeSession, but it sends the calls made to it
+// to a channel, so that calls made from other goroutines can be awaited.
+type notifyingSession struct {
+	session
+	cdpCalls chan string
+}
+
+// Execute implements the cdp.Executor interface.
+func (s *notifyingSession) Execute(
+	ctx context.Context, method string, params easyjson.Marshaler, res easyjson.Unmarshaler,
+) error {
+	s.cdpCalls <- method
+	return nil
+}
+
 func newTestNetworkManager(t *testing.T, k6opts k6lib.Options) (*NetworkManager, *fakeSession) {
 	t.Helper()
 
@@ -387,3 +404,69 @@ func TestRequestForOnLoadingFinished(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestSpanAttributes(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("https://test.k6.io/style.css")
+	require.NoError(t, err)
+
+	wallTime := time.Unix(1700000000, 0)
+	req := &Request{
+		url:          u,
+		method:       "GET",
+		resourceType: "Stylesheet",
+		wallTime:     wallTime,
+		headers:      map[string][]string{},
+	}
+	attrs := func(resp *Response) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range requestSpanAttributes(req, resp, wallTime.Add(10*time.Millisecond)) {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+
+	got := attrs(nil)
+	assert.Equal(t, "https://test.k6.io/style.css", got["http.url"].AsString())
+	assert.Equal(t, "GET", got["http.method"].AsString())
+	assert.Equal(t, "Stylesheet", got["http.resource_type"].AsString())
+	assert.Equal(t, "none", got["http.cache"].AsString())
+	assert.Equal(t, 10.0, got["http.timing.receive"].AsFloat64())
+	assert.NotContains(t, got, "http.status_code")
+	assert.NotContains(t, got, "http.timing.dns")
+
+	got = attrs(&Response{
+		request:       req,
+		status:        200,
+		fromDiskCache: true,
+		headers:       map[string][]string{},
+		timing: &network.ResourceTiming{
+			DNSStart:          0,
+			DNSEnd:            1,
+			ConnectStart:      1,
+			ConnectEnd:        3,
+			SslStart:          2,
+			SslEnd:            3,
+			SendStart:         3,
+			SendEnd:           4,
+			ReceiveHeadersEnd: 8,
+		},
+	})
+	assert.Equal(t, int64(200), got["http.status_code"].AsInt64())
+	assert.Equal(t, "disk", got["http.cache"].AsString())
+	for phase, want := range map[string]float64{
+		"blocked": 0, "dns": 1, "connect": 2, "ssl": 1, "send": 1, "wait": 4, "receive": 2,
+	} {
+		assert.Equal(t, want, got[attribute.Key("http.timing."+phase)].AsFloat64(), phase)
+	}
+}
+
+func TestNetworkManagerTraceContextHeadersWithoutBrowserContext(t *testing.T) {
+	t.Parallel()
+
+	m, _ := newTestNetworkManager(t, k6lib.Options{})
+	m.frameManager = &FrameManager{page: &Page{}}
+
+	assert.Nil(t, m.traceContextHeaders("https://example.com/"))
+}
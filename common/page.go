@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -33,7 +34,8 @@ import (
 const BlankPage = "about:blank"
 
 const (
-	webVitalBinding = "k6browserSendWebVitalMetric"
+	webVitalBinding   = "k6browserSendWebVitalMetric"
+	userMetricBinding = "k6browserSendUserMetric"
 
 	eventPageConsoleAPICalled = "console"
 )
@@ -181,7 +183,37 @@ func NewEmulatedSize(viewport *Viewport, screen *Screen) *EmulatedSize {
 	}
 }
 
-type consoleEventHandlerFunc func(*ConsoleMessage)
+// PageOnEvent represents a generic page event.
+// Use one of the fields to get the specific event data.
+type PageOnEvent struct {
+	// ConsoleMessage is the message of the console event.
+	ConsoleMessage *ConsoleMessage
+
+	// Dialog is the dialog of the dialog event.
+	Dialog *Dialog
+
+	// Download is the download of the download event.
+	Download *Download
+
+	// FileChooser is the file chooser of the filechooser event.
+	FileChooser *FileChooser
+
+	// Page is the new page of the popup event.
+	Page *Page
+
+	// PageError is the uncaught exception of the pageerror event.
+	PageError *PageError
+
+	// Request is the request of the request, requestfinished and
+	// requestfailed events.
+	Request *Request
+
+	// Response is the response of the response event.
+	Response *Response
+}
+
+// PageOnHandler is called with the data of a page event.
+type PageOnHandler func(PageOnEvent)
 
 // ConsoleMessage represents a page console message.
 type ConsoleMessage struct {
@@ -205,9 +237,11 @@ type ConsoleMessage struct {
 type Page struct {
 	BaseEventEmitter
 
-	Keyboard    *Keyboard
-	Mouse       *Mouse
-	Touchscreen *Touchscreen
+	Keyboard      *Keyboard
+	Mouse         *Mouse
+	Accessibility *Accessibility
+	Coverage      *Coverage
+	Touchscreen   *Touchscreen
 
 	ctx context.Context
 
@@ -239,16 +273,31 @@ type Page struct {
 	backgroundPage bool
 
 	eventCh         chan Event
-	eventHandlers   map[string][]consoleEventHandlerFunc
+	eventHandlers   map[string][]PageOnHandler
 	eventHandlersMu sync.RWMutex
 
 	mainFrameSession *FrameSession
 	frameSessions    map[cdp.FrameID]*FrameSession
 	frameSessionsMu  sync.RWMutex
 	workers          map[target.SessionID]*Worker
-	routes           []any // TODO: Implement
+	routes           routes
+	bindings         bindings
+	video            *Video
 	vu               k6modules.VU
 
+	filmstripMu sync.Mutex
+	filmstrip   *filmstrip
+	filmstrips  int
+
+	performanceMu      sync.Mutex
+	performanceEnabled bool
+
+	// webVitalsReports are the forced web vitals reports that are
+	// waited for by their IDs.
+	webVitalsReportsMu sync.Mutex
+	webVitalsReports   map[string]chan struct{}
+	webVitalsReportsN  int
+
 	logger *log.Logger
 }
 
@@ -278,7 +327,7 @@ func NewPage(
 		Keyboard:         NewKeyboard(ctx, s),
 		jsEnabled:        true,
 		eventCh:          make(chan Event),
-		eventHandlers:    make(map[string][]consoleEventHandlerFunc),
+		eventHandlers:    make(map[string][]PageOnHandler),
 		frameSessions:    make(map[cdp.FrameID]*FrameSession),
 		workers:          make(map[target.SessionID]*Worker),
 		vu:               k6ext.GetVU(ctx),
@@ -293,6 +342,12 @@ func NewPage(
 	if bctx.opts.Viewport != nil {
 		p.emulatedSize = NewEmulatedSize(bctx.opts.Viewport, bctx.opts.Screen)
 	}
+	// The video recording starts with the main frame session.
+	if opts := bctx.opts.RecordVideo; opts != nil && !bp {
+		path := filepath.Join(opts.Dir, string(tid)+".gif")
+		p.video = newVideo(ctx, logger, path, videoSize(opts.Size, bctx.opts.Viewport), opts.Mode)
+		bctx.browser.addVideo(p.video)
+	}
 
 	var err error
 	p.frameManager = NewFrameManager(ctx, s, &p, p.timeoutSettings, p.logger)
@@ -308,6 +363,8 @@ func NewPage(
 	p.frameSessionsMu.Unlock()
 	p.Mouse = NewMouse(ctx, s, p.frameManager.MainFrame(), bctx.timeoutSettings, p.Keyboard)
 	p.Touchscreen = NewTouchscreen(ctx, s, p.Keyboard)
+	p.Accessibility = NewAccessibility(ctx, s, &p)
+	p.Coverage = NewCoverage(ctx, s, logger)
 
 	p.initEvents()
 
@@ -321,9 +378,26 @@ func NewPage(
 		return nil, fmt.Errorf("internal error while adding binding to page: %w", err)
 	}
 
+	add = runtime.AddBinding(exposedBinding)
+	if err := add.Do(cdp.WithExecutor(p.ctx, p.session)); err != nil {
+		return nil, fmt.Errorf("internal error while adding binding to page: %w", err)
+	}
+
+	if bctx.opts.UserMetrics != nil {
+		add = runtime.AddBinding(userMetricBinding)
+		if err := add.Do(cdp.WithExecutor(p.ctx, p.session)); err != nil {
+			return nil, fmt.Errorf("internal error while adding binding to page: %w", err)
+		}
+	}
+
 	if err := bctx.applyAllInitScripts(&p); err != nil {
 		return nil, fmt.Errorf("internal error while applying init scripts to page: %w", err)
 	}
+	for _, name := range bctx.bindings.names() {
+		if err := p.installBinding(name); err != nil {
+			return nil, fmt.Errorf("internal error while exposing binding %q to page: %w", name, err)
+		}
+	}
 
 	return &p, nil
 }
@@ -386,6 +460,20 @@ func (p *Page) didClose() {
 	}
 	p.closedMu.Unlock()
 
+	if v := p.video; v != nil {
+		v.stop()
+		// Encoding the video takes a while, so it shouldn't block the
+		// processing of the browser events. The browser waits for the
+		// video to be persisted when it closes.
+		if v.mode == VideoModeOn {
+			go func() {
+				if err := v.persist(GetFilePersister(p.ctx)); err != nil {
+					p.logger.Errorf("Page:didClose", "sid:%v %v", p.sessionID(), err)
+				}
+			}()
+		}
+	}
+
 	p.emit(EventPageClose, p)
 }
 
@@ -509,7 +597,16 @@ func (p *Page) getFrameSession(frameID cdp.FrameID) *FrameSession {
 }
 
 func (p *Page) hasRoutes() bool {
-	return len(p.routes) > 0
+	return p.routes.len() > 0 || p.browserCtx.routes.len() > 0
+}
+
+// routeHandler returns the route handler for the URL. The page routes
+// take precedence over the browser context routes.
+func (p *Page) routeHandler(url string) RouteHandler {
+	if h := p.routes.match(url); h != nil {
+		return h
+	}
+	return p.browserCtx.routes.match(url)
 }
 
 func (p *Page) resetViewport() error {
@@ -556,6 +653,21 @@ func (p *Page) updateExtraHTTPHeaders() error {
 	return nil
 }
 
+func (p *Page) updateFileChooserInterception() error {
+	p.logger.Debugf("Page:updateFileChooserInterception", "sid:%v", p.sessionID())
+
+	p.frameSessionsMu.RLock()
+	defer p.frameSessionsMu.RUnlock()
+
+	for _, fs := range p.frameSessions {
+		if err := fs.updateFileChooserInterception(false); err != nil {
+			return fmt.Errorf("updating file chooser interception: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (p *Page) updateGeolocation() error {
 	p.logger.Debugf("Page:updateGeolocation", "sid:%v", p.sessionID())
 
@@ -609,6 +721,21 @@ func (p *Page) updateHTTPCredentials() error {
 	return nil
 }
 
+func (p *Page) updateRequestInterception() error {
+	p.logger.Debugf("Page:updateRequestInterception", "sid:%v", p.sessionID())
+
+	p.frameSessionsMu.RLock()
+	defer p.frameSessionsMu.RUnlock()
+
+	for _, fs := range p.frameSessions {
+		if err := fs.updateRequestInterception(); err != nil {
+			return fmt.Errorf("updating request interception: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (p *Page) viewportSize() Size {
 	return Size{
 		Width:  float64(p.emulatedSize.Viewport.Width),
@@ -616,6 +743,20 @@ func (p *Page) viewportSize() Size {
 	}
 }
 
+// AddScriptTag adds a script tag to the main frame of the page.
+func (p *Page) AddScriptTag(opts *FrameAddScriptTagOptions) (*ElementHandle, error) {
+	p.logger.Debugf("Page:AddScriptTag", "sid:%v", p.sessionID())
+
+	return p.MainFrame().AddScriptTag(opts)
+}
+
+// AddStyleTag adds a style tag to the main frame of the page.
+func (p *Page) AddStyleTag(opts *FrameAddStyleTagOptions) (*ElementHandle, error) {
+	p.logger.Debugf("Page:AddStyleTag", "sid:%v", p.sessionID())
+
+	return p.MainFrame().AddStyleTag(opts)
+}
+
 // BringToFront activates the browser tab for this page.
 func (p *Page) BringToFront() error {
 	p.logger.Debugf("Page:BringToFront", "sid:%v", p.sessionID())
@@ -663,6 +804,10 @@ func (p *Page) Close(_ sobek.Value) error {
 	_, span := TraceAPICall(p.ctx, p.targetID.String(), "page.close")
 	defer span.End()
 
+	// the page is no longer sampled at the end of the iteration
+	// once it's closed.
+	p.sampleRuntimeMetrics(p.vu.Context())
+
 	// forcing the pagehide event to trigger web vitals metrics.
 	v := `() => window.dispatchEvent(new Event('pagehide'))`
 	ctx, cancel := context.WithTimeout(p.ctx, p.defaultTimeout())
@@ -783,6 +928,22 @@ func (p *Page) EmulateVisionDeficiency(typ string) error {
 	return nil
 }
 
+// ExposeBinding adds a function with the name to the window object of every
+// frame of the page, which calls fn with the source of the call and returns
+// a promise of its result. The function survives navigations.
+func (p *Page) ExposeBinding(name string, fn BindingFunc) error {
+	p.logger.Debugf("Page:ExposeBinding", "sid:%v name:%q", p.sessionID(), name)
+
+	if _, ok := p.browserCtx.bindings.get(name); ok {
+		return fmt.Errorf("function %q has been already registered in the browser context", name)
+	}
+	if !p.bindings.add(name, fn) {
+		return fmt.Errorf("function %q has been already registered", name)
+	}
+
+	return p.installBinding(name)
+}
+
 // Evaluate runs JS code within the execution context of the main frame of the page.
 func (p *Page) Evaluate(pageFunc string, args ...any) (any, error) {
 	p.logger.Debugf("Page:Evaluate", "sid:%v", p.sessionID())
@@ -829,6 +990,16 @@ func (p *Page) GetAttribute(selector string, name string, opts sobek.Value) (str
 	return p.MainFrame().GetAttribute(selector, name, opts)
 }
 
+// GetAccessibility returns the accessibility of the page.
+func (p *Page) GetAccessibility() *Accessibility {
+	return p.Accessibility
+}
+
+// GetCoverage returns the coverage of the page.
+func (p *Page) GetCoverage() *Coverage {
+	return p.Coverage
+}
+
 // GetKeyboard returns the keyboard for the page.
 func (p *Page) GetKeyboard() *Keyboard {
 	return p.Keyboard
@@ -855,12 +1026,28 @@ func (p *Page) Goto(url string, opts *FrameGotoOptions) (*Response, error) {
 	)
 	defer span.End()
 
+	vmOpts := p.visualMetricsOptions()
+	if vmOpts != nil {
+		if err := p.startFilmstrip(); err != nil {
+			spanRecordError(span, err)
+			return nil, err
+		}
+		defer p.stopFilmstrip()
+	}
+
 	resp, err := p.MainFrame().Goto(url, opts)
 	if err != nil {
 		spanRecordError(span, err)
 		return nil, err
 	}
 
+	if vmOpts != nil {
+		if err := p.finishFilmstrip(vmOpts); err != nil {
+			spanRecordError(span, err)
+			return nil, err
+		}
+	}
+
 	return resp, nil
 }
 
@@ -976,20 +1163,35 @@ func (p *Page) NavigationTimeout() time.Duration {
 }
 
 // On subscribes to a page event for which the given handler will be executed
-// passing in the ConsoleMessage associated with the event.
-// The only accepted event value is 'console'.
-func (p *Page) On(event string, handler func(*ConsoleMessage)) error {
-	if event != eventPageConsoleAPICalled {
-		return fmt.Errorf("unknown page event: %q, must be %q", event, eventPageConsoleAPICalled)
+// passing in the data associated with the event. The accepted event values
+// are 'console', 'dialog', 'download', 'filechooser', 'pageerror', 'popup',
+// 'request', 'response', 'requestfinished' and 'requestfailed'.
+func (p *Page) On(event string, handler PageOnHandler) error {
+	switch event {
+	case EventPageConsole,
+		EventPageDialog,
+		EventPageDownload,
+		EventPageFilechooser,
+		EventPageError,
+		EventPagePopup,
+		EventPageRequest,
+		EventPageResponse,
+		EventPageRequestFinished,
+		EventPageRequestFailed:
+	default:
+		return fmt.Errorf("unknown page event: %q", event)
 	}
 
 	p.eventHandlersMu.Lock()
-	defer p.eventHandlersMu.Unlock()
+	if _, ok := p.eventHandlers[event]; !ok {
+		p.eventHandlers[event] = make([]PageOnHandler, 0, 1)
+	}
+	p.eventHandlers[event] = append(p.eventHandlers[event], handler)
+	p.eventHandlersMu.Unlock()
 
-	if _, ok := p.eventHandlers[eventPageConsoleAPICalled]; !ok {
-		p.eventHandlers[eventPageConsoleAPICalled] = make([]consoleEventHandlerFunc, 0, 1)
+	if event == EventPageFilechooser {
+		return p.updateFileChooserInterception()
 	}
-	p.eventHandlers[eventPageConsoleAPICalled] = append(p.eventHandlers[eventPageConsoleAPICalled], handler)
 
 	return nil
 }
@@ -999,6 +1201,56 @@ func (p *Page) Opener() *Page {
 	return p.opener
 }
 
+// PDF generates a PDF of the page with the print CSS media type, and
+// persists it to the path in the options if the path is set.
+// It is only supported in headless mode.
+func (p *Page) PDF(opts *PagePDFOptions, sp ScreenshotPersister) ([]byte, error) {
+	p.logger.Debugf("Page:PDF", "sid:%v path:%s", p.sessionID(), opts.Path)
+
+	spanCtx, span := TraceAPICall(p.ctx, p.targetID.String(), "page.pdf")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("pdf.path", opts.Path))
+
+	if !p.browserCtx.browser.browserOpts.Headless {
+		err := errors.New("generating PDF: only supported in headless mode")
+		spanRecordError(span, err)
+		return nil, err
+	}
+
+	action := cdppage.PrintToPDF().
+		WithLandscape(opts.Landscape).
+		WithDisplayHeaderFooter(opts.DisplayHeaderFooter).
+		WithPrintBackground(opts.PrintBackground).
+		WithScale(opts.Scale).
+		WithPaperWidth(opts.Width).
+		WithPaperHeight(opts.Height).
+		WithMarginTop(opts.MarginTop).
+		WithMarginRight(opts.MarginRight).
+		WithMarginBottom(opts.MarginBottom).
+		WithMarginLeft(opts.MarginLeft).
+		WithPageRanges(opts.PageRanges).
+		WithHeaderTemplate(opts.HeaderTemplate).
+		WithFooterTemplate(opts.FooterTemplate).
+		WithPreferCSSPageSize(opts.PreferCSSPageSize)
+	buf, _, err := action.Do(cdp.WithExecutor(spanCtx, p.session))
+	if err != nil {
+		err := fmt.Errorf("generating PDF: %w", err)
+		spanRecordError(span, err)
+		return nil, err
+	}
+
+	if opts.Path != "" {
+		if err := sp.Persist(spanCtx, opts.Path, bytes.NewReader(buf)); err != nil {
+			err := fmt.Errorf("persisting PDF: %w", err)
+			spanRecordError(span, err)
+			return nil, err
+		}
+	}
+
+	return buf, nil
+}
+
 // Press presses the given key for the first element found that matches the selector.
 func (p *Page) Press(selector string, key string, opts sobek.Value) error {
 	p.logger.Debugf("Page:Press", "sid:%v selector:%s", p.sessionID(), selector)
@@ -1036,6 +1288,15 @@ func (p *Page) Reload(opts sobek.Value) (*Response, error) { //nolint:funlen,cyc
 		return nil, err
 	}
 
+	vmOpts := p.visualMetricsOptions()
+	if vmOpts != nil {
+		if err := p.startFilmstrip(); err != nil {
+			spanRecordError(span, err)
+			return nil, err
+		}
+		defer p.stopFilmstrip()
+	}
+
 	timeoutCtx, timeoutCancelFn := context.WithTimeout(p.ctx, reloadOpts.Timeout)
 	defer timeoutCancelFn()
 
@@ -1113,11 +1374,46 @@ func (p *Page) Reload(opts sobek.Value) (*Response, error) { //nolint:funlen,cyc
 		return nil, err
 	}
 
+	if vmOpts != nil {
+		if err := p.finishFilmstrip(vmOpts); err != nil {
+			spanRecordError(span, err)
+			return nil, err
+		}
+	}
+
 	applySlowMo(p.ctx)
 
 	return resp, nil
 }
 
+// Route registers a handler that intercepts the page requests whose
+// URL matches the given matcher.
+func (p *Page) Route(matcher *URLMatcher, handler RouteHandler) error {
+	p.logger.Debugf("Page:Route", "sid:%v url:%s", p.sessionID(), matcher)
+
+	p.routes.add(matcher, handler)
+
+	return p.updateRequestInterception()
+}
+
+// RouteFromHAR serves the requests of the page from the entries of the
+// HAR file. If update is set, it records the requests of the browser
+// context into the HAR file instead.
+func (p *Page) RouteFromHAR(path string, opts *RouteFromHAROptions) error {
+	p.logger.Debugf("Page:RouteFromHAR", "sid:%v path:%s", p.sessionID(), path)
+
+	if opts.Update {
+		return p.browserCtx.RouteFromHAR(path, opts)
+	}
+
+	router, err := newHARRouter(p.logger, path, opts.NotFound)
+	if err != nil {
+		return err
+	}
+
+	return p.Route(opts.URL, router.handle)
+}
+
 // Screenshot will instruct Chrome to save a screenshot of the current page and save it to specified file.
 func (p *Page) Screenshot(opts *PageScreenshotOptions, sp ScreenshotPersister) ([]byte, error) {
 	spanCtx, span := TraceAPICall(p.ctx, p.targetID.String(), "page.screenshot")
@@ -1275,6 +1571,15 @@ func (p *Page) Type(selector string, text string, opts sobek.Value) error {
 	return p.MainFrame().Type(selector, text, opts)
 }
 
+// Unroute removes the route handlers registered with the given URL matcher.
+func (p *Page) Unroute(matcher *URLMatcher) error {
+	p.logger.Debugf("Page:Unroute", "sid:%v url:%s", p.sessionID(), matcher)
+
+	p.routes.remove(matcher)
+
+	return p.updateRequestInterception()
+}
+
 // URL returns the location of the page.
 func (p *Page) URL() (string, error) {
 	p.logger.Debugf("Page:URL", "sid:%v", p.sessionID())
@@ -1303,6 +1608,61 @@ func (p *Page) ViewportSize() map[string]float64 {
 	}
 }
 
+// Video returns the video recording of the page, or nil if the browser
+// context doesn't record videos.
+func (p *Page) Video() *Video {
+	return p.video
+}
+
+// WaitForEvent waits for the page event and returns its data. If the
+// predicate is not nil, it waits until the predicate returns true for
+// the event data. The accepted event values are 'download' and 'popup'.
+func (p *Page) WaitForEvent(
+	event string, predicateFn func(PageOnEvent) (bool, error), timeout time.Duration,
+) (PageOnEvent, error) {
+	p.logger.Debugf("Page:WaitForEvent", "sid:%v event:%q", p.sessionID(), event)
+
+	if event != EventPageDownload && event != EventPagePopup {
+		return PageOnEvent{}, fmt.Errorf(
+			"incorrect event %q, only %q and %q are supported", event, EventPageDownload, EventPagePopup,
+		)
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, timeout)
+	defer cancel() // This will remove the event handler once we return from here.
+
+	ch := make(chan Event)
+	p.on(ctx, []string{event}, ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return PageOnEvent{}, fmt.Errorf("waitForEvent timed out after %v", timeout)
+			}
+			return PageOnEvent{}, ctx.Err() //nolint:wrapcheck
+		case ev := <-ch:
+			var e PageOnEvent
+			switch data := ev.data.(type) {
+			case *Download:
+				e.Download = data
+			case *Page:
+				e.Page = data
+			}
+			if predicateFn == nil {
+				return e, nil
+			}
+			ok, err := predicateFn(e)
+			if err != nil {
+				return PageOnEvent{}, fmt.Errorf("executing waitForEvent predicate: %w", err)
+			}
+			if ok {
+				return e, nil
+			}
+		}
+	}
+}
+
 // WaitForFunction waits for the given predicate to return a truthy value.
 func (p *Page) WaitForFunction(js string, opts *FrameWaitForFunctionOptions, jsArgs ...any) (any, error) {
 	p.logger.Debugf("Page:WaitForFunction", "sid:%v", p.sessionID())
@@ -1363,13 +1723,16 @@ func (p *Page) TargetID() string {
 }
 
 func (p *Page) onConsoleAPICalled(event *cdpruntime.EventConsoleAPICalled) {
+	if p.browserCtx != nil {
+		if rec := p.browserCtx.tracing.recorder(); rec != nil {
+			rec.recordConsole(p, event)
+		}
+	}
+
 	// If there are no handlers for EventConsoleAPICalled, return
-	p.eventHandlersMu.RLock()
-	if _, ok := p.eventHandlers[eventPageConsoleAPICalled]; !ok {
-		p.eventHandlersMu.RUnlock()
+	if !p.hasEventHandlers(eventPageConsoleAPICalled) {
 		return
 	}
-	p.eventHandlersMu.RUnlock()
 
 	m, err := p.consoleMsgFromConsoleEvent(event)
 	if err != nil {
@@ -1377,11 +1740,26 @@ func (p *Page) onConsoleAPICalled(event *cdpruntime.EventConsoleAPICalled) {
 		return
 	}
 
+	p.callEventHandlers(eventPageConsoleAPICalled, PageOnEvent{ConsoleMessage: m})
+}
+
+// hasEventHandlers returns true if there are handlers registered
+// with page.on for the event.
+func (p *Page) hasEventHandlers(event string) bool {
 	p.eventHandlersMu.RLock()
 	defer p.eventHandlersMu.RUnlock()
-	for _, h := range p.eventHandlers[eventPageConsoleAPICalled] {
-		h := h
-		h(m)
+
+	return len(p.eventHandlers[event]) > 0
+}
+
+// callEventHandlers calls the handlers registered with page.on for the
+// event in the order that they were registered.
+func (p *Page) callEventHandlers(event string, e PageOnEvent) {
+	p.eventHandlersMu.RLock()
+	defer p.eventHandlersMu.RUnlock()
+
+	for _, h := range p.eventHandlers[event] {
+		h(e)
 	}
 }
 
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"strings"
+
+	cdpruntime "github.com/chromedp/cdproto/runtime"
+)
+
+// PageError is an uncaught exception thrown in a page.
+type PageError struct {
+	// Name is the name of the error, e.g. TypeError. It is empty if the
+	// thrown value is not an error.
+	Name string
+
+	// Message is the message of the error, or the thrown value if it is
+	// not an error.
+	Message string
+
+	// Stack is the stack trace of the error, including the name and the
+	// message of the error, as reported by the browser.
+	Stack string
+}
+
+func newPageError(exc *cdpruntime.ExceptionDetails) *PageError {
+	msg := parseExceptionDetails(exc)
+	if exc.Exception == nil {
+		msg = exc.Text
+	}
+	// Values that are not errors, such as strings, don't have a name or
+	// a stack trace.
+	if exc.Exception == nil || exc.Exception.Subtype != cdpruntime.SubtypeError {
+		return &PageError{Message: msg}
+	}
+
+	// The description of an error starts with its name and message, and
+	// continues with the stack frames, e.g.:
+	//
+	//	TypeError: x is not a function
+	//	    at foo (https://test.k6.io/app.js:1:15)
+	var (
+		pe    = &PageError{Stack: msg}
+		lines = strings.Split(msg, "\n")
+		head  = msg
+	)
+	for i, l := range lines {
+		if strings.HasPrefix(l, "    at ") {
+			head = strings.Join(lines[:i], "\n")
+			break
+		}
+	}
+	pe.Message = head
+	if name, m, ok := strings.Cut(head, ": "); ok {
+		pe.Name, pe.Message = name, m
+	} else if exc.Exception.ClassName == head {
+		pe.Name, pe.Message = head, ""
+	}
+
+	return pe
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"testing"
+
+	cdpruntime "github.com/chromedp/cdproto/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPageError(t *testing.T) {
+	t.Parallel()
+
+	const stack = "TypeError: x is not a function\n" +
+		"    at foo (https://test.k6.io/app.js:1:15)\n" +
+		"    at https://test.k6.io/app.js:2:1"
+
+	tests := []struct {
+		name string
+		exc  *cdpruntime.ExceptionDetails
+		want PageError
+	}{
+		{
+			name: "error",
+			exc: &cdpruntime.ExceptionDetails{
+				Text: "Uncaught",
+				Exception: &cdpruntime.RemoteObject{
+					Type:        cdpruntime.TypeObject,
+					Subtype:     cdpruntime.SubtypeError,
+					ClassName:   "TypeError",
+					Description: stack,
+				},
+			},
+			want: PageError{Name: "TypeError", Message: "x is not a function", Stack: stack},
+		},
+		{
+			name: "error_without_stack",
+			exc: &cdpruntime.ExceptionDetails{
+				Text: "Uncaught",
+				Exception: &cdpruntime.RemoteObject{
+					Type:        cdpruntime.TypeObject,
+					Subtype:     cdpruntime.SubtypeError,
+					ClassName:   "Error",
+					Description: "Error: boom",
+				},
+			},
+			want: PageError{Name: "Error", Message: "boom", Stack: "Error: boom"},
+		},
+		{
+			name: "error_without_message",
+			exc: &cdpruntime.ExceptionDetails{
+				Text: "Uncaught",
+				Exception: &cdpruntime.RemoteObject{
+					Type:        cdpruntime.TypeObject,
+					Subtype:     cdpruntime.SubtypeError,
+					ClassName:   "Error",
+					Description: "Error\n    at https://test.k6.io/app.js:2:1",
+				},
+			},
+			want: PageError{Name: "Error", Stack: "Error\n    at https://test.k6.io/app.js:2:1"},
+		},
+		{
+			name: "string",
+			exc: &cdpruntime.ExceptionDetails{
+				Text: "Uncaught",
+				Exception: &cdpruntime.RemoteObject{
+					Type:  cdpruntime.TypeString,
+					Value: []byte(`"boom"`),
+				},
+			},
+			want: PageError{Message: "boom"},
+		},
+		{
+			name: "no_exception",
+			exc:  &cdpruntime.ExceptionDetails{Text: "Uncaught SyntaxError"},
+			want: PageError{Message: "Uncaught SyntaxError"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, *newPageError(tt.exc))
+		})
+	}
+}
@@ -2,7 +2,9 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -146,3 +148,374 @@ func (o *PageScreenshotOptions) Parse(ctx context.Context, opts sobek.Value) err
 
 	return nil
 }
+
+// PagePDFOptions are the options of Page.PDF.
+// The paper sizes and the margins are in inches.
+type PagePDFOptions struct {
+	Path                string  `json:"path"`
+	Width               float64 `json:"width"`
+	Height              float64 `json:"height"`
+	MarginTop           float64 `json:"marginTop"`
+	MarginRight         float64 `json:"marginRight"`
+	MarginBottom        float64 `json:"marginBottom"`
+	MarginLeft          float64 `json:"marginLeft"`
+	PrintBackground     bool    `json:"printBackground"`
+	Landscape           bool    `json:"landscape"`
+	PageRanges          string  `json:"pageRanges"`
+	HeaderTemplate      string  `json:"headerTemplate"`
+	FooterTemplate      string  `json:"footerTemplate"`
+	DisplayHeaderFooter bool    `json:"displayHeaderFooter"`
+	PreferCSSPageSize   bool    `json:"preferCSSPageSize"`
+	Scale               float64 `json:"scale"`
+}
+
+// pdfPaperFormats are the paper sizes in inches that the format option
+// accepts. The names are matched case-insensitively.
+var pdfPaperFormats = map[string][2]float64{ //nolint:gochecknoglobals
+	"letter":  {8.5, 11},
+	"legal":   {8.5, 14},
+	"tabloid": {11, 17},
+	"ledger":  {17, 11},
+	"a0":      {33.1, 46.8},
+	"a1":      {23.4, 33.1},
+	"a2":      {16.54, 23.4},
+	"a3":      {11.7, 16.54},
+	"a4":      {8.27, 11.7},
+	"a5":      {5.83, 8.27},
+	"a6":      {4.13, 5.83},
+}
+
+// pdfUnitsToInches converts the units that the PDF sizes accept to inches.
+var pdfUnitsToInches = map[string]float64{ //nolint:gochecknoglobals
+	"px": 1.0 / 96,
+	"in": 1,
+	"cm": 1 / 2.54,
+	"mm": 1 / 25.4,
+}
+
+// NewPagePDFOptions returns the default page PDF options, which print
+// a Letter sized page without margins.
+func NewPagePDFOptions() *PagePDFOptions {
+	letter := pdfPaperFormats["letter"]
+
+	return &PagePDFOptions{
+		Width:  letter[0],
+		Height: letter[1],
+		Scale:  1,
+	}
+}
+
+// Parse parses the page PDF options.
+func (o *PagePDFOptions) Parse(ctx context.Context, opts sobek.Value) error { //nolint:cyclop,funlen
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	var (
+		rt             = k6ext.Runtime(ctx)
+		obj            = opts.ToObject(rt)
+		format         string
+		width, height  float64
+		widthSpecified bool
+		err            error
+	)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "path":
+			o.Path = v.String()
+		case "format":
+			format = v.String()
+		case "width":
+			if width, err = parsePDFLength(v); err != nil {
+				return fmt.Errorf("parsing width: %w", err)
+			}
+			widthSpecified = true
+		case "height":
+			if height, err = parsePDFLength(v); err != nil {
+				return fmt.Errorf("parsing height: %w", err)
+			}
+		case "margin":
+			if err := o.parseMargin(rt, v); err != nil {
+				return err
+			}
+		case "printBackground":
+			o.PrintBackground = v.ToBoolean()
+		case "landscape":
+			o.Landscape = v.ToBoolean()
+		case "pageRanges":
+			o.PageRanges = v.String()
+		case "headerTemplate":
+			o.HeaderTemplate = v.String()
+			o.DisplayHeaderFooter = true
+		case "footerTemplate":
+			o.FooterTemplate = v.String()
+			o.DisplayHeaderFooter = true
+		case "displayHeaderFooter":
+			o.DisplayHeaderFooter = v.ToBoolean()
+		case "preferCSSPageSize":
+			o.PreferCSSPageSize = v.ToBoolean()
+		case "scale":
+			o.Scale = v.ToFloat()
+			if o.Scale < 0.1 || o.Scale > 2 {
+				return fmt.Errorf("scale must be between 0.1 and 2, got %v", o.Scale)
+			}
+		}
+	}
+
+	// The format takes priority over the width and the height.
+	switch {
+	case format != "":
+		size, ok := pdfPaperFormats[strings.ToLower(format)]
+		if !ok {
+			return fmt.Errorf("unknown paper format: %q", format)
+		}
+		o.Width, o.Height = size[0], size[1]
+	case widthSpecified:
+		o.Width = width
+		if height > 0 {
+			o.Height = height
+		}
+	case height > 0:
+		o.Height = height
+	}
+
+	return nil
+}
+
+func (o *PagePDFOptions) parseMargin(rt *sobek.Runtime, v sobek.Value) error {
+	if !sobekValueExists(v) {
+		return nil
+	}
+
+	obj := v.ToObject(rt)
+	for _, k := range obj.Keys() {
+		var dst *float64
+		switch k {
+		case "top":
+			dst = &o.MarginTop
+		case "right":
+			dst = &o.MarginRight
+		case "bottom":
+			dst = &o.MarginBottom
+		case "left":
+			dst = &o.MarginLeft
+		default:
+			continue
+		}
+		m, err := parsePDFLength(obj.Get(k))
+		if err != nil {
+			return fmt.Errorf("parsing %s margin: %w", k, err)
+		}
+		*dst = m
+	}
+
+	return nil
+}
+
+// parsePDFLength converts a length to inches. The length is either a number
+// of pixels or a string with one of the px, in, cm or mm units, e.g. "2cm".
+// A string without a unit is in pixels.
+func parsePDFLength(v sobek.Value) (float64, error) {
+	s, ok := v.Export().(string)
+	if !ok {
+		return v.ToFloat() * pdfUnitsToInches["px"], nil
+	}
+
+	s = strings.TrimSpace(s)
+	unit := "px"
+	if len(s) > 2 {
+		if _, ok := pdfUnitsToInches[strings.ToLower(s[len(s)-2:])]; ok {
+			unit = strings.ToLower(s[len(s)-2:])
+			s = s[:len(s)-2]
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid length", v.String())
+	}
+
+	return n * pdfUnitsToInches[unit], nil
+}
+
+// ScreenshotAnimations controls the CSS animations and transitions of a
+// page while a screenshot is compared.
+type ScreenshotAnimations string
+
+const (
+	// ScreenshotAnimationsAllow leaves the animations as they are.
+	ScreenshotAnimationsAllow ScreenshotAnimations = "allow"
+
+	// ScreenshotAnimationsDisabled disables the animations and transitions.
+	ScreenshotAnimationsDisabled ScreenshotAnimations = "disabled"
+)
+
+// ScreenshotCaret controls the text caret of a page while a screenshot is
+// compared.
+type ScreenshotCaret string
+
+const (
+	// ScreenshotCaretHide hides the text caret.
+	ScreenshotCaretHide ScreenshotCaret = "hide"
+
+	// ScreenshotCaretInitial leaves the text caret as it is.
+	ScreenshotCaretInitial ScreenshotCaret = "initial"
+)
+
+// CompareScreenshotOptions are the options of Page.CompareScreenshot and
+// Locator.CompareScreenshot.
+type CompareScreenshotOptions struct {
+	// BaselineDir is the directory of the baseline screenshots. The diff
+	// and the actual screenshots are also persisted to it on mismatch.
+	BaselineDir string `js:"baselineDir"`
+	// Threshold is the maximum color difference between 0 and 1 of two
+	// pixels that are considered the same.
+	Threshold float64 `js:"threshold"`
+	// IncludeAA counts the anti-aliased pixels as different pixels.
+	IncludeAA bool `js:"includeAA"`
+	// MaxDiffPixels and MaxDiffPixelRatio are the maximum number and the
+	// maximum ratio of different pixels for the screenshots to match. The
+	// screenshots match if they are within either of the limits.
+	MaxDiffPixels     int64   `js:"maxDiffPixels"`
+	MaxDiffPixelRatio float64 `js:"maxDiffPixelRatio"`
+	// Mask are the selectors of the elements that are covered with the
+	// MaskColor in the screenshot, such as dynamic content.
+	Mask       []string             `js:"mask"`
+	MaskColor  string               `js:"maskColor"`
+	Animations ScreenshotAnimations `js:"animations"`
+	Caret      ScreenshotCaret      `js:"caret"`
+	// FullPage is only used by Page.CompareScreenshot.
+	FullPage bool `js:"fullPage"`
+	// UpdateBaselines replaces the baseline with the actual screenshot.
+	UpdateBaselines bool          `js:"updateBaselines"`
+	Timeout         time.Duration `js:"timeout"`
+}
+
+// NewCompareScreenshotOptions returns the default compare screenshot
+// options.
+func NewCompareScreenshotOptions(defaultTimeout time.Duration) *CompareScreenshotOptions {
+	return &CompareScreenshotOptions{
+		BaselineDir: "screenshots",
+		Threshold:   0.2,
+		MaskColor:   "#FF00FF",
+		Animations:  ScreenshotAnimationsDisabled,
+		Caret:       ScreenshotCaretHide,
+		Timeout:     defaultTimeout,
+	}
+}
+
+// Parse parses the compare screenshot options.
+func (o *CompareScreenshotOptions) Parse(ctx context.Context, opts sobek.Value) error { //nolint:cyclop,funlen
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	obj := opts.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "baselineDir":
+			o.BaselineDir = v.String()
+		case "threshold":
+			o.Threshold = v.ToFloat()
+			if o.Threshold < 0 || o.Threshold > 1 {
+				return fmt.Errorf("threshold must be between 0 and 1, got %v", o.Threshold)
+			}
+		case "includeAA":
+			o.IncludeAA = v.ToBoolean()
+		case "maxDiffPixels":
+			o.MaxDiffPixels = v.ToInteger()
+		case "maxDiffPixelRatio":
+			o.MaxDiffPixelRatio = v.ToFloat()
+			if o.MaxDiffPixelRatio < 0 || o.MaxDiffPixelRatio > 1 {
+				return fmt.Errorf("maxDiffPixelRatio must be between 0 and 1, got %v", o.MaxDiffPixelRatio)
+			}
+		case "mask":
+			var mask []string
+			if err := rt.ExportTo(v, &mask); err != nil {
+				return fmt.Errorf("mask must be an array of selectors: %w", err)
+			}
+			o.Mask = mask
+		case "maskColor":
+			o.MaskColor = v.String()
+		case "animations":
+			switch a := ScreenshotAnimations(v.String()); a {
+			case ScreenshotAnimationsAllow, ScreenshotAnimationsDisabled:
+				o.Animations = a
+			default:
+				return fmt.Errorf("invalid animations %q, must be one of 'allow' or 'disabled'", a)
+			}
+		case "caret":
+			switch c := ScreenshotCaret(v.String()); c {
+			case ScreenshotCaretHide, ScreenshotCaretInitial:
+				o.Caret = c
+			default:
+				return fmt.Errorf("invalid caret %q, must be one of 'hide' or 'initial'", c)
+			}
+		case "fullPage":
+			o.FullPage = v.ToBoolean()
+		case "updateBaselines":
+			o.UpdateBaselines = v.ToBoolean()
+		case "timeout":
+			o.Timeout = time.Duration(v.ToInteger()) * time.Millisecond
+		}
+	}
+
+	return nil
+}
+
+// PageCheckAccessibilityOptions are the options of Page.CheckAccessibility.
+type PageCheckAccessibilityOptions struct {
+	// Rules are the rules to check. All the rules are checked by default.
+	Rules []AccessibilityRule `js:"rules"`
+}
+
+// NewPageCheckAccessibilityOptions returns the default check accessibility
+// options.
+func NewPageCheckAccessibilityOptions() *PageCheckAccessibilityOptions {
+	return &PageCheckAccessibilityOptions{
+		Rules: append([]AccessibilityRule(nil), accessibilityRules...),
+	}
+}
+
+// Parse parses the check accessibility options.
+func (o *PageCheckAccessibilityOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	obj := opts.ToObject(rt)
+	for _, k := range obj.Keys() {
+		if k != "rules" {
+			continue
+		}
+		var rules []string
+		if err := rt.ExportTo(obj.Get(k), &rules); err != nil {
+			return fmt.Errorf("rules must be an array of rule names: %w", err)
+		}
+		if len(rules) == 0 {
+			return errors.New("rules must not be empty")
+		}
+		o.Rules = o.Rules[:0]
+		for _, r := range rules {
+			if !isAccessibilityRule(AccessibilityRule(r)) {
+				return fmt.Errorf("unknown accessibility rule %q", r)
+			}
+			o.Rules = append(o.Rules, AccessibilityRule(r))
+		}
+	}
+
+	return nil
+}
+
+func isAccessibilityRule(rule AccessibilityRule) bool {
+	for _, r := range accessibilityRules {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,247 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/xk6-browser/k6ext/k6test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagePDFOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPagePDFOptions()
+		require.NoError(t, opts.Parse(vu.Context(), nil))
+
+		assert.Equal(t, 8.5, opts.Width)
+		assert.Equal(t, 11.0, opts.Height)
+		assert.Equal(t, 1.0, opts.Scale)
+		assert.False(t, opts.DisplayHeaderFooter)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPagePDFOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"path":            "invoice.pdf",
+			"format":          "a4",
+			"landscape":       true,
+			"printBackground": true,
+			"pageRanges":      "1-2",
+			"headerTemplate":  "<span class=title></span>",
+			"scale":           1.5,
+			"margin": map[string]any{
+				"top":    "1in",
+				"right":  "2.54cm",
+				"bottom": "25.4mm",
+				"left":   96,
+			},
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "invoice.pdf", opts.Path)
+		assert.Equal(t, 8.27, opts.Width)
+		assert.Equal(t, 11.7, opts.Height)
+		assert.True(t, opts.Landscape)
+		assert.True(t, opts.PrintBackground)
+		assert.Equal(t, "1-2", opts.PageRanges)
+		assert.True(t, opts.DisplayHeaderFooter)
+		assert.Equal(t, 1.5, opts.Scale)
+		assert.InDelta(t, 1, opts.MarginTop, 1e-9)
+		assert.InDelta(t, 1, opts.MarginRight, 1e-9)
+		assert.InDelta(t, 1, opts.MarginBottom, 1e-9)
+		assert.InDelta(t, 1, opts.MarginLeft, 1e-9)
+	})
+
+	t.Run("width_and_height", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPagePDFOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"width":  "480px",
+			"height": "10cm",
+		}))
+		require.NoError(t, err)
+
+		assert.InDelta(t, 5, opts.Width, 1e-9)
+		assert.InDelta(t, 10/2.54, opts.Height, 1e-9)
+	})
+
+	t.Run("err/format", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPagePDFOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"format": "B5",
+		}))
+		assert.EqualError(t, err, `unknown paper format: "B5"`)
+	})
+
+	t.Run("err/length", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPagePDFOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"width": "wide",
+		}))
+		assert.EqualError(t, err, `parsing width: "wide" is not a valid length`)
+	})
+
+	t.Run("err/scale", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPagePDFOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"scale": 3,
+		}))
+		assert.EqualError(t, err, "scale must be between 0.1 and 2, got 3")
+	})
+}
+
+func TestCompareScreenshotOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewCompareScreenshotOptions(time.Second)
+		require.NoError(t, opts.Parse(vu.Context(), nil))
+
+		assert.Equal(t, "screenshots", opts.BaselineDir)
+		assert.Equal(t, 0.2, opts.Threshold)
+		assert.Equal(t, ScreenshotAnimationsDisabled, opts.Animations)
+		assert.Equal(t, ScreenshotCaretHide, opts.Caret)
+		assert.Equal(t, time.Second, opts.Timeout)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewCompareScreenshotOptions(time.Second)
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"baselineDir":       "baselines",
+			"threshold":         0.5,
+			"includeAA":         true,
+			"maxDiffPixels":     10,
+			"maxDiffPixelRatio": 0.01,
+			"mask":              []string{".ad", "#clock"},
+			"maskColor":         "black",
+			"animations":        "allow",
+			"caret":             "initial",
+			"fullPage":          true,
+			"updateBaselines":   true,
+			"timeout":           500,
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, &CompareScreenshotOptions{
+			BaselineDir:       "baselines",
+			Threshold:         0.5,
+			IncludeAA:         true,
+			MaxDiffPixels:     10,
+			MaxDiffPixelRatio: 0.01,
+			Mask:              []string{".ad", "#clock"},
+			MaskColor:         "black",
+			Animations:        ScreenshotAnimationsAllow,
+			Caret:             ScreenshotCaretInitial,
+			FullPage:          true,
+			UpdateBaselines:   true,
+			Timeout:           500 * time.Millisecond,
+		}, opts)
+	})
+
+	for name, tt := range map[string]struct {
+		opts    map[string]any
+		wantErr string
+	}{
+		"err/threshold": {
+			opts:    map[string]any{"threshold": 2},
+			wantErr: "threshold must be between 0 and 1",
+		},
+		"err/maxDiffPixelRatio": {
+			opts:    map[string]any{"maxDiffPixelRatio": -1},
+			wantErr: "maxDiffPixelRatio must be between 0 and 1",
+		},
+		"err/animations": {
+			opts:    map[string]any{"animations": "paused"},
+			wantErr: `invalid animations "paused"`,
+		},
+		"err/caret": {
+			opts:    map[string]any{"caret": "blink"},
+			wantErr: `invalid caret "blink"`,
+		},
+	} {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			vu := k6test.NewVU(t)
+			opts := NewCompareScreenshotOptions(time.Second)
+			err := opts.Parse(vu.Context(), vu.ToSobekValue(tt.opts))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPageCheckAccessibilityOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPageCheckAccessibilityOptions()
+		require.NoError(t, opts.Parse(vu.Context(), nil))
+		assert.Equal(t, accessibilityRules, opts.Rules)
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPageCheckAccessibilityOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"rules": []string{"image-alt", "tabindex"},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []AccessibilityRule{AccessibilityRuleImageAlt, AccessibilityRuleTabIndex}, opts.Rules)
+		assert.Len(t, accessibilityRules, 5, "the default rules should not change")
+	})
+
+	t.Run("err/unknown_rule", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPageCheckAccessibilityOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"rules": []string{"image-alt", "aria"},
+		}))
+		assert.ErrorContains(t, err, `unknown accessibility rule "aria"`)
+	})
+
+	t.Run("err/empty_rules", func(t *testing.T) {
+		t.Parallel()
+
+		vu := k6test.NewVU(t)
+		opts := NewPageCheckAccessibilityOptions()
+		err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+			"rules": []string{},
+		}))
+		assert.ErrorContains(t, err, "rules must not be empty")
+	})
+}
@@ -0,0 +1,396 @@
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/k6ext"
+	"github.com/grafana/xk6-browser/log"
+)
+
+// ErrRouteAlreadyHandled is returned when a route is continued, fulfilled
+// or aborted more than once.
+var ErrRouteAlreadyHandled = errors.New("route is already handled")
+
+// routeAbortErrorReasons maps the Playwright abort error codes to the
+// CDP network error reasons.
+var routeAbortErrorReasons = map[string]network.ErrorReason{ //nolint:gochecknoglobals
+	"aborted":              network.ErrorReasonAborted,
+	"accessdenied":         network.ErrorReasonAccessDenied,
+	"addressunreachable":   network.ErrorReasonAddressUnreachable,
+	"blockedbyclient":      network.ErrorReasonBlockedByClient,
+	"blockedbyresponse":    network.ErrorReasonBlockedByResponse,
+	"connectionaborted":    network.ErrorReasonConnectionAborted,
+	"connectionclosed":     network.ErrorReasonConnectionClosed,
+	"connectionfailed":     network.ErrorReasonConnectionFailed,
+	"connectionrefused":    network.ErrorReasonConnectionRefused,
+	"connectionreset":      network.ErrorReasonConnectionReset,
+	"internetdisconnected": network.ErrorReasonInternetDisconnected,
+	"namenotresolved":      network.ErrorReasonNameNotResolved,
+	"timedout":             network.ErrorReasonTimedOut,
+	"failed":               network.ErrorReasonFailed,
+}
+
+// RouteHandler is called with the route of an intercepted request.
+// The handler is expected to continue, fulfill or abort the route. The
+// request is continued if the route is not handled in the default
+// timeout of the page.
+type RouteHandler func(*Route)
+
+// URLMatcher matches request URLs against a glob pattern or a regular
+// expression.
+type URLMatcher struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// NewURLMatcher returns a URLMatcher for the given glob string or
+// JavaScript RegExp value.
+func NewURLMatcher(ctx context.Context, url sobek.Value) (*URLMatcher, error) {
+	if !sobekValueExists(url) {
+		return nil, errors.New("missing URL pattern")
+	}
+
+	rt := k6ext.Runtime(ctx)
+	if o := url.ToObject(rt); o.ClassName() == "RegExp" {
+		return NewRegExpURLMatcher(o.Get("source").String(), o.Get("flags").String())
+	}
+
+	return NewGlobURLMatcher(url.String())
+}
+
+// NewGlobURLMatcher returns a URLMatcher for a glob pattern.
+// A single '*' matches any characters except '/', '**' matches any
+// characters, '?' matches a single character, and '{a,b}' matches
+// any of the comma separated alternatives.
+func NewGlobURLMatcher(glob string) (*URLMatcher, error) {
+	re, err := regexp.Compile(globToRegexp(glob))
+	if err != nil {
+		return nil, fmt.Errorf("compiling glob URL pattern %q: %w", glob, err)
+	}
+
+	return &URLMatcher{pattern: glob, re: re}, nil
+}
+
+// NewRegExpURLMatcher returns a URLMatcher for a regular expression
+// with the given JavaScript flags. Only the 'i', 'm' and 's' flags
+// affect matching.
+func NewRegExpURLMatcher(source, flags string) (*URLMatcher, error) {
+	var goFlags string
+	for _, f := range flags {
+		if strings.ContainsRune("ims", f) {
+			goFlags += string(f)
+		}
+	}
+	expr := source
+	if goFlags != "" {
+		expr = "(?" + goFlags + ")" + source
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("compiling regular expression URL pattern /%s/%s: %w", source, flags, err)
+	}
+
+	return &URLMatcher{pattern: "/" + source + "/" + flags, re: re}, nil
+}
+
+// Match returns true if the URL matches the pattern.
+func (m *URLMatcher) Match(url string) bool {
+	return m.re.MatchString(url)
+}
+
+// String returns the pattern that the matcher was created with.
+func (m *URLMatcher) String() string {
+	return m.pattern
+}
+
+func globToRegexp(glob string) string {
+	var (
+		sb      strings.Builder
+		inGroup bool
+	)
+	sb.WriteByte('^')
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			i++
+			sb.WriteString(".*")
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteByte('.')
+		case c == '{':
+			inGroup = true
+			sb.WriteByte('(')
+		case c == '}' && inGroup:
+			inGroup = false
+			sb.WriteByte(')')
+		case c == ',' && inGroup:
+			sb.WriteByte('|')
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteByte('$')
+
+	return sb.String()
+}
+
+type routeHandlerEntry struct {
+	matcher *URLMatcher
+	handler RouteHandler
+}
+
+// routes keeps the route handlers of a page or a browser context.
+type routes struct {
+	mu       sync.RWMutex
+	handlers []routeHandlerEntry
+}
+
+func (r *routes) add(matcher *URLMatcher, handler RouteHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers = append(r.handlers, routeHandlerEntry{matcher: matcher, handler: handler})
+}
+
+// remove removes all the handlers registered with the same URL pattern.
+func (r *routes) remove(matcher *URLMatcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	handlers := make([]routeHandlerEntry, 0, len(r.handlers))
+	for _, h := range r.handlers {
+		if h.matcher.String() != matcher.String() {
+			handlers = append(handlers, h)
+		}
+	}
+	r.handlers = handlers
+}
+
+// match returns the most recently registered handler that matches
+// the URL, or nil if none matches.
+func (r *routes) match(url string) RouteHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := len(r.handlers) - 1; i >= 0; i-- {
+		if r.handlers[i].matcher.Match(url) {
+			return r.handlers[i].handler
+		}
+	}
+
+	return nil
+}
+
+func (r *routes) len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.handlers)
+}
+
+// Route represents a request intercepted by a route handler.
+type Route struct {
+	ctx     context.Context
+	logger  *log.Logger
+	session session
+	request *Request
+	id      fetch.RequestID
+	// traceContext are the trace context headers that are added to
+	// the request when it's continued.
+	traceContext map[string]string
+
+	handledMu sync.Mutex
+	handled   bool
+	// defaultTimer continues the request if it's not handled in time.
+	defaultTimer *time.Timer
+}
+
+func newRoute(ctx context.Context, logger *log.Logger, s session, id fetch.RequestID, req *Request) *Route {
+	return &Route{
+		ctx:     ctx,
+		logger:  logger,
+		session: s,
+		request: req,
+		id:      id,
+	}
+}
+
+// Request returns the intercepted request.
+func (r *Route) Request() *Request {
+	return r.request
+}
+
+// Abort aborts the request with the given error code.
+// It defaults to 'failed' if the error code is empty.
+func (r *Route) Abort(errorCode string) error {
+	r.logger.Debugf("Route:Abort", "rid:%s url:%s code:%q", r.id, r.request.URL(), errorCode)
+
+	if errorCode == "" {
+		errorCode = "failed"
+	}
+	reason, ok := routeAbortErrorReasons[strings.ToLower(errorCode)]
+	if !ok {
+		return fmt.Errorf("invalid abort error code %q", errorCode)
+	}
+	if err := r.startHandling(); err != nil {
+		return err
+	}
+
+	action := fetch.FailRequest(r.id, reason)
+	if err := action.Do(cdp.WithExecutor(r.ctx, r.session)); err != nil {
+		return fmt.Errorf("aborting request: %w", err)
+	}
+
+	return nil
+}
+
+// Continue sends the request to the network with optional overrides.
+func (r *Route) Continue(opts *RouteContinueOptions) error {
+	r.logger.Debugf("Route:Continue", "rid:%s url:%s", r.id, r.request.URL())
+
+	if err := r.startHandling(); err != nil {
+		return err
+	}
+
+	action := fetch.ContinueRequest(r.id)
+	if opts.URL != "" {
+		action = action.WithURL(opts.URL)
+	}
+	if opts.Method != "" {
+		action = action.WithMethod(opts.Method)
+	}
+	if headers := r.continueHeaders(opts.Headers); len(headers) > 0 {
+		action = action.WithHeaders(toHeaderEntries(headers))
+	}
+	if len(opts.PostData) > 0 {
+		action = action.WithPostData(base64.StdEncoding.EncodeToString(opts.PostData))
+	}
+	if err := action.Do(cdp.WithExecutor(r.ctx, r.session)); err != nil {
+		return fmt.Errorf("continuing request: %w", err)
+	}
+
+	return nil
+}
+
+// continueHeaders returns the headers to continue the request with. The
+// trace context headers are added to the request headers, or to the
+// headers that override them, unless they're overridden as well. It
+// returns nil if the request headers should not be changed.
+func (r *Route) continueHeaders(overrides map[string]string) map[string]string {
+	switch {
+	case len(r.traceContext) == 0:
+		return overrides
+	case len(overrides) == 0:
+		return mergeHeaders(r.request.Headers(), r.traceContext)
+	default:
+		return mergeHeaders(r.traceContext, overrides)
+	}
+}
+
+// Fulfill responds to the request with the given response.
+func (r *Route) Fulfill(opts *RouteFulfillOptions) error {
+	r.logger.Debugf("Route:Fulfill", "rid:%s url:%s status:%d", r.id, r.request.URL(), opts.Status)
+
+	if err := r.startHandling(); err != nil {
+		return err
+	}
+
+	headers := make(map[string]string, len(opts.Headers))
+	for n, v := range opts.Headers {
+		headers[strings.ToLower(n)] = v
+	}
+	if _, ok := headers["content-type"]; !ok && opts.ContentType != "" {
+		headers["content-type"] = opts.ContentType
+	}
+	if _, ok := headers["content-length"]; !ok && len(opts.Body) > 0 {
+		headers["content-length"] = strconv.Itoa(len(opts.Body))
+	}
+
+	action := fetch.FulfillRequest(r.id, opts.Status).
+		WithResponseHeaders(toHeaderEntries(headers))
+	if len(opts.Body) > 0 {
+		action = action.WithBody(base64.StdEncoding.EncodeToString(opts.Body))
+	}
+	if err := action.Do(cdp.WithExecutor(r.ctx, r.session)); err != nil {
+		return fmt.Errorf("fulfilling request: %w", err)
+	}
+
+	return nil
+}
+
+// HandlerFailed aborts the request if the route handler failed before
+// handling it, since the request would otherwise stay paused forever.
+func (r *Route) HandlerFailed(err error) {
+	r.logger.Errorf("Route:HandlerFailed", "rid:%s url:%s err:%v", r.id, r.request.URL(), err)
+
+	if err := r.Abort("failed"); err != nil && !errors.Is(err, ErrRouteAlreadyHandled) {
+		r.logger.Debugf("Route:HandlerFailed", "rid:%s aborting request: %v", r.id, err)
+	}
+}
+
+// continueByDefaultAfter continues the request if it's not handled within
+// the timeout, e.g. since a synchronous call blocks the event loop that
+// runs the route handlers.
+func (r *Route) continueByDefaultAfter(timeout time.Duration) {
+	r.handledMu.Lock()
+	defer r.handledMu.Unlock()
+
+	if r.handled {
+		return
+	}
+	r.defaultTimer = time.AfterFunc(timeout, func() {
+		r.logger.Debugf("Route:continueByDefaultAfter", "rid:%s url:%s not handled in %s", r.id, r.request.URL(), timeout)
+
+		err := r.Continue(NewRouteContinueOptions())
+		if err != nil && !errors.Is(err, ErrRouteAlreadyHandled) {
+			r.logger.Errorf("Route:continueByDefaultAfter", "rid:%s continuing request: %v", r.id, err)
+		}
+	})
+}
+
+func (r *Route) startHandling() error {
+	r.handledMu.Lock()
+	defer r.handledMu.Unlock()
+
+	if r.handled {
+		return ErrRouteAlreadyHandled
+	}
+	r.handled = true
+	if r.defaultTimer != nil {
+		r.defaultTimer.Stop()
+	}
+
+	return nil
+}
+
+// toHeaderEntries converts the headers to CDP header entries sorted by name.
+func toHeaderEntries(headers map[string]string) []*fetch.HeaderEntry {
+	entries := make([]*fetch.HeaderEntry, 0, len(headers))
+	for n, v := range headers {
+		entries = append(entries, &fetch.HeaderEntry{Name: n, Value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
@@ -0,0 +1,166 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// RouteContinueOptions are the request overrides of route.continue.
+type RouteContinueOptions struct {
+	URL      string            `js:"url"`
+	Method   string            `js:"method"`
+	Headers  map[string]string `js:"headers"`
+	PostData []byte            `js:"postData"`
+}
+
+// NewRouteContinueOptions returns a new RouteContinueOptions.
+func NewRouteContinueOptions() *RouteContinueOptions {
+	return &RouteContinueOptions{}
+}
+
+// Parse parses the route continue options.
+func (o *RouteContinueOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	obj := opts.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "url":
+			o.URL = v.String()
+		case "method":
+			o.Method = v.String()
+		case "headers":
+			if err := rt.ExportTo(v, &o.Headers); err != nil {
+				return fmt.Errorf("parsing headers: %w", err)
+			}
+		case "postData":
+			b, err := bytesFromValue(v)
+			if err != nil {
+				return fmt.Errorf("parsing post data: %w", err)
+			}
+			o.PostData = b
+		}
+	}
+
+	return nil
+}
+
+// RouteFulfillOptions are the response details of route.fulfill.
+type RouteFulfillOptions struct {
+	Status      int64             `js:"status"`
+	Headers     map[string]string `js:"headers"`
+	ContentType string            `js:"contentType"`
+	Body        []byte            `js:"body"`
+}
+
+// NewRouteFulfillOptions returns a new RouteFulfillOptions.
+func NewRouteFulfillOptions() *RouteFulfillOptions {
+	return &RouteFulfillOptions{
+		Status: 200,
+	}
+}
+
+// Parse parses the route fulfill options.
+func (o *RouteFulfillOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	obj := opts.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "status":
+			o.Status = v.ToInteger()
+		case "headers":
+			if err := rt.ExportTo(v, &o.Headers); err != nil {
+				return fmt.Errorf("parsing headers: %w", err)
+			}
+		case "contentType":
+			o.ContentType = v.String()
+		case "body":
+			b, err := bytesFromValue(v)
+			if err != nil {
+				return fmt.Errorf("parsing body: %w", err)
+			}
+			o.Body = b
+		}
+	}
+
+	return nil
+}
+
+// RouteFromHAROptions are the options of routeFromHAR.
+type RouteFromHAROptions struct {
+	URL      *URLMatcher       `js:"url"`
+	NotFound HARNotFoundPolicy `js:"notFound"`
+	Update   bool              `js:"update"`
+}
+
+// NewRouteFromHAROptions returns a new RouteFromHAROptions that serves
+// all the requests and aborts the ones that are not in the HAR.
+func NewRouteFromHAROptions() *RouteFromHAROptions {
+	m, _ := NewGlobURLMatcher("**")
+	return &RouteFromHAROptions{
+		URL:      m,
+		NotFound: HARNotFoundAbort,
+	}
+}
+
+// Parse parses the routeFromHAR options.
+func (o *RouteFromHAROptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	rt := k6ext.Runtime(ctx)
+	obj := opts.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "url":
+			m, err := NewURLMatcher(ctx, v)
+			if err != nil {
+				return fmt.Errorf("parsing url: %w", err)
+			}
+			o.URL = m
+		case "notFound":
+			switch p := HARNotFoundPolicy(v.String()); p {
+			case HARNotFoundAbort, HARNotFoundFallback:
+				o.NotFound = p
+			default:
+				return fmt.Errorf("invalid notFound %q, must be either 'abort' or 'fallback'", p)
+			}
+		case "update":
+			o.Update = v.ToBoolean()
+		}
+	}
+
+	return nil
+}
+
+// bytesFromValue returns the bytes of a string or an ArrayBuffer value.
+func bytesFromValue(v sobek.Value) ([]byte, error) {
+	if !sobekValueExists(v) {
+		return nil, nil
+	}
+	switch b := v.Export().(type) {
+	case string:
+		return []byte(b), nil
+	case []byte:
+		return b, nil
+	case sobek.ArrayBuffer:
+		return b.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T, must be a string or an ArrayBuffer", b)
+	}
+}
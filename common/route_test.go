@@ -0,0 +1,194 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/xk6-browser/k6ext/k6test"
+	"github.com/grafana/xk6-browser/log"
+)
+
+func TestGlobURLMatcher(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		glob  string
+		url   string
+		match bool
+	}{
+		{glob: "**/*.png", url: "https://example.com/img/logo.png", match: true},
+		{glob: "**/*.png", url: "https://example.com/img/logo.jpg", match: false},
+		{glob: "https://example.com/*", url: "https://example.com/api", match: true},
+		{glob: "https://example.com/*", url: "https://example.com/api/users", match: false},
+		{glob: "https://example.com/**", url: "https://example.com/api/users", match: true},
+		{glob: "**/api/user?", url: "https://example.com/api/users", match: true},
+		{glob: "**/*.{png,jpg}", url: "https://example.com/logo.jpg", match: true},
+		{glob: "**/*.{png,jpg}", url: "https://example.com/logo.gif", match: false},
+		{glob: "https://example.com/a+b", url: "https://example.com/a+b", match: true},
+		{glob: "https://example.com/a+b", url: "https://example.com/aab", match: false},
+		{glob: `**/\*`, url: "https://example.com/*", match: true},
+		{glob: `**/\*`, url: "https://example.com/a", match: false},
+	}
+	for _, tt := range tests {
+		m, err := NewGlobURLMatcher(tt.glob)
+		require.NoError(t, err)
+		assert.Equalf(t, tt.match, m.Match(tt.url), "glob %q, url %q", tt.glob, tt.url)
+	}
+}
+
+func TestRegExpURLMatcher(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewRegExpURLMatcher(`\.PNG$`, "i")
+	require.NoError(t, err)
+	assert.True(t, m.Match("https://example.com/logo.png"))
+	assert.False(t, m.Match("https://example.com/logo.jpg"))
+	assert.Equal(t, `/\.PNG$/i`, m.String())
+
+	_, err = NewRegExpURLMatcher(`(?<=a)b`, "")
+	require.Error(t, err)
+}
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		r      routes
+		called string
+	)
+	glob, err := NewGlobURLMatcher("**/*.png")
+	require.NoError(t, err)
+	all, err := NewGlobURLMatcher("**")
+	require.NoError(t, err)
+
+	r.add(glob, func(*Route) { called = "glob" })
+	r.add(all, func(*Route) { called = "any" })
+	require.Equal(t, 2, r.len())
+
+	// the most recently added route takes precedence.
+	r.match("https://example.com/logo.png")(nil)
+	assert.Equal(t, "any", called)
+
+	r.remove(all)
+	require.Equal(t, 1, r.len())
+	r.match("https://example.com/logo.png")(nil)
+	assert.Equal(t, "glob", called)
+	assert.Nil(t, r.match("https://example.com/logo.jpg"))
+}
+
+func TestRouteHandlerFailed(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("https://example.com/")
+	require.NoError(t, err)
+	newTestRoute := func() (*Route, *fakeSession) {
+		s := &fakeSession{session: &Session{id: "1234"}}
+		vu := k6test.NewVU(t)
+		return newRoute(vu.Context(), log.NewNullLogger(), s, "1", &Request{url: u}), s
+	}
+
+	t.Run("unhandled", func(t *testing.T) {
+		t.Parallel()
+
+		r, s := newTestRoute()
+		r.HandlerFailed(errors.New("handler failed"))
+		assert.Equal(t, []string{"Fetch.failRequest"}, s.cdpCalls)
+	})
+
+	t.Run("handled", func(t *testing.T) {
+		t.Parallel()
+
+		r, s := newTestRoute()
+		require.NoError(t, r.Continue(NewRouteContinueOptions()))
+		r.HandlerFailed(errors.New("handler failed"))
+		assert.Equal(t, []string{"Fetch.continueRequest"}, s.cdpCalls)
+	})
+}
+
+func TestRouteContinueByDefault(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("https://example.com/")
+	require.NoError(t, err)
+	newTestRoute := func() (*Route, *notifyingSession) {
+		s := &notifyingSession{session: &Session{id: "1234"}, cdpCalls: make(chan string, 2)}
+		vu := k6test.NewVU(t)
+		return newRoute(vu.Context(), log.NewNullLogger(), s, "1", &Request{url: u}), s
+	}
+
+	t.Run("not_handled_in_time", func(t *testing.T) {
+		t.Parallel()
+
+		r, s := newTestRoute()
+		r.continueByDefaultAfter(time.Millisecond)
+		assert.Equal(t, "Fetch.continueRequest", <-s.cdpCalls)
+		assert.ErrorIs(t, r.Abort(""), ErrRouteAlreadyHandled)
+	})
+
+	t.Run("handled", func(t *testing.T) {
+		t.Parallel()
+
+		r, s := newTestRoute()
+		r.continueByDefaultAfter(10 * time.Millisecond)
+		require.NoError(t, r.Fulfill(NewRouteFulfillOptions()))
+		assert.Equal(t, "Fetch.fulfillRequest", <-s.cdpCalls)
+		select {
+		case call := <-s.cdpCalls:
+			t.Errorf("unexpected call %q after the route is handled", call)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestRouteContinueHeaders(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("https://example.com/")
+	require.NoError(t, err)
+	newTestRoute := func(traceContext map[string]string) *Route {
+		req := &Request{url: u, headers: map[string][]string{
+			"Accept":      {"text/html"},
+			"Traceparent": {"00-page"},
+		}}
+		r := newRoute(context.Background(), log.NewNullLogger(), nil, "1", req)
+		r.traceContext = traceContext
+		return r
+	}
+	tc := map[string]string{"traceparent": "00-k6", "baggage": "tenant=k6"}
+
+	t.Run("no_trace_context", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRoute(nil)
+		assert.Nil(t, r.continueHeaders(nil))
+		assert.Equal(t, map[string]string{"x-test": "1"}, r.continueHeaders(map[string]string{"x-test": "1"}))
+	})
+
+	t.Run("request_headers", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRoute(tc)
+		assert.Equal(t, map[string]string{
+			"Accept":      "text/html",
+			"traceparent": "00-k6",
+			"baggage":     "tenant=k6",
+		}, r.continueHeaders(nil))
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		t.Parallel()
+
+		r := newTestRoute(tc)
+		assert.Equal(t, map[string]string{
+			"x-test":      "1",
+			"Baggage":     "tenant=other",
+			"traceparent": "00-k6",
+		}, r.continueHeaders(map[string]string{"x-test": "1", "Baggage": "tenant=other"}))
+	})
+}
@@ -0,0 +1,163 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/performance"
+	k6metrics "go.k6.io/k6/metrics"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// runtimeMetricNames are the Chromium runtime metrics that Page.Metrics
+// returns. Chromium reports more metrics, but the others are either
+// internal or change between versions.
+var runtimeMetricNames = map[string]bool{ //nolint:gochecknoglobals
+	"Timestamp":           true,
+	"Documents":           true,
+	"Frames":              true,
+	"JSEventListeners":    true,
+	"Nodes":               true,
+	"LayoutCount":         true,
+	"RecalcStyleCount":    true,
+	"LayoutDuration":      true,
+	"RecalcStyleDuration": true,
+	"ScriptDuration":      true,
+	"TaskDuration":        true,
+	"JSHeapUsedSize":      true,
+	"JSHeapTotalSize":     true,
+}
+
+// Metrics returns the Chromium runtime metrics of the page, such as the
+// JS heap size and the number of DOM nodes. The durations are the total
+// number of seconds spent since the page was created.
+func (p *Page) Metrics() (map[string]float64, error) {
+	p.logger.Debugf("Page:Metrics", "sid:%v", p.sessionID())
+	_, span := TraceAPICall(p.ctx, p.targetID.String(), "page.metrics")
+	defer span.End()
+
+	m, err := p.runtimeMetrics()
+	if err != nil {
+		spanRecordError(span, err)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (p *Page) runtimeMetrics() (map[string]float64, error) {
+	if err := p.enablePerformance(); err != nil {
+		return nil, err
+	}
+
+	metrics, err := performance.GetMetrics().Do(cdp.WithExecutor(p.ctx, p.session))
+	if err != nil {
+		return nil, fmt.Errorf("getting page metrics: %w", err)
+	}
+
+	m := make(map[string]float64, len(runtimeMetricNames))
+	for _, metric := range metrics {
+		if runtimeMetricNames[metric.Name] {
+			m[metric.Name] = metric.Value
+		}
+	}
+
+	return m, nil
+}
+
+// enablePerformance enables the collection of the runtime metrics the
+// first time they are needed, as it has a small overhead on the page.
+func (p *Page) enablePerformance() error {
+	p.performanceMu.Lock()
+	defer p.performanceMu.Unlock()
+
+	if p.performanceEnabled {
+		return nil
+	}
+	if err := performance.Enable().Do(cdp.WithExecutor(p.ctx, p.session)); err != nil {
+		return fmt.Errorf("enabling page metrics: %w", err)
+	}
+	p.performanceEnabled = true
+
+	return nil
+}
+
+// sampleRuntimeMetrics pushes the runtime metrics of the page as k6
+// metrics if the browser context samples them. The samples are pushed
+// until ctx is done.
+func (p *Page) sampleRuntimeMetrics(ctx context.Context) {
+	if !p.browserCtx.opts.RuntimeMetrics || p.backgroundPage {
+		return
+	}
+
+	m, err := p.runtimeMetrics()
+	if err != nil {
+		p.logger.Warnf("Page:sampleRuntimeMetrics", "sid:%v err:%v", p.sessionID(), err)
+		return
+	}
+	p.emitRuntimeMetrics(ctx, m)
+}
+
+func (p *Page) emitRuntimeMetrics(ctx context.Context, m map[string]float64) {
+	state := p.vu.State()
+	if state == nil {
+		return
+	}
+	tags := state.Tags.GetCurrentValues().Tags
+	if state.Options.SystemTags.Has(k6metrics.TagURL) {
+		tags = tags.With("url", p.MainFrame().URL())
+	}
+
+	k6metrics.PushIfNotDone(ctx, state.Samples, k6metrics.ConnectedSamples{
+		Samples: runtimeMetricSamples(k6ext.GetCustomMetrics(p.ctx), m, tags, time.Now()),
+	})
+}
+
+// runtimeMetricSamples converts the runtime metrics of a page to k6
+// samples. The durations are reported in seconds by Chromium, while k6
+// expects the time metrics in milliseconds.
+func runtimeMetricSamples(
+	cm *k6ext.CustomMetrics, m map[string]float64, tags *k6metrics.TagSet, now time.Time,
+) []k6metrics.Sample {
+	samples := make([]k6metrics.Sample, 0, len(cm.RuntimeMetrics))
+	for name, metric := range cm.RuntimeMetrics {
+		v, ok := m[name]
+		if !ok {
+			continue
+		}
+		if strings.HasSuffix(name, "Duration") {
+			v *= 1000
+		}
+		samples = append(samples, k6metrics.Sample{
+			TimeSeries: k6metrics.TimeSeries{Metric: metric, Tags: tags},
+			Value:      v,
+			Time:       now,
+		})
+	}
+
+	return samples
+}
+
+// SampleRuntimeMetrics pushes the runtime metrics of the open pages as
+// k6 metrics if the browser context was created with the runtimeMetrics
+// option. It's called at the end of each iteration, once k6 has already
+// cancelled the context of the iteration, so the samples are pushed
+// until the given ctx is done instead.
+func (b *Browser) SampleRuntimeMetrics(ctx context.Context) {
+	b.contextMu.RLock()
+	bctx := b.context
+	b.contextMu.RUnlock()
+
+	if bctx == nil || !bctx.opts.RuntimeMetrics {
+		return
+	}
+	for _, p := range bctx.Pages() {
+		if !p.IsClosed() {
+			p.sampleRuntimeMetrics(ctx)
+		}
+	}
+}
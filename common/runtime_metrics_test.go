@@ -0,0 +1,42 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k6metrics "go.k6.io/k6/metrics"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+func TestRuntimeMetricSamples(t *testing.T) {
+	t.Parallel()
+
+	registry := k6metrics.NewRegistry()
+	cm := k6ext.RegisterCustomMetrics(registry)
+	tags := registry.RootTagSet().With("url", "https://test.k6.io")
+
+	samples := runtimeMetricSamples(cm, map[string]float64{
+		"Timestamp":      1234.5,
+		"JSHeapUsedSize": 1024,
+		"Nodes":          42,
+		"ScriptDuration": 0.25,
+	}, tags, time.Now())
+	require.Len(t, samples, 3)
+
+	got := make(map[string]float64)
+	for _, s := range samples {
+		got[s.Metric.Name] = s.Value
+		assert.Equal(t, k6metrics.Trend, s.Metric.Type)
+		v, ok := s.Tags.Get("url")
+		assert.True(t, ok)
+		assert.Equal(t, "https://test.k6.io", v)
+	}
+	assert.Equal(t, map[string]float64{
+		"browser_js_heap_used":    1024,
+		"browser_dom_nodes":       42,
+		"browser_script_duration": 250,
+	}, got)
+}
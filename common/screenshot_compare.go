@@ -0,0 +1,321 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	k6metrics "go.k6.io/k6/metrics"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// compareScreenshotAttr marks the elements that are added to a page while
+// a screenshot is compared, so that they can be removed afterwards.
+const compareScreenshotAttr = "data-k6-compare-screenshot"
+
+// prepareCompareScreenshotScript disables the animations, hides the caret
+// and covers the masked elements of a page before a screenshot is taken.
+const prepareCompareScreenshotScript = `(opts) => {
+	const attr = '` + compareScreenshotAttr + `';
+	let css = '';
+	if (opts.disableAnimations) {
+		css += '*, *::before, *::after { animation: none !important; transition: none !important; }';
+	}
+	if (opts.hideCaret) {
+		css += '* { caret-color: transparent !important; }';
+	}
+	const style = document.createElement('style');
+	style.setAttribute(attr, '');
+	style.textContent = css;
+	(document.head || document.documentElement).appendChild(style);
+
+	for (const selector of opts.mask) {
+		for (const el of document.querySelectorAll(selector)) {
+			const r = el.getBoundingClientRect();
+			const mask = document.createElement('div');
+			mask.setAttribute(attr, '');
+			Object.assign(mask.style, {
+				position: 'absolute',
+				left: (r.left + window.scrollX) + 'px',
+				top: (r.top + window.scrollY) + 'px',
+				width: r.width + 'px',
+				height: r.height + 'px',
+				background: opts.maskColor,
+				zIndex: '2147483647',
+				pointerEvents: 'none',
+			});
+			document.documentElement.appendChild(mask);
+		}
+	}
+}`
+
+// restoreCompareScreenshotTimeout is how long restoring the page after a
+// screenshot is compared can take. The restore has its own timeout, since
+// the screenshot might have used up the timeout of the comparison.
+const restoreCompareScreenshotTimeout = 5 * time.Second
+
+// restoreCompareScreenshotScript removes the elements that are added by
+// prepareCompareScreenshotScript.
+const restoreCompareScreenshotScript = `() => {
+	document.querySelectorAll('[` + compareScreenshotAttr + `]').forEach(el => el.remove());
+}`
+
+// persistedFileReader is implemented by the persisters that can read back
+// the files that they persist.
+type persistedFileReader interface {
+	ReadFile(ctx context.Context, path string) ([]byte, error)
+}
+
+// ScreenshotComparison is the result of comparing a screenshot with its
+// baseline.
+type ScreenshotComparison struct {
+	Name string `js:"name"`
+	// Passed is true if the screenshot matches the baseline.
+	Passed bool `js:"passed"`
+	// DiffPixels and DiffRatio are the number and the ratio of the pixels
+	// that are different from the baseline.
+	DiffPixels int64   `js:"diffPixels"`
+	DiffRatio  float64 `js:"diffRatio"`
+	// BaselinePath is the path of the baseline screenshot. The actual and
+	// diff paths are only set if the screenshot doesn't match the baseline.
+	BaselinePath string `js:"baselinePath"`
+	ActualPath   string `js:"actualPath"`
+	DiffPath     string `js:"diffPath"`
+	// Updated is true if the screenshot is written as the baseline, either
+	// because there was no baseline or because the baselines are updated.
+	Updated bool `js:"updated"`
+}
+
+// CompareScreenshot takes a screenshot of the page and compares it with
+// the baseline screenshot of the name.
+func (p *Page) CompareScreenshot(
+	name string, opts *CompareScreenshotOptions, sp ScreenshotPersister,
+) (*ScreenshotComparison, error) {
+	p.logger.Debugf("Page:CompareScreenshot", "sid:%v name:%q", p.sessionID(), name)
+
+	spanCtx, span := TraceAPICall(p.ctx, p.targetID.String(), "page.compareScreenshot")
+	defer span.End()
+
+	res, err := compareScreenshot(spanCtx, p.MainFrame(), name, opts, sp, func(s *screenshotter) ([]byte, error) {
+		return s.screenshotPage(p, &PageScreenshotOptions{
+			Format:   ImageFormatPNG,
+			FullPage: opts.FullPage,
+		})
+	})
+	if err != nil {
+		err := fmt.Errorf("comparing screenshot %q of page: %w", name, err)
+		spanRecordError(span, err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CompareScreenshot takes a screenshot of the element that matches the
+// locator's selector with strict mode on, and compares it with the baseline
+// screenshot of the name.
+func (l *Locator) CompareScreenshot(
+	name string, opts *CompareScreenshotOptions, sp ScreenshotPersister,
+) (*ScreenshotComparison, error) {
+	l.log.Debugf(
+		"Locator:CompareScreenshot", "fid:%s furl:%q sel:%q name:%q",
+		l.frame.ID(), l.frame.URL(), l.selector, name,
+	)
+
+	spanCtx, span := TraceAPICall(l.ctx, l.frame.page.targetID.String(), "locator.compareScreenshot")
+	defer span.End()
+
+	res, err := compareScreenshot(spanCtx, l.frame, name, opts, sp, func(s *screenshotter) ([]byte, error) {
+		h, err := l.frame.waitForSelector(l.selector, &FrameWaitForSelectorOptions{
+			State:   DOMElementStateVisible,
+			Strict:  true,
+			Timeout: opts.Timeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := h.Dispose(); err != nil {
+				l.log.Debugf("Locator:CompareScreenshot", "disposing element handle: %v", err)
+			}
+		}()
+
+		return s.screenshotElement(h, &ElementHandleScreenshotOptions{
+			Format:  ImageFormatPNG,
+			Timeout: opts.Timeout,
+		})
+	})
+	if err != nil {
+		err := fmt.Errorf("comparing screenshot %q of %q: %w", name, l.selector, err)
+		spanRecordError(span, err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// compareScreenshot prepares the frame for the screenshot, takes it with
+// the screenshot function, and compares it with the baseline.
+func compareScreenshot(
+	ctx context.Context,
+	f *Frame,
+	name string,
+	opts *CompareScreenshotOptions,
+	sp ScreenshotPersister,
+	screenshot func(*screenshotter) ([]byte, error),
+) (*ScreenshotComparison, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	evalCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	mask := opts.Mask
+	if mask == nil {
+		mask = []string{}
+	}
+	if _, err := f.EvaluateWithContext(evalCtx, prepareCompareScreenshotScript, map[string]any{
+		"disableAnimations": opts.Animations == ScreenshotAnimationsDisabled,
+		"hideCaret":         opts.Caret == ScreenshotCaretHide,
+		"mask":              mask,
+		"maskColor":         opts.MaskColor,
+	}); err != nil {
+		return nil, fmt.Errorf("preparing page: %w", err)
+	}
+	defer func() {
+		restoreCtx, cancel := context.WithTimeout(ctx, restoreCompareScreenshotTimeout)
+		defer cancel()
+		if _, err := f.EvaluateWithContext(restoreCtx, restoreCompareScreenshotScript); err != nil {
+			f.log.Debugf("compareScreenshot", "fid:%s restoring page: %v", f.ID(), err)
+		}
+	}()
+
+	actual, err := screenshot(newScreenshotter(ctx, sp))
+	if err != nil {
+		return nil, err
+	}
+	res, err := compareWithBaseline(ctx, name, actual, opts, sp)
+	if err != nil {
+		return nil, err
+	}
+	f.page.emitVisualDiffRatio(name, res.DiffRatio)
+
+	return res, nil
+}
+
+// compareWithBaseline compares the actual PNG screenshot with the baseline
+// of the name, and persists the actual and diff images on mismatch. The
+// actual screenshot is persisted as the baseline if there is no baseline
+// or if the baselines are updated.
+//
+// The baselines are read and written with the screenshot persister, which
+// must be able to read back the files, such as the local file persister.
+func compareWithBaseline(
+	ctx context.Context, name string, actual []byte, opts *CompareScreenshotOptions, sp ScreenshotPersister,
+) (*ScreenshotComparison, error) {
+	fr, ok := sp.(persistedFileReader)
+	if !ok {
+		return nil, errors.New("baseline screenshots can only be compared when the screenshots are stored locally")
+	}
+	base := filepath.Join(opts.BaselineDir, strings.TrimSuffix(name, ".png"))
+	res := &ScreenshotComparison{
+		Name:         name,
+		BaselinePath: base + ".png",
+	}
+
+	baseline, err := fr.ReadFile(ctx, res.BaselinePath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && opts.UpdateBaselines) {
+		if err := sp.Persist(ctx, res.BaselinePath, bytes.NewReader(actual)); err != nil {
+			return nil, fmt.Errorf("persisting baseline screenshot: %w", err)
+		}
+		res.Passed = true
+		res.Updated = true
+		return res, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline screenshot: %w", err)
+	}
+
+	baselineImg, err := png.Decode(bytes.NewReader(baseline))
+	if err != nil {
+		return nil, fmt.Errorf("decoding baseline screenshot %q: %w", res.BaselinePath, err)
+	}
+	actualImg, err := png.Decode(bytes.NewReader(actual))
+	if err != nil {
+		return nil, fmt.Errorf("decoding screenshot: %w", err)
+	}
+
+	var diff *imageDiff
+	if baselineImg.Bounds().Size() == actualImg.Bounds().Size() {
+		diff = diffImages(baselineImg, actualImg, opts.Threshold, opts.IncludeAA)
+		size := actualImg.Bounds().Size()
+		res.DiffPixels = int64(diff.Pixels)
+		res.DiffRatio = float64(diff.Pixels) / float64(size.X*size.Y)
+		// The screenshots match if the different pixels are within either
+		// of the limits.
+		res.Passed = res.DiffPixels <= opts.MaxDiffPixels || res.DiffRatio <= opts.MaxDiffPixelRatio
+	} else {
+		// The screenshots of different sizes can't be compared pixel by pixel.
+		size := actualImg.Bounds().Size()
+		res.DiffPixels = int64(size.X * size.Y)
+		res.DiffRatio = 1
+	}
+	if res.Passed {
+		return res, nil
+	}
+
+	res.ActualPath = base + "-actual.png"
+	if err := sp.Persist(ctx, res.ActualPath, bytes.NewReader(actual)); err != nil {
+		return nil, fmt.Errorf("persisting actual screenshot: %w", err)
+	}
+	if diff == nil {
+		return res, nil
+	}
+	res.DiffPath = base + "-diff.png"
+	if err := persistPNG(ctx, sp, res.DiffPath, diff.Image); err != nil {
+		return nil, fmt.Errorf("persisting diff image: %w", err)
+	}
+
+	return res, nil
+}
+
+func persistPNG(ctx context.Context, sp ScreenshotPersister, path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("encoding %q: %w", path, err)
+	}
+
+	return sp.Persist(ctx, path, &buf) //nolint:wrapcheck
+}
+
+func (p *Page) emitVisualDiffRatio(name string, ratio float64) {
+	state := p.vu.State()
+	if state == nil {
+		return
+	}
+	tags := state.Tags.GetCurrentValues().Tags.With("screenshot", name)
+	if state.Options.SystemTags.Has(k6metrics.TagURL) {
+		tags = tags.With("url", p.MainFrame().URL())
+	}
+
+	k6metrics.PushIfNotDone(p.vu.Context(), state.Samples, k6metrics.ConnectedSamples{
+		Samples: []k6metrics.Sample{
+			{
+				TimeSeries: k6metrics.TimeSeries{
+					Metric: k6ext.GetCustomMetrics(p.ctx).BrowserVisualDiffRatio,
+					Tags:   tags,
+				},
+				Value: ratio,
+				Time:  time.Now(),
+			},
+		},
+	})
+}
@@ -0,0 +1,163 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareWithBaseline(t *testing.T) {
+	t.Parallel()
+
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	encode := func(t *testing.T, img image.Image) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, img))
+		return buf.Bytes()
+	}
+	setup := func(t *testing.T, baseline image.Image) (*CompareScreenshotOptions, filesPersister) {
+		t.Helper()
+		opts := NewCompareScreenshotOptions(0)
+		opts.BaselineDir = "baselines"
+		sp := filesPersister{}
+		if baseline != nil {
+			sp[filepath.Join(opts.BaselineDir, "home.png")] = encode(t, baseline)
+		}
+		return opts, sp
+	}
+
+	t.Run("no_baseline", func(t *testing.T) {
+		t.Parallel()
+
+		opts, sp := setup(t, nil)
+		actual := encode(t, newFilledImage(4, 4, white))
+		res, err := compareWithBaseline(context.Background(), "home", actual, opts, sp)
+		require.NoError(t, err)
+
+		assert.True(t, res.Passed)
+		assert.True(t, res.Updated)
+		assert.Equal(t, filepath.Join(opts.BaselineDir, "home.png"), res.BaselinePath)
+		assert.Equal(t, actual, sp[res.BaselinePath])
+	})
+
+	t.Run("match", func(t *testing.T) {
+		t.Parallel()
+
+		opts, sp := setup(t, newFilledImage(4, 4, white))
+		res, err := compareWithBaseline(
+			context.Background(), "home", encode(t, newFilledImage(4, 4, white)), opts, sp,
+		)
+		require.NoError(t, err)
+
+		assert.True(t, res.Passed)
+		assert.False(t, res.Updated)
+		assert.Zero(t, res.DiffRatio)
+		assert.Len(t, sp, 1)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		opts, sp := setup(t, newFilledImage(4, 4, white))
+		img := newFilledImage(4, 4, white)
+		img.SetNRGBA(0, 0, color.NRGBA{A: 255})
+		res, err := compareWithBaseline(context.Background(), "home", encode(t, img), opts, sp)
+		require.NoError(t, err)
+
+		assert.False(t, res.Passed)
+		assert.Equal(t, int64(1), res.DiffPixels)
+		assert.Equal(t, 1.0/16, res.DiffRatio)
+		assert.Equal(t, filepath.Join(opts.BaselineDir, "home-actual.png"), res.ActualPath)
+		assert.Equal(t, filepath.Join(opts.BaselineDir, "home-diff.png"), res.DiffPath)
+		assert.Contains(t, sp, res.ActualPath)
+		diff, err := png.Decode(bytes.NewReader(sp[res.DiffPath]))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 4, 4), diff.Bounds())
+	})
+
+	t.Run("max_diff_pixels", func(t *testing.T) {
+		t.Parallel()
+
+		opts, sp := setup(t, newFilledImage(4, 4, white))
+		opts.MaxDiffPixels = 1
+		img := newFilledImage(4, 4, white)
+		img.SetNRGBA(0, 0, color.NRGBA{A: 255})
+		res, err := compareWithBaseline(context.Background(), "home", encode(t, img), opts, sp)
+		require.NoError(t, err)
+
+		assert.True(t, res.Passed)
+		assert.Equal(t, int64(1), res.DiffPixels)
+	})
+
+	t.Run("size_mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		opts, sp := setup(t, newFilledImage(4, 4, white))
+		res, err := compareWithBaseline(
+			context.Background(), "home", encode(t, newFilledImage(4, 5, white)), opts, sp,
+		)
+		require.NoError(t, err)
+
+		assert.False(t, res.Passed)
+		assert.Equal(t, 1.0, res.DiffRatio)
+		assert.NotEmpty(t, res.ActualPath)
+		assert.Empty(t, res.DiffPath)
+	})
+
+	t.Run("update_baselines", func(t *testing.T) {
+		t.Parallel()
+
+		opts, sp := setup(t, newFilledImage(4, 4, white))
+		opts.UpdateBaselines = true
+		actual := encode(t, newFilledImage(4, 5, white))
+		res, err := compareWithBaseline(context.Background(), "home", actual, opts, sp)
+		require.NoError(t, err)
+
+		assert.True(t, res.Passed)
+		assert.True(t, res.Updated)
+		assert.Equal(t, actual, sp[res.BaselinePath])
+	})
+
+	t.Run("err/remote_persister", func(t *testing.T) {
+		t.Parallel()
+
+		opts, _ := setup(t, nil)
+		_, err := compareWithBaseline(
+			context.Background(), "home", encode(t, newFilledImage(4, 4, white)), opts, writeOnlyPersister{},
+		)
+		assert.ErrorContains(t, err, "only be compared when the screenshots are stored locally")
+	})
+}
+
+// filesPersister keeps the persisted files in memory by their paths.
+type filesPersister map[string][]byte
+
+func (f filesPersister) Persist(_ context.Context, path string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	f[path] = b
+	return err
+}
+
+func (f filesPersister) ReadFile(_ context.Context, path string) ([]byte, error) {
+	b, ok := f[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return b, nil
+}
+
+// writeOnlyPersister can't read back the persisted files, like the remote
+// file persister.
+type writeOnlyPersister struct{}
+
+func (writeOnlyPersister) Persist(context.Context, string, io.Reader) error { return nil }
@@ -3,7 +3,9 @@ package common
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 
 	browsertrace "github.com/grafana/xk6-browser/trace"
@@ -20,18 +22,24 @@ type Tracer interface {
 	TraceEvent(
 		ctx context.Context, targetID string, eventName string, spanID string, opts ...trace.SpanStartOption,
 	) (context.Context, trace.Span)
+	TraceRequest(
+		ctx context.Context, targetID string, opts ...trace.SpanStartOption,
+	) (context.Context, trace.Span)
+	ActiveSpanContext(targetID string) trace.SpanContext
 }
 
 // TraceAPICall is a helper method that retrieves the Tracer from the given ctx and
 // calls its TraceAPICall implementation. If the Tracer is not present in the given
-// ctx, it returns a noopSpan and the given context.
+// ctx, it returns a noopSpan and the given context. The call is also recorded as
+// an action if the browser context of the page is being traced.
 func TraceAPICall(
 	ctx context.Context, targetID string, spanName string, opts ...trace.SpanStartOption,
 ) (context.Context, trace.Span) {
+	var span trace.Span = browsertrace.NoopSpan{}
 	if tracer := GetTracer(ctx); tracer != nil {
-		return tracer.TraceAPICall(ctx, targetID, spanName, opts...)
+		ctx, span = tracer.TraceAPICall(ctx, targetID, spanName, opts...)
 	}
-	return ctx, browsertrace.NoopSpan{}
+	return ctx, traceAction(ctx, targetID, spanName, span, opts)
 }
 
 // TraceNavigation is a helper method that retrieves the Tracer from the given ctx and
@@ -58,6 +66,42 @@ func TraceEvent(
 	return ctx, browsertrace.NoopSpan{}
 }
 
+// TraceRequest is a helper method that retrieves the Tracer from the given ctx and
+// calls its TraceRequest implementation. If the Tracer is not present in the given
+// ctx, it returns a noopSpan and the given context.
+func TraceRequest(
+	ctx context.Context, targetID string, opts ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	if tracer := GetTracer(ctx); tracer != nil {
+		return tracer.TraceRequest(ctx, targetID, opts...)
+	}
+	return ctx, browsertrace.NoopSpan{}
+}
+
+// traceContextHeaders returns the W3C trace context headers, traceparent
+// and tracestate, of the active span for the given targetID, along with
+// the baggage header if the baggage is not empty. It returns nil if the
+// Tracer is not present in the given ctx or there is no active span.
+func traceContextHeaders(ctx context.Context, targetID string, b baggage.Baggage) map[string]string {
+	tracer := GetTracer(ctx)
+	if tracer == nil {
+		return nil
+	}
+	sc := tracer.ActiveSpanContext(targetID)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	ctx = trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = baggage.ContextWithBaggage(ctx, b)
+	headers := propagation.MapCarrier{}
+	propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	).Inject(ctx, headers)
+
+	return headers
+}
+
 // spanRecordError will set the status of the span to error and record the
 // error on the span. Check the documentation for trace.SetStatus and
 // trace.RecordError for more details.
@@ -0,0 +1,713 @@
+package common
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdppage "github.com/chromedp/cdproto/page"
+	cdpruntime "github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+	"github.com/grafana/sobek"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/xk6-browser/common/js"
+	"github.com/grafana/xk6-browser/k6ext"
+	"github.com/grafana/xk6-browser/log"
+)
+
+// traceArchiveVersion is the version of the Playwright trace format that
+// the trace archives are recorded in.
+const traceArchiveVersion = 6
+
+// traceSnapshotTimeout is how long taking a snapshot of a page can take,
+// since a page might not respond, e.g. while it shows a dialog.
+const traceSnapshotTimeout = 5 * time.Second
+
+// traceSnapshotActions are the API calls that change the page, which are
+// the only ones that the snapshots of the page are taken before and after,
+// since taking them delays the call.
+var traceSnapshotActions = map[string]bool{ //nolint:gochecknoglobals
+	"locator.click":          true,
+	"locator.type":           true,
+	"page.close":             true,
+	"page.goto":              true,
+	"page.reload":            true,
+	"page.waitForNavigation": true,
+}
+
+// Tracing records a trace archive of the actions in a browser context,
+// which can be opened in the Playwright trace viewer. Each BrowserContext
+// has a publicly accessible Tracing.
+type Tracing struct {
+	ctx    context.Context
+	logger *log.Logger
+
+	mu  sync.Mutex
+	rec *traceRecorder
+}
+
+// NewTracing returns a new Tracing for the browser context.
+func NewTracing(ctx context.Context, logger *log.Logger) *Tracing {
+	return &Tracing{
+		ctx:    ctx,
+		logger: logger,
+	}
+}
+
+// Start starts recording the trace archive.
+func (t *Tracing) Start(opts *TracingStartOptions) error {
+	t.logger.Debugf("Tracing:Start", "screenshots:%t snapshots:%t sources:%t",
+		opts.Screenshots, opts.Snapshots, opts.Sources)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rec != nil {
+		return errors.New("starting tracing: tracing is already started")
+	}
+	t.rec = newTraceRecorder(opts)
+	// The call stacks of the actions are only available for the async
+	// API, since its mappings capture the stack of each call on the event
+	// loop before the call leaves it.
+	if opts.Sources {
+		t.rec.stopCallStacks = k6ext.GetCallStacks(t.ctx).Record()
+	}
+
+	return nil
+}
+
+// Stop stops recording the trace archive, and saves it if the path is set.
+func (t *Tracing) Stop(opts *TracingStopOptions, sp ScreenshotPersister) error {
+	t.logger.Debugf("Tracing:Stop", "path:%q", opts.Path)
+
+	t.mu.Lock()
+	rec := t.rec
+	t.rec = nil
+	t.mu.Unlock()
+	if rec == nil {
+		return errors.New("stopping tracing: tracing is not started")
+	}
+	if rec.stopCallStacks != nil {
+		rec.stopCallStacks()
+	}
+	if opts.Path == "" {
+		return nil
+	}
+
+	data, err := rec.archive()
+	if err != nil {
+		return fmt.Errorf("stopping tracing: %w", err)
+	}
+	if err := sp.Persist(t.ctx, opts.Path, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("persisting trace archive to %q: %w", opts.Path, err)
+	}
+
+	return nil
+}
+
+// recorder returns the recorder of the trace archive, or nil if tracing
+// is not started.
+func (t *Tracing) recorder() *traceRecorder {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.rec
+}
+
+// traceAction records the API call of the page with the targetID as an
+// action of the trace archive of its browser context, if the browser
+// context is being traced. The action has the JS call stack that is
+// captured for the call, if any. It returns a span that ends the action
+// when the API call ends.
+func traceAction(
+	ctx context.Context, targetID string, spanName string, span trace.Span, opts []trace.SpanStartOption,
+) trace.Span {
+	if targetID == "" {
+		return span
+	}
+	// The stack is taken even if the call is not recorded, so that it's
+	// not taken by a later call.
+	frames := k6ext.GetCallStacks(ctx).Take(spanName)
+	b := getBrowser(ctx)
+	if b == nil {
+		return span
+	}
+	b.pagesMu.RLock()
+	p := b.pages[target.ID(targetID)]
+	b.pagesMu.RUnlock()
+	if p == nil || p.browserCtx == nil {
+		return span
+	}
+	rec := p.browserCtx.tracing.recorder()
+	if rec == nil {
+		return span
+	}
+
+	cfg := trace.NewSpanStartConfig(opts...)
+
+	return &actionSpan{
+		Span:   span,
+		rec:    rec,
+		action: rec.startAction(p, spanName, cfg.Attributes(), frames),
+	}
+}
+
+// actionSpan is the span of an API call that is recorded as an action.
+type actionSpan struct {
+	trace.Span
+
+	rec    *traceRecorder
+	action *tracedAction
+	err    string
+}
+
+// SetStatus records the error of the action.
+func (s *actionSpan) SetStatus(code codes.Code, description string) {
+	if code == codes.Error {
+		s.err = description
+	}
+	s.Span.SetStatus(code, description)
+}
+
+// End ends the action and the span.
+func (s *actionSpan) End(options ...trace.SpanEndOption) {
+	s.rec.endAction(s.action, s.err)
+	s.Span.End(options...)
+}
+
+// tracedAction is an API call that is recorded in the trace archive.
+type tracedAction struct {
+	page      *Page
+	callID    string
+	snapshots bool
+}
+
+// traceRecorder records the events of a trace archive.
+type traceRecorder struct {
+	opts           *TracingStartOptions
+	start          time.Time
+	har            *harRecorder
+	stopCallStacks func()
+
+	mu        sync.Mutex
+	events    []any
+	resources map[string][]byte
+	pageIDs   map[target.ID]string
+	calls     int
+	files     []string
+	fileIDs   map[string]int
+	stacks    []any
+}
+
+func newTraceRecorder(opts *TracingStartOptions) *traceRecorder {
+	return &traceRecorder{
+		opts:      opts,
+		start:     time.Now(),
+		har:       newHARRecorder(&RecordHAROptions{Content: HARContentAttach}),
+		resources: make(map[string][]byte),
+		pageIDs:   make(map[target.ID]string),
+		files:     []string{},
+		fileIDs:   make(map[string]int),
+		stacks:    []any{},
+	}
+}
+
+// The trace events are the events of the Playwright trace format. The
+// times are the milliseconds since the tracing started.
+type (
+	traceContextOptionsEvent struct {
+		Version       int            `json:"version"`
+		Type          string         `json:"type"`
+		Origin        string         `json:"origin"`
+		BrowserName   string         `json:"browserName"`
+		Platform      string         `json:"platform"`
+		WallTime      float64        `json:"wallTime"`
+		MonotonicTime float64        `json:"monotonicTime"`
+		SDKLanguage   string         `json:"sdkLanguage"`
+		Options       map[string]any `json:"options"`
+	}
+
+	traceBeforeEvent struct {
+		Type           string         `json:"type"`
+		CallID         string         `json:"callId"`
+		StartTime      float64        `json:"startTime"`
+		APIName        string         `json:"apiName"`
+		Class          string         `json:"class"`
+		Method         string         `json:"method"`
+		Params         map[string]any `json:"params"`
+		PageID         string         `json:"pageId,omitempty"`
+		BeforeSnapshot string         `json:"beforeSnapshot,omitempty"`
+	}
+
+	traceAfterEvent struct {
+		Type          string      `json:"type"`
+		CallID        string      `json:"callId"`
+		EndTime       float64     `json:"endTime"`
+		AfterSnapshot string      `json:"afterSnapshot,omitempty"`
+		Error         *traceError `json:"error,omitempty"`
+	}
+
+	traceError struct {
+		Message string `json:"message"`
+	}
+
+	traceFrameSnapshotEvent struct {
+		Type     string             `json:"type"`
+		Snapshot traceFrameSnapshot `json:"snapshot"`
+	}
+
+	traceFrameSnapshot struct {
+		SnapshotName      string          `json:"snapshotName"`
+		CallID            string          `json:"callId"`
+		PageID            string          `json:"pageId"`
+		FrameID           string          `json:"frameId"`
+		FrameURL          string          `json:"frameUrl"`
+		Timestamp         float64         `json:"timestamp"`
+		WallTime          float64         `json:"wallTime"`
+		CollectionTime    float64         `json:"collectionTime"`
+		Doctype           string          `json:"doctype,omitempty"`
+		HTML              json.RawMessage `json:"html"`
+		ResourceOverrides []struct{}      `json:"resourceOverrides"`
+		Viewport          traceViewport   `json:"viewport"`
+		IsMainFrame       bool            `json:"isMainFrame"`
+	}
+
+	traceViewport struct {
+		Width  int64 `json:"width"`
+		Height int64 `json:"height"`
+	}
+
+	traceScreencastFrameEvent struct {
+		Type      string  `json:"type"`
+		PageID    string  `json:"pageId"`
+		SHA1      string  `json:"sha1"`
+		Width     int     `json:"width"`
+		Height    int     `json:"height"`
+		Timestamp float64 `json:"timestamp"`
+	}
+
+	traceConsoleEvent struct {
+		Type        string        `json:"type"`
+		Time        float64       `json:"time"`
+		PageID      string        `json:"pageId"`
+		MessageType string        `json:"messageType"`
+		Text        string        `json:"text"`
+		Location    traceLocation `json:"location"`
+	}
+
+	traceLocation struct {
+		URL          string `json:"url"`
+		LineNumber   int64  `json:"lineNumber"`
+		ColumnNumber int64  `json:"columnNumber"`
+	}
+
+	traceResourceSnapshotEvent struct {
+		Type     string                `json:"type"`
+		Snapshot traceResourceSnapshot `json:"snapshot"`
+	}
+
+	// traceResourceSnapshot is a network request, which is a HAR entry
+	// whose response body is a resource of the trace archive.
+	traceResourceSnapshot struct {
+		HAREntry
+		MonotonicTime float64 `json:"_monotonicTime"`
+	}
+)
+
+// since returns the milliseconds since the tracing started.
+func (r *traceRecorder) since(t time.Time) float64 {
+	return float64(t.Sub(r.start).Microseconds()) / 1000
+}
+
+// pageID returns the ID of the page in the trace archive. It must be
+// called with the lock held.
+func (r *traceRecorder) pageID(tid target.ID) string {
+	id, ok := r.pageIDs[tid]
+	if !ok {
+		id = fmt.Sprintf("page@%d", len(r.pageIDs)+1)
+		r.pageIDs[tid] = id
+	}
+
+	return id
+}
+
+func (r *traceRecorder) addEvent(event any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+// startAction records the start of an API call, such as page.goto, with
+// the JS call stack of the call and, if the call changes the page, the
+// snapshots of the page before it.
+func (r *traceRecorder) startAction(
+	p *Page, apiName string, attrs []attribute.KeyValue, frames []sobek.StackFrame,
+) *tracedAction {
+	class, method, _ := strings.Cut(apiName, ".")
+	if class != "" {
+		class = strings.ToUpper(class[:1]) + class[1:]
+	}
+	// The attributes of the span, such as page.goto.url, are the
+	// parameters of the call.
+	params := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		key := string(a.Key)
+		params[key[strings.LastIndex(key, ".")+1:]] = a.Value.AsInterface()
+	}
+
+	r.mu.Lock()
+	r.calls++
+	a := &tracedAction{
+		page:      p,
+		callID:    fmt.Sprintf("call@%d", r.calls),
+		snapshots: traceSnapshotActions[apiName],
+	}
+	pageID := r.pageID(p.targetID)
+	r.mu.Unlock()
+
+	event := traceBeforeEvent{
+		Type:      "before",
+		CallID:    a.callID,
+		StartTime: r.since(time.Now()),
+		APIName:   apiName,
+		Class:     class,
+		Method:    method,
+		Params:    params,
+		PageID:    pageID,
+	}
+	if r.opts.Sources {
+		r.recordStack(a.callID, frames)
+	}
+	if a.snapshots {
+		event.BeforeSnapshot = r.snapshot(p, a.callID, "before@"+a.callID)
+	}
+	r.addEvent(event)
+
+	return a
+}
+
+// endAction records the end of an API call and, if the call changes the
+// page, the snapshots of the page after it.
+func (r *traceRecorder) endAction(a *tracedAction, errMessage string) {
+	event := traceAfterEvent{
+		Type:   "after",
+		CallID: a.callID,
+	}
+	if errMessage != "" {
+		event.Error = &traceError{Message: errMessage}
+	}
+	if a.snapshots && !a.page.IsClosed() {
+		event.AfterSnapshot = r.snapshot(a.page, a.callID, "after@"+a.callID)
+	}
+	event.EndTime = r.since(time.Now())
+	r.addEvent(event)
+}
+
+// snapshot records the DOM snapshot and the screenshot of the page, if
+// they're recorded. It returns the name of the DOM snapshot if it's
+// recorded.
+func (r *traceRecorder) snapshot(p *Page, callID, name string) string {
+	var snapshotName string
+	if r.opts.Snapshots {
+		if err := r.recordDOMSnapshot(p, callID, name); err != nil {
+			p.logger.Debugf("traceRecorder:snapshot", "sid:%v snapshot:%s err:%v", p.sessionID(), name, err)
+		} else {
+			snapshotName = name
+		}
+	}
+	if r.opts.Screenshots {
+		if err := r.recordScreenshot(p); err != nil {
+			p.logger.Debugf("traceRecorder:snapshot", "sid:%v screenshot err:%v", p.sessionID(), err)
+		}
+	}
+
+	return snapshotName
+}
+
+func (r *traceRecorder) recordDOMSnapshot(p *Page, callID, name string) error {
+	mf := p.MainFrame()
+	if mf == nil {
+		return errors.New("page has no main frame")
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, traceSnapshotTimeout)
+	defer cancel()
+
+	start := time.Now()
+	action := cdpruntime.Evaluate(js.TraceSnapshotScript).WithReturnByValue(true)
+	result, exception, err := action.Do(cdp.WithExecutor(ctx, p.session))
+	if err != nil {
+		return fmt.Errorf("taking DOM snapshot: %w", err)
+	}
+	if exception != nil {
+		return fmt.Errorf("taking DOM snapshot: %s", exception.Text)
+	}
+	var dom struct {
+		Doctype  string          `json:"doctype"`
+		HTML     json.RawMessage `json:"html"`
+		Viewport traceViewport   `json:"viewport"`
+		URL      string          `json:"url"`
+	}
+	if err := json.Unmarshal(result.Value, &dom); err != nil {
+		return fmt.Errorf("parsing DOM snapshot: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, traceFrameSnapshotEvent{
+		Type: "frame-snapshot",
+		Snapshot: traceFrameSnapshot{
+			SnapshotName:      name,
+			CallID:            callID,
+			PageID:            r.pageID(p.targetID),
+			FrameID:           mf.ID(),
+			FrameURL:          dom.URL,
+			Timestamp:         r.since(start),
+			WallTime:          float64(start.UnixMicro()) / 1000,
+			CollectionTime:    float64(time.Since(start).Microseconds()) / 1000,
+			Doctype:           dom.Doctype,
+			HTML:              dom.HTML,
+			ResourceOverrides: []struct{}{},
+			Viewport:          dom.Viewport,
+			IsMainFrame:       true,
+		},
+	})
+
+	return nil
+}
+
+func (r *traceRecorder) recordScreenshot(p *Page) error {
+	ctx, cancel := context.WithTimeout(p.ctx, traceSnapshotTimeout)
+	defer cancel()
+
+	now := time.Now()
+	action := cdppage.CaptureScreenshot().
+		WithFormat(cdppage.CaptureScreenshotFormatJpeg).
+		WithQuality(50)
+	data, err := action.Do(cdp.WithExecutor(ctx, p.session))
+	if err != nil {
+		return fmt.Errorf("taking screenshot: %w", err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decoding screenshot: %w", err)
+	}
+	sum := sha1.Sum(data) //nolint:gosec
+	name := hex.EncodeToString(sum[:]) + ".jpeg"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resources[name] = data
+	r.events = append(r.events, traceScreencastFrameEvent{
+		Type:      "screencast-frame",
+		PageID:    r.pageID(p.targetID),
+		SHA1:      name,
+		Width:     cfg.Width,
+		Height:    cfg.Height,
+		Timestamp: r.since(now),
+	})
+
+	return nil
+}
+
+// recordStack records the JS call stack of the call as the stack of the
+// action.
+func (r *traceRecorder) recordStack(callID string, frames []sobek.StackFrame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stack := make([][]any, 0, len(frames))
+	for _, f := range frames {
+		// The native frames, such as of the mapping of the call, have no
+		// source.
+		file := strings.TrimPrefix(f.SrcName(), "file://")
+		if file == "" || file == "<native>" {
+			continue
+		}
+		i, ok := r.fileIDs[file]
+		if !ok {
+			i = len(r.files)
+			r.files = append(r.files, file)
+			r.fileIDs[file] = i
+		}
+		pos := f.Position()
+		stack = append(stack, []any{i, pos.Line, pos.Column, f.FuncName()})
+	}
+	id := strings.TrimPrefix(callID, "call@")
+	r.stacks = append(r.stacks, []any{json.Number(id), stack})
+}
+
+// recordConsole records the console message of the page.
+func (r *traceRecorder) recordConsole(p *Page, e *cdpruntime.EventConsoleAPICalled) {
+	args := make([]string, 0, len(e.Args))
+	for _, robj := range e.Args {
+		s, _ := parseConsoleRemoteObject(p.logger, robj)
+		args = append(args, s)
+	}
+	var loc traceLocation
+	if e.StackTrace != nil && len(e.StackTrace.CallFrames) > 0 {
+		cf := e.StackTrace.CallFrames[0]
+		loc = traceLocation{URL: cf.URL, LineNumber: cf.LineNumber, ColumnNumber: cf.ColumnNumber}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, traceConsoleEvent{
+		Type:        "console",
+		Time:        r.since(time.Now()),
+		PageID:      r.pageID(p.targetID),
+		MessageType: e.Type.String(),
+		Text:        textForConsoleEvent(e, args),
+		Location:    loc,
+	})
+}
+
+// archive returns the zip archive of the trace, which has the actions in
+// trace.trace, the requests in trace.network, and the screenshots, the
+// response bodies and the sources in resources.
+func (r *traceRecorder) archive() ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		zw  = zip.NewWriter(&buf)
+	)
+	write := func(name string, data []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("creating %q: %w", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("writing %q: %w", name, err)
+		}
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := append([]any{traceContextOptionsEvent{
+		Version:       traceArchiveVersion,
+		Type:          "context-options",
+		Origin:        "library",
+		BrowserName:   "chromium",
+		Platform:      runtime.GOOS,
+		WallTime:      float64(r.start.UnixMicro()) / 1000,
+		MonotonicTime: 0,
+		SDKLanguage:   "javascript",
+		Options:       map[string]any{},
+	}}, r.events...)
+	trace, err := marshalJSONLines(events)
+	if err != nil {
+		return nil, err
+	}
+	if err := write("trace.trace", trace); err != nil {
+		return nil, err
+	}
+
+	network, resources, err := r.network()
+	if err != nil {
+		return nil, err
+	}
+	if err := write("trace.network", network); err != nil {
+		return nil, err
+	}
+	for name, data := range resources {
+		r.resources[name] = data
+	}
+
+	if r.opts.Sources {
+		stacks, err := json.Marshal(map[string]any{"files": r.files, "stacks": r.stacks})
+		if err != nil {
+			return nil, fmt.Errorf("marshaling stacks: %w", err)
+		}
+		if err := write("trace.stacks", stacks); err != nil {
+			return nil, err
+		}
+		// The trace viewer finds the source of a file by the SHA1
+		// of its path.
+		for _, file := range r.files {
+			src, err := os.ReadFile(file) //nolint:gosec
+			if err != nil {
+				continue
+			}
+			sum := sha1.Sum([]byte(file)) //nolint:gosec
+			r.resources["src@"+hex.EncodeToString(sum[:])+".txt"] = src
+		}
+	}
+
+	for name, data := range r.resources {
+		if err := write("resources/"+name, data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("closing trace archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// network returns the requests of the trace and a copy of their response
+// bodies, since the HAR recorder keeps recording them. It must be called
+// with the lock held.
+func (r *traceRecorder) network() ([]byte, map[string][]byte, error) {
+	r.har.mu.Lock()
+	defer r.har.mu.Unlock()
+
+	pageIDs := make(map[string]string, len(r.har.pageRefs))
+	for tid, hp := range r.har.pageRefs {
+		pageIDs[hp.ID] = r.pageID(tid)
+	}
+	events := make([]any, 0, len(r.har.entries))
+	for _, e := range r.har.entries {
+		s := traceResourceSnapshot{HAREntry: *e, MonotonicTime: r.since(e.StartedDateTime)}
+		s.PageRef = pageIDs[e.PageRef]
+		s.Response.Content.SHA1 = s.Response.Content.File
+		s.Response.Content.File = ""
+		events = append(events, traceResourceSnapshotEvent{Type: "resource-snapshot", Snapshot: s})
+	}
+	network, err := marshalJSONLines(events)
+	if err != nil {
+		return nil, nil, err
+	}
+	attachments := make(map[string][]byte, len(r.har.attachments))
+	for name, data := range r.har.attachments {
+		attachments[name] = data
+	}
+
+	return network, attachments, nil
+}
+
+func marshalJSONLines(events []any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return nil, fmt.Errorf("marshaling trace event: %w", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// TracingStartOptions are the options of Tracing.Start.
+type TracingStartOptions struct {
+	// Screenshots records a screenshot of the page before and after
+	// each action.
+	Screenshots bool `js:"screenshots"`
+	// Snapshots records a DOM snapshot of the page before and after
+	// each action.
+	Snapshots bool `js:"snapshots"`
+	// Sources records the script location of each action and includes
+	// the source of the script in the trace.
+	Sources bool `js:"sources"`
+}
+
+// NewTracingStartOptions returns the default tracing start options.
+func NewTracingStartOptions() *TracingStartOptions {
+	return &TracingStartOptions{}
+}
+
+// Parse parses the tracing start options.
+func (o *TracingStartOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	obj := opts.ToObject(k6ext.Runtime(ctx))
+	for _, k := range obj.Keys() {
+		switch k {
+		case "screenshots":
+			o.Screenshots = obj.Get(k).ToBoolean()
+		case "snapshots":
+			o.Snapshots = obj.Get(k).ToBoolean()
+		case "sources":
+			o.Sources = obj.Get(k).ToBoolean()
+		}
+	}
+
+	return nil
+}
+
+// TracingStopOptions are the options of Tracing.Stop.
+type TracingStopOptions struct {
+	// Path is where the trace archive is saved to. The trace is
+	// discarded if it's empty.
+	Path string `js:"path"`
+}
+
+// NewTracingStopOptions returns the default tracing stop options.
+func NewTracingStopOptions() *TracingStopOptions {
+	return &TracingStopOptions{}
+}
+
+// Parse parses the tracing stop options.
+func (o *TracingStopOptions) Parse(ctx context.Context, opts sobek.Value) error {
+	if !sobekValueExists(opts) {
+		return nil
+	}
+
+	obj := opts.ToObject(k6ext.Runtime(ctx))
+	for _, k := range obj.Keys() {
+		if k == "path" {
+			o.Path = obj.Get(k).String()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package common
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/target"
+	"github.com/grafana/sobek"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/xk6-browser/k6ext"
+	"github.com/grafana/xk6-browser/k6ext/k6test"
+)
+
+func TestTraceRecorderArchive(t *testing.T) {
+	t.Parallel()
+
+	rec := newTraceRecorder(&TracingStartOptions{Sources: true})
+	rec.addEvent(traceBeforeEvent{Type: "before", CallID: "call@1", APIName: "page.goto"})
+	rec.addEvent(traceAfterEvent{Type: "after", CallID: "call@1", Error: &traceError{Message: "timeout"}})
+	rec.resources["screenshot.jpeg"] = []byte("jpeg")
+
+	const tid = target.ID("target")
+	rec.har.pageRefs[tid] = &HARPage{ID: "page_1"}
+	rec.har.entries = append(rec.har.entries, &HAREntry{
+		PageRef:         "page_1",
+		StartedDateTime: rec.start.Add(time.Second),
+		Response: HARResponse{
+			Content: HARContent{File: "body.html"},
+		},
+	})
+	rec.har.attachments["body.html"] = []byte("<html></html>")
+
+	data, err := rec.archive()
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[f.Name] = string(b)
+	}
+
+	assert.Equal(t, "jpeg", files["resources/screenshot.jpeg"])
+	assert.Equal(t, "<html></html>", files["resources/body.html"])
+	assert.JSONEq(t, `{"files":[],"stacks":[]}`, files["trace.stacks"])
+
+	lines := strings.Split(strings.TrimSpace(files["trace.trace"]), "\n")
+	require.Len(t, lines, 3)
+	var options traceContextOptionsEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &options))
+	assert.Equal(t, "context-options", options.Type)
+	assert.Equal(t, traceArchiveVersion, options.Version)
+	assert.Contains(t, lines[2], `"error":{"message":"timeout"}`)
+
+	var network struct {
+		Type     string `json:"type"`
+		Snapshot struct {
+			PageRef       string  `json:"pageref"`
+			MonotonicTime float64 `json:"_monotonicTime"`
+			Response      struct {
+				Content struct {
+					SHA1 string `json:"_sha1"`
+					File string `json:"_file"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"snapshot"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(files["trace.network"]), &network))
+	assert.Equal(t, "resource-snapshot", network.Type)
+	assert.Equal(t, "page@1", network.Snapshot.PageRef)
+	assert.Equal(t, float64(1000), network.Snapshot.MonotonicTime)
+	assert.Equal(t, "body.html", network.Snapshot.Response.Content.SHA1)
+	assert.Empty(t, network.Snapshot.Response.Content.File)
+}
+
+func TestTraceRecorderNetworkCopiesAttachments(t *testing.T) {
+	t.Parallel()
+
+	rec := newTraceRecorder(&TracingStartOptions{})
+	rec.har.attachments["body.html"] = []byte("<html></html>")
+
+	_, attachments, err := rec.network()
+	require.NoError(t, err)
+
+	// The HAR recorder keeps recording the response bodies after the
+	// lock is released.
+	rec.har.mu.Lock()
+	rec.har.attachments["other.html"] = []byte("<html></html>")
+	rec.har.mu.Unlock()
+
+	assert.Equal(t, map[string][]byte{"body.html": []byte("<html></html>")}, attachments)
+}
+
+func TestTraceRecorderStacks(t *testing.T) {
+	t.Parallel()
+
+	rt := sobek.New()
+	cs := k6ext.NewCallStacks()
+	require.NoError(t, rt.Set("call", func(apiName string) {
+		cs.Capture(rt, apiName)
+	}))
+
+	_, err := rt.RunScript("file:///unrecorded.js", "call('page.goto');")
+	require.NoError(t, err)
+	assert.Nil(t, cs.Take("page.goto"))
+
+	stop := cs.Record()
+	defer stop()
+	_, err = rt.RunScript("file:///test.js", `
+function goto() { return call('page.goto'); }
+function click() { return call('locator.click'); }
+Promise.all([goto(), click()]);
+`)
+	require.NoError(t, err)
+
+	// The calls are traced in another order than they are made, and each
+	// of them is recorded with the stack of its own call.
+	rec := newTraceRecorder(&TracingStartOptions{Sources: true})
+	rec.recordStack("call@2", cs.Take("locator.click"))
+	rec.recordStack("call@1", cs.Take("page.goto"))
+	assert.Nil(t, cs.Take("page.goto"), "must take the stack of a call once")
+
+	assert.Equal(t, []string{"/test.js"}, rec.files)
+	require.Len(t, rec.stacks, 2)
+	assert.Equal(t, []any{json.Number("2"), [][]any{
+		{0, 3, 31, "click"},
+		{0, 4, 27, "<anonymous>"},
+	}}, rec.stacks[0])
+	assert.Equal(t, []any{json.Number("1"), [][]any{
+		{0, 2, 30, "goto"},
+		{0, 4, 18, "<anonymous>"},
+	}}, rec.stacks[1])
+}
+
+func TestTracingStartOptionsParse(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	opts := NewTracingStartOptions()
+	err := opts.Parse(vu.Context(), vu.ToSobekValue(map[string]any{
+		"screenshots": true,
+		"snapshots":   true,
+	}))
+	require.NoError(t, err)
+
+	assert.True(t, opts.Screenshots)
+	assert.True(t, opts.Snapshots)
+	assert.False(t, opts.Sources)
+}
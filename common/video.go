@@ -0,0 +1,450 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"compress/lzw"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/jpeg"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/grafana/xk6-browser/log"
+)
+
+// VideoMode controls which of the recorded videos are kept.
+type VideoMode string
+
+const (
+	// VideoModeOn keeps all the videos.
+	VideoModeOn VideoMode = "on"
+
+	// VideoModeRetainOnFailure keeps the videos only if the iteration
+	// fails, and discards them otherwise.
+	VideoModeRetainOnFailure VideoMode = "retain-on-failure"
+)
+
+// maxVideoSize is the default maximum width and height of a video. The
+// viewport is scaled down to fit into it.
+const maxVideoSize = 800
+
+// minFrameDelay is the minimum delay of a GIF frame in 100ths of a second.
+// Most GIF viewers play the frames with a shorter delay slower.
+const minFrameDelay = 2
+
+// maxFrameDelay is the maximum delay of a GIF frame in 100ths of a second,
+// as the delay is an unsigned 16-bit integer.
+const maxFrameDelay = 0xffff
+
+// videoFrameBuffer is how many screencast frames can wait to be encoded.
+// The frames that arrive while the buffer is full are dropped, so that
+// encoding the frames doesn't hold up the page events.
+const videoFrameBuffer = 8
+
+// Video is the video recording of a page.
+//
+// The frames of the page are encoded into an animated GIF in a temporary
+// file while the page is open, so that they're not kept in memory. The
+// file is removed when the browser closes.
+type Video struct {
+	ctx    context.Context
+	logger *log.Logger
+	path   string
+	size   Viewport
+	mode   VideoMode
+
+	framesMu sync.Mutex
+	frames   chan videoFrame
+	last     *videoFrame
+	stopped  time.Time
+	// dropped is the number of frames dropped while encoding is behind.
+	dropped int
+
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// encoded is closed once the frames are encoded into the file.
+	encoded   chan struct{}
+	file      string
+	encodeErr error
+
+	persistOnce sync.Once
+	persistErr  error
+}
+
+// videoFrame is a JPEG screencast frame of a page.
+type videoFrame struct {
+	data      []byte
+	timestamp time.Time
+}
+
+func newVideo(ctx context.Context, logger *log.Logger, path string, size Viewport, mode VideoMode) *Video {
+	v := &Video{
+		ctx:     ctx,
+		logger:  logger,
+		path:    path,
+		size:    size,
+		mode:    mode,
+		frames:  make(chan videoFrame, videoFrameBuffer),
+		done:    make(chan struct{}),
+		encoded: make(chan struct{}),
+	}
+	go v.encodeFrames()
+
+	return v
+}
+
+// videoSize returns the size of the screencast frames of a page. The
+// viewport is scaled down to fit into 800x800 if the size is not set.
+func videoSize(size, viewport *Viewport) Viewport {
+	if size != nil {
+		return *size
+	}
+	fit := Viewport{Width: maxVideoSize, Height: maxVideoSize * 3 / 4}
+	if viewport == nil || viewport.Width <= 0 || viewport.Height <= 0 {
+		return fit
+	}
+	scale := 1.0
+	if w := float64(maxVideoSize) / float64(viewport.Width); w < scale {
+		scale = w
+	}
+	if h := float64(maxVideoSize) / float64(viewport.Height); h < scale {
+		scale = h
+	}
+	fit.Width = int64(float64(viewport.Width) * scale)
+	fit.Height = int64(float64(viewport.Height) * scale)
+
+	return fit
+}
+
+// addFrame adds a frame to the video unless the recording is stopped.
+func (v *Video) addFrame(data []byte, timestamp time.Time) {
+	v.framesMu.Lock()
+	defer v.framesMu.Unlock()
+
+	if !v.stopped.IsZero() {
+		return
+	}
+	f := videoFrame{data: data, timestamp: timestamp}
+	v.last = &f
+	select {
+	case v.frames <- f:
+	default:
+		v.dropped++
+		v.logger.Debugf("Video:addFrame", "path:%s dropping frame, encoding is behind (%d dropped)", v.path, v.dropped)
+	}
+}
+
+// lastFrame returns the most recent frame of the video, if any.
+func (v *Video) lastFrame() (videoFrame, bool) {
+	v.framesMu.Lock()
+	defer v.framesMu.Unlock()
+
+	if v.last == nil {
+		return videoFrame{}, false
+	}
+
+	return *v.last, true
+}
+
+// stop stops the recording. The frames that are added afterwards are
+// ignored.
+func (v *Video) stop() {
+	v.stopOnce.Do(func() {
+		v.framesMu.Lock()
+		v.stopped = time.Now()
+		close(v.frames)
+		if v.dropped > 0 {
+			v.logger.Debugf("Video:stop", "path:%s dropped %d frames in total", v.path, v.dropped)
+		}
+		v.framesMu.Unlock()
+		close(v.done)
+	})
+}
+
+// wait waits for the recording to stop.
+func (v *Video) wait() error {
+	select {
+	case <-v.done:
+		return nil
+	case <-v.ctx.Done():
+		return fmt.Errorf("waiting for video of the page to finish: %w", v.ctx.Err())
+	}
+}
+
+// encodeFrames encodes the frames into the file as they arrive. Each frame
+// is shown until the timestamp of the next frame, and the last one until
+// the recording stops.
+func (v *Video) encodeFrames() {
+	defer close(v.encoded)
+
+	var (
+		f    *os.File
+		gw   *gifWriter
+		prev *videoFrame
+		err  error
+	)
+	write := func(fr *videoFrame, next time.Time) error {
+		if gw == nil {
+			if f, err = os.CreateTemp("", "k6browser-video-*.gif"); err != nil {
+				return fmt.Errorf("creating video file: %w", err)
+			}
+			v.file = f.Name()
+			go v.removeFileOnDone()
+			gw = newGIFWriter(f, v.size)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(fr.data))
+		if err != nil {
+			return fmt.Errorf("decoding video frame: %w", err)
+		}
+
+		return gw.writeFrame(img, frameDelay(next.Sub(fr.timestamp)))
+	}
+	for fr := range v.frames {
+		fr := fr
+		if prev != nil && err == nil {
+			err = write(prev, fr.timestamp)
+		}
+		prev = &fr
+	}
+
+	v.framesMu.Lock()
+	stopped := v.stopped
+	v.framesMu.Unlock()
+	if prev != nil && err == nil {
+		err = write(prev, stopped)
+	}
+	if gw != nil && err == nil {
+		err = gw.close()
+	}
+	if f != nil {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("closing video file: %w", cerr)
+		}
+	}
+	switch {
+	case err != nil:
+		v.encodeErr = fmt.Errorf("encoding video: %w", err)
+	case prev == nil:
+		v.encodeErr = errors.New("encoding video: no frames were recorded")
+	}
+}
+
+// removeFileOnDone removes the temporary video file when the browser
+// closes.
+func (v *Video) removeFileOnDone() {
+	<-v.ctx.Done()
+	<-v.encoded
+	if err := os.Remove(v.file); err != nil {
+		v.logger.Debugf("Video:removeFileOnDone", "removing %s: %v", v.file, err)
+	}
+}
+
+// save persists the encoded video of a stopped recording to the path.
+func (v *Video) save(sp ScreenshotPersister, path string) error {
+	select {
+	case <-v.encoded:
+	case <-v.ctx.Done():
+		return fmt.Errorf("waiting for video of the page to be encoded: %w", v.ctx.Err())
+	}
+	if v.encodeErr != nil {
+		return v.encodeErr
+	}
+
+	f, err := os.Open(v.file)
+	if err != nil {
+		return fmt.Errorf("opening video file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	return sp.Persist(v.ctx, path, f) //nolint:wrapcheck
+}
+
+// persist persists the video to its path. It only persists the video
+// once, and returns the same error on the subsequent calls.
+func (v *Video) persist(sp ScreenshotPersister) error {
+	v.persistOnce.Do(func() {
+		v.logger.Debugf("Video:persist", "path:%s", v.path)
+
+		if err := v.save(sp, v.path); err != nil {
+			v.persistErr = fmt.Errorf("persisting video to %q: %w", v.path, err)
+		}
+	})
+
+	return v.persistErr
+}
+
+// Path returns the path that the video is persisted to.
+func (v *Video) Path() string {
+	return v.path
+}
+
+// SaveAs waits for the page to close and persists the video to the path.
+func (v *Video) SaveAs(path string, sp ScreenshotPersister) error {
+	v.logger.Debugf("Video:SaveAs", "path:%s", path)
+
+	if path == "" {
+		return errors.New("path is required")
+	}
+	if err := v.wait(); err != nil {
+		return err
+	}
+	if err := v.save(sp, path); err != nil {
+		return fmt.Errorf("saving video to %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// gifWriter encodes an animated GIF frame by frame, so that the frames
+// don't have to be kept in memory as gif.EncodeAll requires. The frames
+// use the web safe palette as the global color table.
+type gifWriter struct {
+	w       *bufio.Writer
+	bounds  image.Rectangle
+	started bool
+}
+
+func newGIFWriter(w io.Writer, size Viewport) *gifWriter {
+	return &gifWriter{
+		w:      bufio.NewWriter(w),
+		bounds: image.Rect(0, 0, int(size.Width), int(size.Height)),
+	}
+}
+
+// writeHeader writes the header, the global color table and the looping
+// extension of the GIF.
+func (g *gifWriter) writeHeader() {
+	w, h := g.bounds.Dx(), g.bounds.Dy()
+	_, _ = g.w.WriteString("GIF89a")
+	// The global color table has 256 colors of 8 bits.
+	_, _ = g.w.Write([]byte{byte(w), byte(w >> 8), byte(h), byte(h >> 8), 0xf7, 0, 0})
+	for i := 0; i < 256; i++ {
+		var r, gr, b uint32
+		if i < len(palette.WebSafe) {
+			r, gr, b, _ = palette.WebSafe[i].RGBA()
+		}
+		_, _ = g.w.Write([]byte{byte(r >> 8), byte(gr >> 8), byte(b >> 8)})
+	}
+	_, _ = g.w.Write([]byte{0x21, 0xff, 0x0b})
+	_, _ = g.w.WriteString("NETSCAPE2.0")
+	_, _ = g.w.Write([]byte{0x03, 0x01, 0, 0, 0})
+}
+
+// frameDelay returns the GIF frame delay of the duration in 100ths of a
+// second, clamped to the delays that a GIF frame can have.
+func frameDelay(d time.Duration) int {
+	delay := d / (10 * time.Millisecond)
+	switch {
+	case delay < minFrameDelay:
+		return minFrameDelay
+	case delay > maxFrameDelay:
+		return maxFrameDelay
+	default:
+		return int(delay)
+	}
+}
+
+// writeFrame writes the image as a frame that's shown for the delay in
+// 100ths of a second.
+func (g *gifWriter) writeFrame(img image.Image, delay int) error {
+	if !g.started {
+		g.writeHeader()
+		g.started = true
+	}
+	w, h := g.bounds.Dx(), g.bounds.Dy()
+	// The graphic control extension and the image descriptor.
+	_, _ = g.w.Write([]byte{0x21, 0xf9, 0x04, 0, byte(delay), byte(delay >> 8), 0, 0})
+	_, _ = g.w.Write([]byte{0x2c, 0, 0, 0, 0, byte(w), byte(w >> 8), byte(h), byte(h >> 8), 0})
+	_ = g.w.WriteByte(8) // the LZW minimum code size
+
+	bw := &gifBlockWriter{w: g.w}
+	lw := lzw.NewWriter(bw, lzw.LSB, 8)
+	if _, err := lw.Write(toWebSafe(img, g.bounds).Pix); err != nil {
+		return fmt.Errorf("compressing frame: %w", err)
+	}
+	if err := lw.Close(); err != nil {
+		return fmt.Errorf("compressing frame: %w", err)
+	}
+
+	return bw.close()
+}
+
+// close writes the trailer of the GIF.
+func (g *gifWriter) close() error {
+	_ = g.w.WriteByte(0x3b)
+
+	return g.w.Flush() //nolint:wrapcheck
+}
+
+// gifBlockWriter splits the compressed image data into the sub-blocks of
+// up to 255 bytes of the GIF format.
+type gifBlockWriter struct {
+	w   *bufio.Writer
+	buf [256]byte
+	n   int
+}
+
+func (b *gifBlockWriter) Write(p []byte) (int, error) {
+	for _, c := range p {
+		b.n++
+		b.buf[b.n] = c
+		if b.n == 255 {
+			if err := b.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+func (b *gifBlockWriter) flush() error {
+	if b.n == 0 {
+		return nil
+	}
+	b.buf[0] = byte(b.n)
+	_, err := b.w.Write(b.buf[:b.n+1])
+	b.n = 0
+
+	return err //nolint:wrapcheck
+}
+
+// close writes the remaining data and the block terminator.
+func (b *gifBlockWriter) close() error {
+	if err := b.flush(); err != nil {
+		return err
+	}
+
+	return b.w.WriteByte(0) //nolint:wrapcheck
+}
+
+// toWebSafe converts the image to the web safe palette, and crops or pads
+// it to the bounds. The color index is calculated directly instead of
+// searching the palette for the closest color, which is much faster.
+func toWebSafe(img image.Image, bounds image.Rectangle) *image.Paletted {
+	pm := image.NewPaletted(bounds, palette.WebSafe)
+	src := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y && y-bounds.Min.Y < src.Dy(); y++ {
+		for x := bounds.Min.X; x < bounds.Max.X && x-bounds.Min.X < src.Dx(); x++ {
+			r, g, b, _ := img.At(src.Min.X+x-bounds.Min.X, src.Min.Y+y-bounds.Min.Y).RGBA()
+			pm.SetColorIndex(x, y, webSafeIndex(r>>8, g>>8, b>>8))
+		}
+	}
+
+	return pm
+}
+
+// webSafeIndex returns the index of the closest color in the web safe
+// palette, whose colors have 6 levels per component in the RGB order.
+func webSafeIndex(r, g, b uint32) uint8 {
+	level := func(c uint32) uint32 { return (c + 0x33/2) / 0x33 }
+
+	return uint8(36*level(r) + 6*level(g) + level(b))
+}
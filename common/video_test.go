@@ -0,0 +1,174 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/xk6-browser/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		size     *Viewport
+		viewport *Viewport
+		want     Viewport
+	}{
+		{
+			name:     "size",
+			size:     &Viewport{Width: 320, Height: 240},
+			viewport: &Viewport{Width: 1280, Height: 720},
+			want:     Viewport{Width: 320, Height: 240},
+		},
+		{
+			name:     "scaled_viewport",
+			viewport: &Viewport{Width: 1280, Height: 720},
+			want:     Viewport{Width: 800, Height: 450},
+		},
+		{
+			name:     "small_viewport",
+			viewport: &Viewport{Width: 400, Height: 300},
+			want:     Viewport{Width: 400, Height: 300},
+		},
+		{
+			name: "no_viewport",
+			want: Viewport{Width: 800, Height: 600},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, videoSize(tt.size, tt.viewport))
+		})
+	}
+}
+
+func TestFrameDelay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		d    time.Duration
+		want int
+	}{
+		{name: "zero", d: 0, want: minFrameDelay},
+		{name: "short", d: 5 * time.Millisecond, want: minFrameDelay},
+		{name: "exact", d: 250 * time.Millisecond, want: 25},
+		{name: "rounded_down", d: 259 * time.Millisecond, want: 25},
+		{name: "max", d: 655350 * time.Millisecond, want: maxFrameDelay},
+		{name: "too_long", d: time.Hour, want: maxFrameDelay},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, frameDelay(tt.d))
+		})
+	}
+}
+
+func TestWebSafeIndex(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []color.RGBA{
+		{0, 0, 0, 255},
+		{255, 255, 255, 255},
+		{255, 0, 0, 255},
+		{0x33, 0x66, 0x99, 255},
+		{0x30, 0x70, 0xa0, 255},
+	} {
+		want := palette.WebSafe[color.Palette(palette.WebSafe).Index(c)]
+		got := palette.WebSafe[webSafeIndex(uint32(c.R), uint32(c.G), uint32(c.B))]
+		assert.Equal(t, want, got, "color %v", c)
+	}
+}
+
+func TestVideoEncode(t *testing.T) {
+	t.Parallel()
+
+	frame := func(c color.Color) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 6))
+		for y := 0; y < 6; y++ {
+			for x := 0; x < 8; x++ {
+				img.Set(x, y, c)
+			}
+		}
+		var buf bytes.Buffer
+		require.NoError(t, jpeg.Encode(&buf, img, nil))
+		return buf.Bytes()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	v := newVideo(ctx, log.NewNullLogger(), "video.gif", Viewport{Width: 8, Height: 6}, VideoModeOn)
+	v.addFrame(frame(color.White), start)
+	v.addFrame(frame(color.Black), start.Add(500*time.Millisecond))
+	v.stop()
+	v.addFrame(frame(color.White), start.Add(time.Second))
+
+	sp := &bufferPersister{}
+	require.NoError(t, v.SaveAs("saved.gif", sp))
+	assert.Equal(t, "saved.gif", sp.path)
+
+	anim, err := gif.DecodeAll(bytes.NewReader(sp.data))
+	require.NoError(t, err)
+	require.Len(t, anim.Image, 2, "frames after stopping the recording should be ignored")
+	assert.Equal(t, 50, anim.Delay[0])
+	assert.Equal(t, 8, anim.Config.Width)
+	assert.Equal(t, 6, anim.Config.Height)
+
+	r, _, _, _ := anim.Image[0].At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+	r, _, _, _ = anim.Image[1].At(0, 0).RGBA()
+	assert.Equal(t, uint32(0), r)
+
+	// The frames are encoded into a temporary file, which is removed
+	// when the browser closes.
+	require.FileExists(t, v.file)
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(v.file)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestVideoEncodeNoFrames(t *testing.T) {
+	t.Parallel()
+
+	v := newVideo(context.Background(), log.NewNullLogger(), "video.gif", Viewport{Width: 8, Height: 6}, VideoModeOn)
+	v.stop()
+
+	err := v.persist(&bufferPersister{})
+	assert.ErrorContains(t, err, "no frames were recorded")
+}
+
+type bufferPersister struct {
+	path string
+	data []byte
+}
+
+func (b *bufferPersister) Persist(_ context.Context, path string, r io.Reader) error {
+	b.path = path
+	var err error
+	b.data, err = io.ReadAll(r)
+	return err
+}
@@ -0,0 +1,342 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+	k6metrics "go.k6.io/k6/metrics"
+
+	"github.com/grafana/xk6-browser/k6ext"
+)
+
+// VisualMetricsOptions are the page options to capture the visual progress
+// of the navigations of the page with goto and reload, which is measured
+// with the Speed Index, first visual change and last visual change metrics.
+type VisualMetricsOptions struct {
+	// FilmstripDir is the directory that the captured frames are persisted
+	// to, in a directory for each navigation of a page. The frames are not
+	// persisted if it's empty.
+	FilmstripDir string `js:"filmstripDir"`
+}
+
+// parseVisualMetricsOptions parses the visualMetrics page option, which is
+// either a boolean or an object with the filmstripDir property.
+// It returns nil if the visual metrics are not enabled.
+func parseVisualMetricsOptions(ctx context.Context, v sobek.Value) (*VisualMetricsOptions, error) {
+	if !sobekValueExists(v) {
+		return nil, nil //nolint:nilnil
+	}
+	if enabled, ok := v.Export().(bool); ok {
+		if !enabled {
+			return nil, nil //nolint:nilnil
+		}
+		return &VisualMetricsOptions{}, nil
+	}
+	if _, ok := v.(*sobek.Object); !ok {
+		return nil, fmt.Errorf("visualMetrics must be a boolean or an object, got %q", v.String())
+	}
+
+	var opts VisualMetricsOptions
+	obj := v.ToObject(k6ext.Runtime(ctx))
+	for _, k := range obj.Keys() {
+		if k == "filmstripDir" {
+			dir := obj.Get(k)
+			if _, ok := dir.Export().(string); !ok {
+				return nil, fmt.Errorf("visualMetrics.filmstripDir must be a string, got %q", dir.String())
+			}
+			opts.FilmstripDir = dir.String()
+		}
+	}
+
+	return &opts, nil
+}
+
+// filmstrip is the screencast frames of a page that are captured from the
+// start of a navigation.
+type filmstrip struct {
+	start time.Time
+	// n is the number of the navigation of the page that it captures.
+	n int
+
+	mu     sync.Mutex
+	frames []videoFrame
+}
+
+func (f *filmstrip) add(data []byte, timestamp time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.frames = append(f.frames, videoFrame{data: data, timestamp: timestamp})
+}
+
+// visualMetrics are the visual progress metrics of a navigation. The
+// durations are relative to the start of the navigation.
+type visualMetrics struct {
+	SpeedIndex        time.Duration
+	FirstVisualChange time.Duration
+	LastVisualChange  time.Duration
+}
+
+// histogram is the number of pixels for each value of the red, green and
+// blue components of an image.
+type histogram [3][256]int
+
+func newHistogram(img image.Image) *histogram {
+	var h histogram
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			h[0][r>>8]++
+			h[1][g>>8]++
+			h[2][b>>8]++
+		}
+	}
+
+	return &h
+}
+
+// progress returns how close the histogram is to the end histogram,
+// starting from the start histogram, between 0 and 1.
+//
+// It is the same approach that WebPageTest uses to calculate the Speed
+// Index: for each color component, the pixels that moved from the start
+// towards the end are matched against all the pixels that have to move.
+func (h *histogram) progress(start, end *histogram) float64 {
+	var total float64
+	for c := range h {
+		var moved, matched int
+		for i := range h[c] {
+			want := abs(end[c][i] - start[c][i])
+			moved += want
+			got := abs(h[c][i] - start[c][i])
+			if got > want {
+				got = want
+			}
+			matched += got
+		}
+		if moved == 0 {
+			total++
+			continue
+		}
+		total += float64(matched) / float64(moved)
+	}
+
+	return total / float64(len(h))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// calculateVisualMetrics calculates the visual metrics from the frames
+// that are captured from the start of a navigation. The first frame is
+// the page before the navigation, and the last one is the final state of
+// the page that the progress is measured against.
+//
+// It returns nil if there are too few frames or the page doesn't visually
+// change, since the metrics would be zero without measuring anything.
+func calculateVisualMetrics(start time.Time, frames []videoFrame) (*visualMetrics, error) {
+	if len(frames) < 2 {
+		return nil, nil //nolint:nilnil
+	}
+
+	frames = append([]videoFrame(nil), frames...)
+	sort.SliceStable(frames, func(i, j int) bool {
+		return frames[i].timestamp.Before(frames[j].timestamp)
+	})
+	histograms := make([]*histogram, len(frames))
+	for i, f := range frames {
+		img, err := jpeg.Decode(bytes.NewReader(f.data))
+		if err != nil {
+			return nil, fmt.Errorf("decoding filmstrip frame: %w", err)
+		}
+		histograms[i] = newHistogram(img)
+	}
+
+	var (
+		vm           visualMetrics
+		changed      bool
+		first, last  = histograms[0], histograms[len(histograms)-1]
+		prevProgress float64
+		prevTime     time.Duration
+	)
+	for i := 1; i < len(frames); i++ {
+		t := frames[i].timestamp.Sub(start)
+		if t < 0 {
+			t = 0
+		}
+		vm.SpeedIndex += time.Duration((1 - prevProgress) * float64(t-prevTime))
+
+		if *histograms[i] != *histograms[i-1] {
+			if !changed {
+				vm.FirstVisualChange = t
+				changed = true
+			}
+			vm.LastVisualChange = t
+		}
+		prevProgress = histograms[i].progress(first, last)
+		prevTime = t
+	}
+	if !changed {
+		return nil, nil //nolint:nilnil
+	}
+
+	return &vm, nil
+}
+
+// startFilmstrip starts capturing the screencast frames of the page for
+// the visual metrics of a navigation.
+func (p *Page) startFilmstrip() error {
+	p.filmstripMu.Lock()
+	if p.filmstrip != nil {
+		p.filmstripMu.Unlock()
+		return errors.New("capturing visual metrics: another navigation is already captured")
+	}
+	p.filmstrips++
+	f := &filmstrip{start: time.Now(), n: p.filmstrips}
+	p.filmstrip = f
+	p.filmstripMu.Unlock()
+
+	// The screencast is already running if the page records a video, and
+	// its last frame is the page before the navigation. Otherwise, the
+	// browser sends the current frame when the screencast starts.
+	// The mutex isn't held while waiting for the browser, because the
+	// frames that it sends are added to the filmstrip in the meantime.
+	if p.video != nil {
+		if fr, ok := p.video.lastFrame(); ok {
+			f.add(fr.data, f.start)
+		}
+		return nil
+	}
+	size := videoSize(nil, p.browserCtx.opts.Viewport)
+	if err := p.mainFrameSession.startScreencast(size); err != nil {
+		p.filmstripMu.Lock()
+		p.filmstrip = nil
+		p.filmstripMu.Unlock()
+		return fmt.Errorf("capturing visual metrics: %w", err)
+	}
+
+	return nil
+}
+
+// stopFilmstrip stops capturing the screencast frames of the page, and
+// returns the captured filmstrip, or nil if the frames are not captured.
+func (p *Page) stopFilmstrip() *filmstrip {
+	p.filmstripMu.Lock()
+	f := p.filmstrip
+	p.filmstrip = nil
+	p.filmstripMu.Unlock()
+
+	if f == nil || p.video != nil {
+		return f
+	}
+	if err := p.mainFrameSession.stopScreencast(); err != nil {
+		p.logger.Debugf("Page:stopFilmstrip", "sid:%v %v", p.sessionID(), err)
+	}
+
+	return f
+}
+
+func (p *Page) addFilmstripFrame(data []byte, timestamp time.Time) {
+	p.filmstripMu.Lock()
+	defer p.filmstripMu.Unlock()
+
+	if p.filmstrip != nil {
+		p.filmstrip.add(data, timestamp)
+	}
+}
+
+// visualMetricsOptions returns the visual metrics options of the page, or
+// nil if the visual metrics of its navigations are not captured.
+func (p *Page) visualMetricsOptions() *VisualMetricsOptions {
+	if p.browserCtx == nil || p.browserCtx.opts == nil {
+		return nil
+	}
+
+	return p.browserCtx.opts.VisualMetrics
+}
+
+// finishFilmstrip stops capturing the screencast frames of a navigation,
+// emits its visual metrics and persists the filmstrip if it's enabled.
+func (p *Page) finishFilmstrip(opts *VisualMetricsOptions) error {
+	f := p.stopFilmstrip()
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	frames := f.frames
+	f.mu.Unlock()
+
+	vm, err := calculateVisualMetrics(f.start, frames)
+	if err != nil {
+		return fmt.Errorf("calculating visual metrics: %w", err)
+	}
+	if vm != nil {
+		p.emitVisualMetrics(vm)
+	}
+
+	if opts.FilmstripDir == "" {
+		return nil
+	}
+	sp := GetFilePersister(p.ctx)
+	for i, fr := range frames {
+		offset := fr.timestamp.Sub(f.start)
+		if offset < 0 {
+			offset = 0
+		}
+		path := filepath.Join(
+			opts.FilmstripDir, p.targetID.String(), strconv.Itoa(f.n),
+			fmt.Sprintf("%03d-%dms.jpg", i, offset.Milliseconds()),
+		)
+		if err := sp.Persist(p.ctx, path, bytes.NewReader(fr.data)); err != nil {
+			return fmt.Errorf("persisting filmstrip frame to %q: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Page) emitVisualMetrics(vm *visualMetrics) {
+	state := p.vu.State()
+	if state == nil {
+		return
+	}
+	tags := state.Tags.GetCurrentValues().Tags
+	if state.Options.SystemTags.Has(k6metrics.TagURL) {
+		tags = tags.With("url", p.MainFrame().URL())
+	}
+
+	var (
+		cm  = k6ext.GetCustomMetrics(p.ctx)
+		now = time.Now()
+	)
+	sample := func(m *k6metrics.Metric, d time.Duration) k6metrics.Sample {
+		return k6metrics.Sample{
+			TimeSeries: k6metrics.TimeSeries{Metric: m, Tags: tags},
+			Value:      k6metrics.D(d),
+			Time:       now,
+		}
+	}
+	k6metrics.PushIfNotDone(p.vu.Context(), state.Samples, k6metrics.ConnectedSamples{
+		Samples: []k6metrics.Sample{
+			sample(cm.SpeedIndex, vm.SpeedIndex),
+			sample(cm.FirstVisualChange, vm.FirstVisualChange),
+			sample(cm.LastVisualChange, vm.LastVisualChange),
+		},
+	})
+}
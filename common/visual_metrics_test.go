@@ -0,0 +1,134 @@
+package common
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"github.com/grafana/xk6-browser/k6ext/k6test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVisualMetricsOptions(t *testing.T) {
+	t.Parallel()
+
+	vu := k6test.NewVU(t)
+	ctx := vu.Context()
+	parse := func(v any) *VisualMetricsOptions {
+		t.Helper()
+
+		var sv sobek.Value
+		if v != nil {
+			sv = vu.ToSobekValue(v)
+		}
+		opts, err := parseVisualMetricsOptions(ctx, sv)
+		require.NoError(t, err)
+		return opts
+	}
+
+	assert.Nil(t, parse(nil))
+	assert.Nil(t, parse(false))
+	assert.Equal(t, &VisualMetricsOptions{}, parse(true))
+	assert.Equal(t,
+		&VisualMetricsOptions{FilmstripDir: "filmstrip"},
+		parse(map[string]any{"filmstripDir": "filmstrip"}),
+	)
+
+	_, err := parseVisualMetricsOptions(ctx, vu.ToSobekValue("yes"))
+	assert.ErrorContains(t, err, "visualMetrics must be a boolean or an object")
+	_, err = parseVisualMetricsOptions(ctx, vu.ToSobekValue(map[string]any{"filmstripDir": 1}))
+	assert.ErrorContains(t, err, "visualMetrics.filmstripDir must be a string")
+}
+
+func TestCalculateVisualMetrics(t *testing.T) {
+	t.Parallel()
+
+	// frame returns a white frame whose top rows are black.
+	frame := func(blackRows int) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		for y := 0; y < 10; y++ {
+			c := color.White
+			if y < blackRows {
+				c = color.Black
+			}
+			for x := 0; x < 10; x++ {
+				img.Set(x, y, c)
+			}
+		}
+		var buf bytes.Buffer
+		require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}))
+		return buf.Bytes()
+	}
+
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	t.Run("progress", func(t *testing.T) {
+		t.Parallel()
+
+		vm, err := calculateVisualMetrics(start, []videoFrame{
+			{data: frame(0), timestamp: at(-50)},
+			{data: frame(0), timestamp: at(100)},
+			{data: frame(5), timestamp: at(200)},
+			{data: frame(10), timestamp: at(600)},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 200*time.Millisecond, vm.FirstVisualChange)
+		assert.Equal(t, 600*time.Millisecond, vm.LastVisualChange)
+		// Nothing is visible until 200ms, and half of the page until 600ms.
+		assert.InDelta(t, float64(400*time.Millisecond), float64(vm.SpeedIndex), float64(5*time.Millisecond))
+	})
+
+	t.Run("unordered", func(t *testing.T) {
+		t.Parallel()
+
+		vm, err := calculateVisualMetrics(start, []videoFrame{
+			{data: frame(10), timestamp: at(300)},
+			{data: frame(0), timestamp: at(0)},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 300*time.Millisecond, vm.FirstVisualChange)
+		assert.Equal(t, 300*time.Millisecond, vm.LastVisualChange)
+		assert.Equal(t, 300*time.Millisecond, vm.SpeedIndex)
+	})
+
+	t.Run("single_frame", func(t *testing.T) {
+		t.Parallel()
+
+		vm, err := calculateVisualMetrics(start, []videoFrame{
+			{data: frame(10), timestamp: at(300)},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, vm)
+	})
+
+	t.Run("no_change", func(t *testing.T) {
+		t.Parallel()
+
+		vm, err := calculateVisualMetrics(start, []videoFrame{
+			{data: frame(10), timestamp: at(0)},
+			{data: frame(10), timestamp: at(300)},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, vm)
+	})
+
+	t.Run("err/decode", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := calculateVisualMetrics(start, []videoFrame{
+			{data: []byte("not a jpeg"), timestamp: at(0)},
+			{data: frame(10), timestamp: at(300)},
+		})
+		assert.ErrorContains(t, err, "decoding filmstrip frame")
+	})
+}
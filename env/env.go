@@ -71,6 +71,11 @@ const (
 	// set additional metadata to be included in the generated traces.
 	// The format must comply with: key1=value1,key2=value2,...
 	TracesMetadata = "K6_BROWSER_TRACES_METADATA"
+
+	// TracesOutput is an environment variable that can be used to write
+	// the browser traces to a file instead of sending them to k6.
+	// The format must comply with: file=traces.json,format=otlp|chrome
+	TracesOutput = "K6_BROWSER_TRACES_OUTPUT"
 )
 
 // Screenshots.
@@ -0,0 +1,47 @@
+import { browser } from 'k6/x/browser/async';
+import { check } from 'k6';
+
+export const options = {
+  scenarios: {
+    ui: {
+      executor: 'shared-iterations',
+      options: {
+        browser: {
+            type: 'chromium',
+        },
+      },
+    },
+  },
+  thresholds: {
+    checks: ["rate==1.0"]
+  }
+}
+
+export default async function() {
+  const page = await browser.newPage();
+
+  try {
+    // Block the images, and respond to the news requests with a mocked page.
+    await page.route(/\.(png|jpg|jpeg|gif)$/, async (route) => {
+      await route.abort('blockedbyclient');
+    });
+    await page.route('**/news.php', async (route) => {
+      await route.fulfill({
+        status: 200,
+        contentType: 'text/html',
+        body: '<html><body><h1>Mocked news</h1></body></html>',
+      });
+    });
+
+    await page.goto('https://test.k6.io/news.php');
+
+    const heading = await page.locator('h1').textContent();
+    check(heading, {
+      'fulfilled by the route': h => h == 'Mocked news',
+    });
+
+    await page.unroute('**/news.php');
+  } finally {
+    await page.close();
+  }
+}
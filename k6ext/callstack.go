@@ -0,0 +1,93 @@
+package k6ext
+
+import (
+	"sync"
+
+	"github.com/grafana/sobek"
+)
+
+// CallStacks records the JS call stacks of the API calls of a VU, so
+// that the calls can be traced with the stacks they are made from.
+//
+// The stack of a call is captured on the event loop where the call is
+// made, and it's taken once the call is traced outside of the event loop.
+// The stacks of the calls of the same API are taken in the order the calls
+// are made.
+type CallStacks struct {
+	mu      sync.Mutex
+	users   int
+	pending map[string][][]sobek.StackFrame
+}
+
+// NewCallStacks returns a new CallStacks that doesn't record the call
+// stacks until Record is called.
+func NewCallStacks() *CallStacks {
+	return &CallStacks{
+		pending: make(map[string][][]sobek.StackFrame),
+	}
+}
+
+// Record starts recording the call stacks. It returns a function that
+// stops recording them.
+func (c *CallStacks) Record() (stop func()) {
+	if c == nil {
+		return func() {}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.users++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.users--; c.users == 0 {
+				c.pending = make(map[string][][]sobek.StackFrame)
+			}
+		})
+	}
+}
+
+// Capture captures the current JS call stack of the runtime as the stack
+// of the call of the API, such as page.goto, if the call stacks are
+// recorded. It must be called on the event loop where the call is made.
+func (c *CallStacks) Capture(rt *sobek.Runtime, apiName string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.users == 0 {
+		return
+	}
+	c.pending[apiName] = append(c.pending[apiName], rt.CaptureCallStack(0, nil))
+}
+
+// Take returns the call stack of the earliest call of the API that's not
+// taken yet, or nil if there is none.
+func (c *CallStacks) Take(apiName string) []sobek.StackFrame {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stacks := c.pending[apiName]
+	if len(stacks) == 0 {
+		return nil
+	}
+	if len(stacks) == 1 {
+		delete(c.pending, apiName)
+	} else {
+		c.pending[apiName] = stacks[1:]
+	}
+
+	return stacks[0]
+}
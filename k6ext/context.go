@@ -15,6 +15,8 @@ const (
 	ctxKeyVU ctxKey = iota
 	ctxKeyPid
 	ctxKeyCustomK6Metrics
+	ctxKeyUserMetrics
+	ctxKeyCallStacks
 )
 
 // WithVU returns a new context based on ctx with the k6 VU instance attached.
@@ -48,6 +50,34 @@ func GetCustomMetrics(ctx context.Context) *CustomMetrics {
 	return nil
 }
 
+// WithUserMetrics attaches the UserMetrics object to the context.
+func WithUserMetrics(ctx context.Context, um *UserMetrics) context.Context {
+	return context.WithValue(ctx, ctxKeyUserMetrics, um)
+}
+
+// GetUserMetrics returns the UserMetrics object attached to the context.
+func GetUserMetrics(ctx context.Context) *UserMetrics {
+	v := ctx.Value(ctxKeyUserMetrics)
+	if um, ok := v.(*UserMetrics); ok {
+		return um
+	}
+	return nil
+}
+
+// WithCallStacks attaches the CallStacks object to the context.
+func WithCallStacks(ctx context.Context, cs *CallStacks) context.Context {
+	return context.WithValue(ctx, ctxKeyCallStacks, cs)
+}
+
+// GetCallStacks returns the CallStacks object attached to the context.
+func GetCallStacks(ctx context.Context) *CallStacks {
+	v := ctx.Value(ctxKeyCallStacks)
+	if cs, ok := v.(*CallStacks); ok {
+		return cs
+	}
+	return nil
+}
+
 // Runtime is a convenience function for getting a k6 VU runtime.
 func Runtime(ctx context.Context) *sobek.Runtime {
 	return GetVU(ctx).Runtime()
@@ -1,6 +1,9 @@
 package k6ext
 
 import (
+	"fmt"
+	"sync"
+
 	k6metrics "go.k6.io/k6/metrics"
 )
 
@@ -11,6 +14,7 @@ const (
 	webVitalCLS  = "CLS"
 	webVitalINP  = "INP"
 	webVitalFCP  = "FCP"
+	webVitalTBT  = "TBT"
 
 	fidName  = "browser_web_vital_fid"
 	ttfbName = "browser_web_vital_ttfb"
@@ -18,21 +22,113 @@ const (
 	clsName  = "browser_web_vital_cls"
 	inpName  = "browser_web_vital_inp"
 	fcpName  = "browser_web_vital_fcp"
+	tbtName  = "browser_web_vital_tbt"
+
+	longTaskDurationName = "browser_long_task_duration"
+	longTaskCountName    = "browser_long_task_count"
+
+	speedIndexName        = "browser_speed_index"
+	firstVisualChangeName = "browser_first_visual_change"
+	lastVisualChangeName  = "browser_last_visual_change"
 
 	browserDataSentName        = "browser_data_sent"
 	browserDataReceivedName    = "browser_data_received"
 	browserHTTPReqDurationName = "browser_http_req_duration"
 	browserHTTPReqFailedName   = "browser_http_req_failed"
+	browserJSErrorsName        = "browser_js_errors"
+	browserVisualDiffRatioName = "browser_visual_diff_ratio"
+	browserA11yViolationsName  = "browser_a11y_violations"
+
+	jsHeapUsedName          = "browser_js_heap_used"
+	jsHeapTotalName         = "browser_js_heap_total"
+	domNodesName            = "browser_dom_nodes"
+	documentsName           = "browser_documents"
+	framesName              = "browser_frames"
+	jsEventListenersName    = "browser_js_event_listeners"
+	layoutCountName         = "browser_layout_count"
+	recalcStyleCountName    = "browser_recalc_style_count"
+	layoutDurationName      = "browser_layout_duration"
+	recalcStyleDurationName = "browser_recalc_style_duration"
+	scriptDurationName      = "browser_script_duration"
+	taskDurationName        = "browser_task_duration"
 )
 
+// runtimeMetric is a Chromium runtime metric that is sampled as a k6
+// metric with the given name and value type.
+type runtimeMetric struct {
+	name      string
+	valueType k6metrics.ValueType
+}
+
 // CustomMetrics are the custom k6 metrics used by xk6-browser.
 type CustomMetrics struct {
 	WebVitals map[string]*k6metrics.Metric
 
+	// The long tasks are the tasks that block the main thread of a page
+	// for more than 50ms.
+	LongTaskDuration *k6metrics.Metric
+	LongTaskCount    *k6metrics.Metric
+
+	// RuntimeMetrics are the Chromium runtime metrics of the pages, such
+	// as the JS heap size, keyed by their Chromium names.
+	RuntimeMetrics map[string]*k6metrics.Metric
+
+	// The visual metrics are calculated from the screencast frames of a
+	// page that are captured during a navigation.
+	SpeedIndex        *k6metrics.Metric
+	FirstVisualChange *k6metrics.Metric
+	LastVisualChange  *k6metrics.Metric
+
 	BrowserDataSent        *k6metrics.Metric
 	BrowserDataReceived    *k6metrics.Metric
 	BrowserHTTPReqDuration *k6metrics.Metric
 	BrowserHTTPReqFailed   *k6metrics.Metric
+	BrowserJSErrors        *k6metrics.Metric
+	BrowserVisualDiffRatio *k6metrics.Metric
+	BrowserA11yViolations  *k6metrics.Metric
+}
+
+// UserMetrics are the metrics that the pages emit, such as the metrics of
+// the performance measures. Unlike CustomMetrics, they're shared by the VUs
+// of the module instance, like the registry of the test run.
+type UserMetrics struct {
+	registry *k6metrics.Registry
+
+	mu sync.Mutex
+	// names are the names of the metrics that the pages registered.
+	names map[string]bool
+}
+
+// NewUserMetrics returns the user metrics of the pages that are registered
+// in the registry.
+func NewUserMetrics(registry *k6metrics.Registry) *UserMetrics {
+	return &UserMetrics{
+		registry: registry,
+		names:    make(map[string]bool),
+	}
+}
+
+// Metric returns the metric with the name that a page emits. The metric is
+// registered the first time it's emitted. It returns an error if the name
+// is of a metric that wasn't registered by a page, so that a page can't
+// emit the samples of the other metrics of the test.
+func (u *UserMetrics) Metric(
+	name string, typ k6metrics.MetricType, t k6metrics.ValueType,
+) (*k6metrics.Metric, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.names[name] && u.registry.Get(name) != nil {
+		return nil, fmt.Errorf("registering user metric: metric %q is already registered", name)
+	}
+
+	m, err := u.registry.NewMetric(name, typ, t)
+	if err != nil {
+		return nil, fmt.Errorf("registering user metric: %w", err)
+	}
+	u.names[name] = true
+
+	return m, nil
 }
 
 // RegisterCustomMetrics creates and registers our custom metrics with the k6
@@ -45,6 +141,7 @@ func RegisterCustomMetrics(registry *k6metrics.Registry) *CustomMetrics {
 		webVitalCLS:  clsName,  // cumulative layout shift
 		webVitalINP:  inpName,  // interaction to next paint
 		webVitalFCP:  fcpName,  // first contentful paint
+		webVitalTBT:  tbtName,  // total blocking time
 	}
 	webVitals := make(map[string]*k6metrics.Metric)
 
@@ -59,12 +156,41 @@ func RegisterCustomMetrics(registry *k6metrics.Registry) *CustomMetrics {
 		webVitals[k] = registry.MustNewMetric(v, k6metrics.Trend, t)
 	}
 
+	rms := map[string]runtimeMetric{
+		"JSHeapUsedSize":      {jsHeapUsedName, k6metrics.Data},
+		"JSHeapTotalSize":     {jsHeapTotalName, k6metrics.Data},
+		"Nodes":               {domNodesName, k6metrics.Default},
+		"Documents":           {documentsName, k6metrics.Default},
+		"Frames":              {framesName, k6metrics.Default},
+		"JSEventListeners":    {jsEventListenersName, k6metrics.Default},
+		"LayoutCount":         {layoutCountName, k6metrics.Default},
+		"RecalcStyleCount":    {recalcStyleCountName, k6metrics.Default},
+		"LayoutDuration":      {layoutDurationName, k6metrics.Time},
+		"RecalcStyleDuration": {recalcStyleDurationName, k6metrics.Time},
+		"ScriptDuration":      {scriptDurationName, k6metrics.Time},
+		"TaskDuration":        {taskDurationName, k6metrics.Time},
+	}
+	runtimeMetrics := make(map[string]*k6metrics.Metric)
+
+	for k, v := range rms {
+		runtimeMetrics[k] = registry.MustNewMetric(v.name, k6metrics.Trend, v.valueType)
+	}
+
 	//nolint:lll
 	return &CustomMetrics{
 		WebVitals:              webVitals,
+		LongTaskDuration:       registry.MustNewMetric(longTaskDurationName, k6metrics.Trend, k6metrics.Time),
+		LongTaskCount:          registry.MustNewMetric(longTaskCountName, k6metrics.Counter),
+		RuntimeMetrics:         runtimeMetrics,
+		SpeedIndex:             registry.MustNewMetric(speedIndexName, k6metrics.Trend, k6metrics.Time),
+		FirstVisualChange:      registry.MustNewMetric(firstVisualChangeName, k6metrics.Trend, k6metrics.Time),
+		LastVisualChange:       registry.MustNewMetric(lastVisualChangeName, k6metrics.Trend, k6metrics.Time),
 		BrowserDataSent:        registry.MustNewMetric(browserDataSentName, k6metrics.Counter, k6metrics.Data),
 		BrowserDataReceived:    registry.MustNewMetric(browserDataReceivedName, k6metrics.Counter, k6metrics.Data),
 		BrowserHTTPReqDuration: registry.MustNewMetric(browserHTTPReqDurationName, k6metrics.Trend, k6metrics.Time),
 		BrowserHTTPReqFailed:   registry.MustNewMetric(browserHTTPReqFailedName, k6metrics.Rate),
+		BrowserJSErrors:        registry.MustNewMetric(browserJSErrorsName, k6metrics.Counter),
+		BrowserVisualDiffRatio: registry.MustNewMetric(browserVisualDiffRatioName, k6metrics.Trend),
+		BrowserA11yViolations:  registry.MustNewMetric(browserA11yViolationsName, k6metrics.Counter),
 	}
 }
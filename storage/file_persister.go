@@ -51,6 +51,11 @@ func (l *LocalFilePersister) Persist(_ context.Context, path string, data io.Rea
 	return nil
 }
 
+// ReadFile reads the file on the specified path from the local disk.
+func (l *LocalFilePersister) ReadFile(_ context.Context, path string) ([]byte, error) {
+	return os.ReadFile(filepath.Clean(path)) //nolint:wrapcheck
+}
+
 // RemoteFilePersister is to be used when files created by the browser module need
 // to be uploaded to a remote location. This uses a preSignedURLGetterURL to
 // retrieve one pre-signed URL. The pre-signed url is used to upload the file
@@ -1,15 +1,21 @@
 package tests
 
 import (
+	"bytes"
+	"context"
 	_ "embed"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	k6metrics "go.k6.io/k6/metrics"
 
 	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/storage"
 )
 
 func TestBrowserContextOptionsDefaultValues(t *testing.T) {
@@ -30,10 +36,13 @@ func TestBrowserContextOptionsDefaultValues(t *testing.T) {
 	assert.Equal(t, common.DefaultLocale, opts.Locale)
 	assert.False(t, opts.Offline)
 	assert.Empty(t, opts.Permissions)
+	assert.Nil(t, opts.RecordHAR)
 	assert.Equal(t, common.ReducedMotionNoPreference, opts.ReducedMotion)
+	assert.False(t, opts.RuntimeMetrics)
 	assert.Equal(t, &common.Screen{Width: common.DefaultScreenWidth, Height: common.DefaultScreenHeight}, opts.Screen)
 	assert.Equal(t, "", opts.TimezoneID)
 	assert.Equal(t, "", opts.UserAgent)
+	assert.Nil(t, opts.UserMetrics)
 	assert.Equal(t, &common.Viewport{Width: common.DefaultScreenWidth, Height: common.DefaultScreenHeight}, opts.Viewport)
 }
 
@@ -120,3 +129,165 @@ func TestBrowserContextOptionsExtraHTTPHeaders(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestBrowserContextOptionsRecordHAR(t *testing.T) {
+	t.Parallel()
+
+	harPath := filepath.Join(t.TempDir(), "requests.har")
+
+	tb := newTestBrowser(t, withHTTPServer())
+	bctx, err := tb.NewContext(tb.toSobekValue(struct {
+		RecordHAR map[string]any `js:"recordHar"`
+	}{
+		RecordHAR: map[string]any{
+			"path":      harPath,
+			"urlFilter": "**/get",
+		},
+	}))
+	require.NoError(t, err)
+	p, err := bctx.NewPage()
+	require.NoError(t, err)
+
+	opts := &common.FrameGotoOptions{
+		Timeout: common.DefaultTimeout,
+	}
+	_, err = p.Goto(tb.url("/get"), opts)
+	require.NoError(t, err)
+	require.NoError(t, bctx.Close())
+
+	b, err := os.ReadFile(harPath) //nolint:gosec
+	require.NoError(t, err)
+
+	var har common.HAR
+	require.NoError(t, json.Unmarshal(b, &har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	require.Len(t, har.Log.Pages, 1)
+	require.Len(t, har.Log.Entries, 1)
+
+	entry := har.Log.Entries[0]
+	assert.Equal(t, har.Log.Pages[0].ID, entry.PageRef)
+	assert.Equal(t, "GET", entry.Request.Method)
+	assert.Equal(t, tb.url("/get"), entry.Request.URL)
+	assert.Equal(t, int64(200), entry.Response.Status)
+	assert.Contains(t, entry.Response.Content.Text, `"headers"`)
+}
+
+func TestBrowserContextOptionsRecordVideo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	tb := newTestBrowser(t)
+	bctx, err := tb.NewContext(tb.toSobekValue(struct {
+		RecordVideo map[string]any `js:"recordVideo"`
+	}{
+		RecordVideo: map[string]any{
+			"dir":  dir,
+			"size": map[string]any{"width": 320, "height": 240},
+		},
+	}))
+	require.NoError(t, err)
+	p, err := bctx.NewPage()
+	require.NoError(t, err)
+
+	video := p.Video()
+	require.NotNil(t, video)
+	assert.Equal(t, dir, filepath.Dir(video.Path()))
+
+	err = p.SetContent(`<h1 style="background: red">Recording</h1>`, nil)
+	require.NoError(t, err)
+	p.WaitForTimeout(200)
+	require.NoError(t, p.Close(nil))
+
+	saveAs := filepath.Join(t.TempDir(), "video.gif")
+	require.NoError(t, video.SaveAs(saveAs, &storage.LocalFilePersister{}))
+	b, err := os.ReadFile(saveAs) //nolint:gosec
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(b, []byte("GIF89a")), "expected an animated GIF")
+}
+
+func TestBrowserContextOptionsRuntimeMetrics(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	bctx, err := tb.NewContext(tb.toSobekValue(struct {
+		RuntimeMetrics bool `js:"runtimeMetrics"`
+	}{
+		RuntimeMetrics: true,
+	}))
+	require.NoError(t, err)
+	p, err := bctx.NewPage()
+	require.NoError(t, err)
+
+	err = p.SetContent(`<h1>Runtime metrics</h1>`, nil)
+	require.NoError(t, err)
+
+	// The metrics of a page are sampled when it's closed, as it's no
+	// longer sampled at the end of the iteration.
+	require.NoError(t, p.Close(nil))
+
+	got := make(map[string]bool)
+	tb.vu.AssertSamples(func(s k6metrics.Sample) {
+		got[s.Metric.Name] = true
+	})
+	for _, name := range []string{
+		"browser_js_heap_used", "browser_dom_nodes", "browser_layout_count", "browser_task_duration",
+	} {
+		assert.True(t, got[name], "expected a %s sample", name)
+	}
+}
+
+func TestBrowserContextOptionsUserMetrics(t *testing.T) {
+	t.Parallel()
+
+	var (
+		samples  = make(chan k6metrics.SampleContainer)
+		tb       = newTestBrowser(t, withSamples(samples))
+		expected = map[string]float64{
+			"browser_user_timing_checkout_render": 0,
+			"browser_page_cart_items":             0,
+		}
+	)
+	bctx, err := tb.NewContext(tb.toSobekValue(struct {
+		UserMetrics bool `js:"userMetrics"`
+	}{
+		UserMetrics: true,
+	}))
+	require.NoError(t, err)
+	p, err := bctx.NewPage()
+	require.NoError(t, err)
+
+	_, err = p.Evaluate(`() => {
+		performance.mark("checkout-start");
+		performance.measure("checkout-render", "checkout-start");
+		window.k6.counter("cart_items", 3, { step: "checkout", scenario: "other" });
+	}`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(tb.context(), 5*time.Second)
+	defer cancel()
+	for seen := 0; seen < len(expected); {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("expected %v to have been emitted", expected)
+		case sc := <-samples:
+			for _, s := range sc.GetSamples() {
+				if _, ok := expected[s.Metric.Name]; !ok {
+					continue
+				}
+				if s.Metric.Name == "browser_page_cart_items" {
+					assert.Equal(t, k6metrics.Counter, s.Metric.Type)
+					v, _ := s.Tags.Get("step")
+					assert.Equal(t, "checkout", v)
+					// The page can't override the system tags.
+					v, _ = s.Tags.Get("scenario")
+					assert.NotEqual(t, "other", v)
+				}
+				expected[s.Metric.Name] = s.Value
+				seen++
+			}
+		}
+	}
+	assert.GreaterOrEqual(t, expected["browser_user_timing_checkout_render"], 0.0)
+	assert.Equal(t, 3.0, expected["browser_page_cart_items"])
+}
@@ -1,12 +1,17 @@
 package tests
 
 import (
+	"archive/zip"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +20,7 @@ import (
 
 	"github.com/grafana/xk6-browser/common"
 	"github.com/grafana/xk6-browser/env"
+	"github.com/grafana/xk6-browser/storage"
 )
 
 func TestBrowserContextAddCookies(t *testing.T) {
@@ -977,3 +983,75 @@ func TestBrowserContextClearPermissions(t *testing.T) {
 		require.False(t, hasPermission(tb, p, "geolocation"))
 	})
 }
+
+func TestBrowserContextTracing(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/tracing", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body>
+			<button onclick="console.log('clicked')">Click</button>
+		</body></html>`)
+	})
+	p := tb.NewPage(nil)
+	tracing := p.Context().Tracing()
+
+	opts := common.NewTracingStartOptions()
+	opts.Screenshots = true
+	opts.Snapshots = true
+	require.NoError(t, tracing.Start(opts))
+	assert.ErrorContains(t, tracing.Start(opts), "already started")
+
+	gotoOpts := &common.FrameGotoOptions{
+		Timeout:   common.DefaultTimeout,
+		WaitUntil: common.LifecycleEventLoad,
+	}
+	_, err := p.Goto(tb.url("/tracing"), gotoOpts)
+	require.NoError(t, err)
+	l := p.Locator("button", nil)
+	require.NoError(t, l.Click(common.NewFrameClickOptions(l.Timeout())))
+	_, err = p.Metrics()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "trace.zip")
+	require.NoError(t, tracing.Stop(&common.TracingStopOptions{Path: path}, &storage.LocalFilePersister{}))
+	assert.ErrorContains(t, tracing.Stop(common.NewTracingStopOptions(), nil), "not started")
+
+	zr, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer zr.Close() //nolint:errcheck
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[f.Name] = string(data)
+	}
+	require.Contains(t, files, "trace.trace")
+	require.Contains(t, files, "trace.network")
+
+	types := make(map[string]int)
+	var apiNames []string
+	for _, line := range strings.Split(strings.TrimSpace(files["trace.trace"]), "\n") {
+		var event struct {
+			Type    string `json:"type"`
+			APIName string `json:"apiName"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		types[event.Type]++
+		if event.Type == "before" {
+			apiNames = append(apiNames, event.APIName)
+		}
+	}
+	assert.Equal(t, []string{"page.goto", "locator.click", "page.metrics"}, apiNames)
+	assert.Equal(t, 1, types["context-options"])
+	assert.Equal(t, 3, types["after"])
+	// page.metrics doesn't change the page, so it has no snapshots.
+	assert.Equal(t, 4, types["frame-snapshot"])
+	assert.Equal(t, 4, types["screencast-frame"])
+	assert.Equal(t, 1, types["console"])
+	assert.Contains(t, files["trace.network"], `"type":"resource-snapshot"`)
+}
@@ -2,7 +2,10 @@ package tests
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -20,6 +23,7 @@ import (
 	"github.com/grafana/xk6-browser/env"
 	"github.com/grafana/xk6-browser/k6ext"
 	"github.com/grafana/xk6-browser/k6ext/k6test"
+	"github.com/grafana/xk6-browser/storage"
 )
 
 func TestBrowserNewPage(t *testing.T) {
@@ -406,3 +410,93 @@ func TestIsolateBrowserContexts(t *testing.T) {
 	assert.Equalf(t, 1, bctx1PagesLen, "browser context #1 should be attached to a single page, but got %d", bctx1PagesLen)
 	assert.Equalf(t, 1, bctx2PagesLen, "browser context #2 should be attached to a single page, but got %d", bctx2PagesLen)
 }
+
+func TestBrowserTracing(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/tracing", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body><h1>Tracing</h1></body></html>`)
+	})
+	tb.withHandler("/layout_shift", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body>
+			<div style="height: 600px">Layout shift</div>
+			<script>
+				requestAnimationFrame(() => requestAnimationFrame(() => {
+					const d = document.createElement("div");
+					d.style.height = "400px";
+					document.body.prepend(d);
+					requestAnimationFrame(() => requestAnimationFrame(() => {
+						document.body.dataset.shifted = "true";
+					}));
+				}));
+			</script>
+		</body></html>`)
+	})
+	p := tb.NewPage(nil)
+	gotoOpts := &common.FrameGotoOptions{
+		Timeout:   common.DefaultTimeout,
+		WaitUntil: common.LifecycleEventLoad,
+	}
+
+	t.Run("save", func(t *testing.T) { //nolint:paralleltest
+		opts := common.NewTracingOptions()
+		opts.Path = filepath.Join(t.TempDir(), "trace.json")
+		opts.Screenshots = true
+		require.NoError(t, tb.StartTracing(p, opts))
+		assert.ErrorContains(t, tb.StartTracing(nil, opts), "already started")
+
+		_, err := p.Goto(tb.url("/tracing"), gotoOpts)
+		require.NoError(t, err)
+
+		buf, err := tb.StopTracing(&storage.LocalFilePersister{})
+		require.NoError(t, err)
+
+		var trace struct {
+			TraceEvents []json.RawMessage `json:"traceEvents"`
+		}
+		require.NoError(t, json.Unmarshal(buf, &trace))
+		assert.NotEmpty(t, trace.TraceEvents)
+
+		saved, err := os.ReadFile(opts.Path)
+		require.NoError(t, err)
+		assert.Equal(t, buf, saved)
+	})
+
+	t.Run("discard", func(t *testing.T) { //nolint:paralleltest
+		opts := common.NewTracingOptions()
+		opts.Path = filepath.Join(t.TempDir(), "trace.json")
+		opts.OnlyOnPoorWebVitals = true
+		require.NoError(t, tb.StartTracing(nil, opts))
+
+		_, err := p.Goto(tb.url("/tracing"), gotoOpts)
+		require.NoError(t, err)
+
+		buf, err := tb.StopTracing(&storage.LocalFilePersister{})
+		require.NoError(t, err)
+		assert.Nil(t, buf)
+		assert.NoFileExists(t, opts.Path)
+
+		_, err = tb.StopTracing(&storage.LocalFilePersister{})
+		assert.ErrorContains(t, err, "not started")
+	})
+
+	t.Run("keep_on_poor_cls", func(t *testing.T) { //nolint:paralleltest
+		opts := common.NewTracingOptions()
+		opts.Path = filepath.Join(t.TempDir(), "trace.json")
+		opts.OnlyOnPoorWebVitals = true
+		require.NoError(t, tb.StartTracing(p, opts))
+
+		_, err := p.Goto(tb.url("/layout_shift"), gotoOpts)
+		require.NoError(t, err)
+		_, err = p.WaitForSelector("body[data-shifted]", nil)
+		require.NoError(t, err)
+
+		// CLS is only reported when the page is hidden, so it must be
+		// reported when the tracing stops while the page is open.
+		buf, err := tb.StopTracing(&storage.LocalFilePersister{})
+		require.NoError(t, err)
+		assert.NotNil(t, buf)
+		assert.FileExists(t, opts.Path)
+	})
+}
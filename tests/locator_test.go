@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/storage"
 )
 
 // Strict mode:
@@ -635,6 +636,36 @@ func TestLocatorPress(t *testing.T) {
 	require.Equal(t, "AbC", v)
 }
 
+func TestLocatorCompareScreenshot(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	err := p.SetContent(`<button id="btn" style="width: 80px; height: 30px">Buy</button>`, nil)
+	require.NoError(t, err)
+
+	opts := common.NewCompareScreenshotOptions(common.DefaultTimeout)
+	opts.BaselineDir = t.TempDir()
+	sp := &storage.LocalFilePersister{}
+	l := p.Locator("#btn", nil)
+
+	res, err := l.CompareScreenshot("button", opts, sp)
+	require.NoError(t, err)
+	assert.True(t, res.Updated)
+
+	_, err = p.Evaluate(`() => document.querySelector('#btn').textContent = 'Sold out'`)
+	require.NoError(t, err)
+	res, err = l.CompareScreenshot("button", opts, sp)
+	require.NoError(t, err)
+	assert.False(t, res.Passed)
+	assert.Positive(t, res.DiffPixels)
+	assert.FileExists(t, res.DiffPath)
+
+	_, err = p.Locator("button, #btn, body", nil).CompareScreenshot("button", opts, sp)
+	assert.ErrorContains(t, err, "strict mode violation")
+}
+
 func TestLocatorShadowDOM(t *testing.T) {
 	t.Parallel()
 
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
@@ -10,7 +11,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +23,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/xk6-browser/common"
+	"github.com/grafana/xk6-browser/storage"
+
+	k6metrics "go.k6.io/k6/metrics"
 )
 
 type emulateMediaOpts struct {
@@ -609,6 +616,87 @@ func TestPageIsChecked(t *testing.T) {
 	assert.False(t, checked, "expected checkbox to be unchecked")
 }
 
+func TestPagePDF(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	err := p.SetContent(`<h1>Invoice</h1>`, nil)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "invoice.pdf")
+	opts := common.NewPagePDFOptions()
+	opts.Path = path
+	buf, err := p.PDF(opts, &storage.LocalFilePersister{})
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(buf, []byte("%PDF")), "expected a PDF document")
+
+	saved, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, buf, saved)
+}
+
+func TestPageCompareScreenshot(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	err := p.SetContent(`
+		<style>@keyframes spin { to { transform: rotate(360deg) } }</style>
+		<h1 style="animation: spin 1s infinite">Title</h1>
+		<div id="clock">00:00:00</div>
+	`, nil)
+	require.NoError(t, err)
+
+	opts := common.NewCompareScreenshotOptions(common.DefaultTimeout)
+	opts.BaselineDir = t.TempDir()
+	opts.Mask = []string{"#clock"}
+	sp := &storage.LocalFilePersister{}
+
+	res, err := p.CompareScreenshot("page", opts, sp)
+	require.NoError(t, err)
+	assert.True(t, res.Updated, "the missing baseline should be created")
+	assert.FileExists(t, res.BaselinePath)
+
+	// The masked clock and the disabled animation should not change the
+	// screenshot.
+	_, err = p.Evaluate(`() => document.querySelector('#clock').textContent = '12:34:56'`)
+	require.NoError(t, err)
+	res, err = p.CompareScreenshot("page", opts, sp)
+	require.NoError(t, err)
+	assert.True(t, res.Passed)
+	assert.False(t, res.Updated)
+	assert.Zero(t, res.DiffPixels)
+
+	_, err = p.Evaluate(`() => document.body.style.background = 'red'`)
+	require.NoError(t, err)
+	res, err = p.CompareScreenshot("page", opts, sp)
+	require.NoError(t, err)
+	assert.False(t, res.Passed)
+	assert.Greater(t, res.DiffRatio, 0.5)
+	assert.FileExists(t, res.ActualPath)
+	assert.FileExists(t, res.DiffPath)
+
+	// The elements that prepare the page for the screenshot are removed.
+	n, err := p.Evaluate(`() => document.querySelectorAll('[data-k6-compare-screenshot]').length`)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+
+	var ratios int
+	tb.vu.AssertSamples(func(s k6metrics.Sample) {
+		if s.Metric.Name != "browser_visual_diff_ratio" {
+			return
+		}
+		ratios++
+		v, ok := s.Tags.Get("screenshot")
+		assert.True(t, ok)
+		assert.Equal(t, "page", v)
+	})
+	assert.Equal(t, 3, ratios)
+}
+
 func TestPageScreenshotFullpage(t *testing.T) {
 	t.Parallel()
 
@@ -1225,14 +1313,14 @@ func TestPageOn(t *testing.T) {
 			)
 
 			// Console Messages should be multiplexed for every registered handler
-			eventHandlerOne := func(cm *common.ConsoleMessage) {
+			eventHandlerOne := func(event common.PageOnEvent) {
 				defer close(done1)
-				tc.assertFn(t, cm)
+				tc.assertFn(t, event.ConsoleMessage)
 			}
 
-			eventHandlerTwo := func(cm *common.ConsoleMessage) {
+			eventHandlerTwo := func(event common.PageOnEvent) {
 				defer close(done2)
-				tc.assertFn(t, cm)
+				tc.assertFn(t, event.ConsoleMessage)
 			}
 
 			// eventHandlerOne and eventHandlerTwo will be called from a
@@ -1850,3 +1938,833 @@ func TestPageGetAttributeEmpty(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, "", got)
 }
+
+func TestPageRoute(t *testing.T) {
+	t.Parallel()
+
+	gotoOpts := &common.FrameGotoOptions{
+		Timeout: common.DefaultTimeout,
+	}
+
+	t.Run("fulfill", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t, withHTTPServer())
+		p := tb.NewPage(nil)
+
+		m, err := common.NewGlobURLMatcher("**/get")
+		require.NoError(t, err)
+		err = p.Route(m, func(r *common.Route) {
+			opts := common.NewRouteFulfillOptions()
+			opts.Status = 201
+			opts.ContentType = "text/plain"
+			opts.Body = []byte("fulfilled")
+			assert.NoError(t, r.Fulfill(opts))
+		})
+		require.NoError(t, err)
+
+		resp, err := p.Goto(tb.url("/get"), gotoOpts)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, int64(201), resp.Status())
+
+		body, err := resp.Body()
+		require.NoError(t, err)
+		assert.Equal(t, "fulfilled", string(body))
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t, withHTTPServer())
+		p := tb.NewPage(nil)
+
+		m, err := common.NewGlobURLMatcher("**/get")
+		require.NoError(t, err)
+		err = p.Route(m, func(r *common.Route) {
+			opts := common.NewRouteContinueOptions()
+			opts.Headers = map[string]string{"Some-Header": "Some-Value"}
+			assert.NoError(t, r.Continue(opts))
+		})
+		require.NoError(t, err)
+
+		resp, err := p.Goto(tb.url("/get"), gotoOpts)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+
+		responseBody, err := resp.Body()
+		require.NoError(t, err)
+
+		var body struct{ Headers map[string][]string }
+		require.NoError(t, json.Unmarshal(responseBody, &body))
+		h := body.Headers["Some-Header"]
+		require.NotEmpty(t, h)
+		assert.Equal(t, "Some-Value", h[0])
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		t.Parallel()
+
+		tb := newTestBrowser(t, withHTTPServer())
+		p := tb.NewPage(nil)
+
+		m, err := common.NewGlobURLMatcher("**/get")
+		require.NoError(t, err)
+		err = p.Route(m, func(r *common.Route) {
+			assert.NoError(t, r.Abort("blockedbyclient"))
+		})
+		require.NoError(t, err)
+
+		_, err = p.Goto(tb.url("/get"), gotoOpts)
+		require.ErrorContains(t, err, "net::ERR_BLOCKED_BY_CLIENT")
+
+		require.NoError(t, p.Unroute(m))
+		resp, err := p.Goto(tb.url("/get"), gotoOpts)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, int64(200), resp.Status())
+	})
+}
+
+func TestPageRouteFromHAR(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	p := tb.NewPage(nil)
+
+	har := common.HAR{
+		Log: common.HARLog{
+			Version: "1.2",
+			Entries: []*common.HAREntry{
+				{
+					Request: common.HARRequest{Method: "GET", URL: tb.url("/get")},
+					Response: common.HARResponse{
+						Status:  200,
+						Headers: []common.HARNameValue{{Name: "Content-Type", Value: "text/plain"}},
+						Content: common.HARContent{Text: "from HAR"},
+					},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(har)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "test.har")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	require.NoError(t, p.RouteFromHAR(path, common.NewRouteFromHAROptions()))
+
+	opts := &common.FrameGotoOptions{
+		Timeout: common.DefaultTimeout,
+	}
+	resp, err := p.Goto(tb.url("/get"), opts)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, int64(200), resp.Status())
+
+	body, err := resp.Body()
+	require.NoError(t, err)
+	assert.Equal(t, "from HAR", string(body))
+
+	// Requests that aren't in the HAR are aborted by default.
+	_, err = p.Goto(tb.url("/status/200"), opts)
+	require.Error(t, err)
+}
+
+func TestPageOnNetworkEvents(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	p := tb.NewPage(nil)
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(event string) common.PageOnHandler {
+		return func(e common.PageOnEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			url := ""
+			if e.Request != nil {
+				url = e.Request.URL()
+			}
+			if e.Response != nil {
+				url = e.Response.URL()
+			}
+			if url == tb.url("/get") {
+				events = append(events, event)
+			}
+		}
+	}
+	for _, event := range []string{
+		common.EventPageRequest,
+		common.EventPageResponse,
+		common.EventPageRequestFinished,
+		common.EventPageRequestFailed,
+	} {
+		require.NoError(t, p.On(event, record(event)))
+	}
+
+	opts := &common.FrameGotoOptions{
+		Timeout: common.DefaultTimeout,
+	}
+	_, err := p.Goto(tb.url("/get"), opts)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == 3
+	}, 5*time.Second, 50*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		common.EventPageRequest,
+		common.EventPageResponse,
+		common.EventPageRequestFinished,
+	}, events)
+
+	assert.ErrorContains(t, p.On("unknown", func(common.PageOnEvent) {}), `unknown page event: "unknown"`)
+}
+
+func TestPageOnDialog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, fn, wantType, wantMessage, wantDefault string
+		accept                                       bool
+		want                                         any
+	}{
+		{
+			name: "accept_prompt", fn: `() => prompt('name?', 'k6')`,
+			wantType: "prompt", wantMessage: "name?", wantDefault: "k6",
+			accept: true, want: "grafana",
+		},
+		{
+			name: "dismiss_prompt", fn: `() => prompt('name?')`,
+			wantType: "prompt", wantMessage: "name?",
+			accept: false, want: nil,
+		},
+		{
+			name: "accept_confirm", fn: `() => confirm('sure?')`,
+			wantType: "confirm", wantMessage: "sure?",
+			accept: true, want: true,
+		},
+		{
+			name: "dismiss_confirm", fn: `() => confirm('sure?')`,
+			wantType: "confirm", wantMessage: "sure?",
+			accept: false, want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newTestBrowser(t).NewPage(nil)
+
+			err := p.On(common.EventPageDialog, func(e common.PageOnEvent) {
+				d := e.Dialog
+				assert.Equal(t, tt.wantType, d.Type())
+				assert.Equal(t, tt.wantMessage, d.Message())
+				assert.Equal(t, tt.wantDefault, d.DefaultValue())
+				if tt.accept {
+					assert.NoError(t, d.Accept("grafana"))
+				} else {
+					assert.NoError(t, d.Dismiss())
+				}
+			})
+			require.NoError(t, err)
+
+			got, err := p.Evaluate(tt.fn)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("not_handled", func(t *testing.T) {
+		t.Parallel()
+
+		p := newTestBrowser(t).NewPage(nil)
+		p.SetDefaultTimeout(500)
+
+		// The dialog is dismissed by default if the handler doesn't handle it.
+		err := p.On(common.EventPageDialog, func(common.PageOnEvent) {})
+		require.NoError(t, err)
+
+		got, err := p.Evaluate(`() => confirm('sure?')`)
+		require.NoError(t, err)
+		assert.Equal(t, false, got)
+	})
+}
+
+func TestPageOnDownload(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/download", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="report.txt"`)
+		_, _ = w.Write([]byte("k6 report"))
+	})
+
+	bctx, err := tb.NewContext(tb.toSobekValue(struct {
+		AcceptDownloads bool `js:"acceptDownloads"`
+	}{
+		AcceptDownloads: true,
+	}))
+	require.NoError(t, err)
+	p, err := bctx.NewPage()
+	require.NoError(t, err)
+
+	downloads := make(chan *common.Download, 1)
+	err = p.On(common.EventPageDownload, func(e common.PageOnEvent) {
+		downloads <- e.Download
+	})
+	require.NoError(t, err)
+
+	html := fmt.Sprintf(`<a href="%s">download</a>`, tb.url("/download"))
+	require.NoError(t, p.SetContent(html, nil))
+
+	type result struct {
+		e   common.PageOnEvent
+		err error
+	}
+	waited := make(chan result, 1)
+	go func() {
+		e, err := p.WaitForEvent(common.EventPageDownload, nil, common.DefaultTimeout)
+		waited <- result{e, err}
+	}()
+	// Let WaitForEvent subscribe to the event before the download begins.
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, p.Click("a", common.NewFrameClickOptions(p.Timeout())))
+
+	var d *common.Download
+	select {
+	case d = <-downloads:
+	case <-time.After(common.DefaultTimeout):
+		require.FailNow(t, "timed out waiting for the download event")
+	}
+	r := <-waited
+	require.NoError(t, r.err)
+	assert.Same(t, d, r.e.Download)
+
+	assert.Equal(t, tb.url("/download"), d.URL())
+	assert.Equal(t, "report.txt", d.SuggestedFilename())
+	assert.Same(t, p, d.Page())
+
+	failure, err := d.Failure()
+	require.NoError(t, err)
+	assert.Empty(t, failure)
+
+	path, err := d.Path()
+	require.NoError(t, err)
+	b, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, "k6 report", string(b))
+
+	saveAs := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, d.SaveAs(saveAs, &storage.LocalFilePersister{}))
+	b, err = os.ReadFile(saveAs) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, "k6 report", string(b))
+}
+
+func TestPageOnFileChooser(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	fileChoosers := make(chan *common.FileChooser, 1)
+	err := p.On(common.EventPageFilechooser, func(e common.PageOnEvent) {
+		fileChoosers <- e.FileChooser
+	})
+	require.NoError(t, err)
+
+	const html = `
+		<input type="file" id="upload" style="display: none" multiple>
+		<button onclick="document.getElementById('upload').click()">upload</button>
+	`
+	require.NoError(t, p.SetContent(html, nil))
+	require.NoError(t, p.Click("button", common.NewFrameClickOptions(p.Timeout())))
+
+	var fc *common.FileChooser
+	select {
+	case fc = <-fileChoosers:
+	case <-time.After(common.DefaultTimeout):
+		require.FailNow(t, "timed out waiting for the filechooser event")
+	}
+	assert.True(t, fc.IsMultiple())
+	assert.Same(t, p, fc.Page())
+
+	id, ok, err := fc.Element().GetAttribute("id")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "upload", id)
+
+	file := map[string]any{"name": "test.json", "mimetype": "text/json", "buffer": "MDEyMzQ1Njc4OQ=="}
+	require.NoError(t, fc.SetFiles(tb.toSobekValue(file), tb.toSobekValue(nil)))
+
+	got, err := p.Evaluate(`() => document.getElementById('upload').files[0].name`)
+	require.NoError(t, err)
+	assert.Equal(t, "test.json", got)
+}
+
+func TestPageOnPopup(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	popups := make(chan *common.Page, 1)
+	err := p.On(common.EventPagePopup, func(e common.PageOnEvent) {
+		popups <- e.Page
+	})
+	require.NoError(t, err)
+
+	pages := make(chan *common.Page, 1)
+	err = p.Context().On(common.EventBrowserContextPage, func(p *common.Page) {
+		pages <- p
+	})
+	require.NoError(t, err)
+
+	type result struct {
+		e   common.PageOnEvent
+		err error
+	}
+	waited := make(chan result, 1)
+	go func() {
+		e, err := p.WaitForEvent(common.EventPagePopup, nil, common.DefaultTimeout)
+		waited <- result{e, err}
+	}()
+	// Let WaitForEvent subscribe to the event before the popup opens.
+	time.Sleep(100 * time.Millisecond)
+	_, err = p.Evaluate(`() => { window.open('about:blank') }`)
+	require.NoError(t, err)
+
+	var popup *common.Page
+	select {
+	case popup = <-popups:
+	case <-time.After(common.DefaultTimeout):
+		require.FailNow(t, "timed out waiting for the popup event")
+	}
+	assert.Same(t, p, popup.Opener())
+	assert.Same(t, popup, <-pages)
+
+	r := <-waited
+	require.NoError(t, r.err)
+	assert.Same(t, popup, r.e.Page)
+
+	got, err := popup.Evaluate(`() => 1 + 1`)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), got)
+}
+
+func TestPageOnPageError(t *testing.T) {
+	t.Parallel()
+
+	samples := make(chan k6metrics.SampleContainer)
+	tb := newTestBrowser(t, withSamples(samples))
+	p := tb.NewPage(nil)
+
+	jsErrors := make(chan float64, 1)
+	ctx, cancel := context.WithTimeout(tb.context(), common.DefaultTimeout)
+	defer cancel()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sc := <-samples:
+				for _, s := range sc.GetSamples() {
+					if s.Metric.Name == "browser_js_errors" {
+						jsErrors <- s.Value
+					}
+				}
+			}
+		}
+	}()
+
+	pageErrors := make(chan *common.PageError, 1)
+	err := p.On(common.EventPageError, func(e common.PageOnEvent) {
+		pageErrors <- e.PageError
+	})
+	require.NoError(t, err)
+
+	_, err = p.Evaluate(`() => { setTimeout(() => { throw new TypeError('boom') }) }`)
+	require.NoError(t, err)
+
+	select {
+	case pe := <-pageErrors:
+		assert.Equal(t, "TypeError", pe.Name)
+		assert.Equal(t, "boom", pe.Message)
+		assert.Contains(t, pe.Stack, "TypeError: boom")
+	case <-ctx.Done():
+		require.FailNow(t, "timed out waiting for the pageerror event")
+	}
+	select {
+	case v := <-jsErrors:
+		assert.Equal(t, float64(1), v)
+	case <-ctx.Done():
+		require.FailNow(t, "timed out waiting for the browser_js_errors metric")
+	}
+}
+
+func TestPageExposeFunction(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	p := tb.NewPage(nil)
+
+	err := p.ExposeBinding("double", func(_ *common.BindingSource, args []any) (any, error) {
+		n, ok := args[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected argument %v", args[0])
+		}
+		return n * 2, nil
+	})
+	require.NoError(t, err)
+
+	got, err := p.Evaluate(`() => double(21)`)
+	require.NoError(t, err)
+	assert.Equal(t, float64(42), got)
+
+	_, err = p.Evaluate(`() => double('a')`)
+	assert.ErrorContains(t, err, "unexpected argument a")
+
+	// The function should survive navigations.
+	opts := &common.FrameGotoOptions{
+		Timeout: common.DefaultTimeout,
+	}
+	_, err = p.Goto(tb.url("/get"), opts)
+	require.NoError(t, err)
+	got, err = p.Evaluate(`() => double(2)`)
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), got)
+
+	err = p.ExposeBinding("double", func(*common.BindingSource, []any) (any, error) { return nil, nil })
+	assert.ErrorContains(t, err, `function "double" has been already registered`)
+}
+
+func TestBrowserContextExposeBinding(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	sources := make(chan *common.BindingSource, 1)
+	err := p.Context().ExposeBinding("whoami", func(src *common.BindingSource, _ []any) (any, error) {
+		sources <- src
+		return src.Frame.URL(), nil
+	})
+	require.NoError(t, err)
+
+	got, err := p.Evaluate(`() => whoami()`)
+	require.NoError(t, err)
+	assert.Equal(t, common.BlankPage, got)
+	source := <-sources
+	assert.Same(t, p, source.Page)
+	assert.Same(t, p.Context(), source.Context)
+
+	// New pages of the browser context should also get the binding.
+	p2, err := p.Context().NewPage()
+	require.NoError(t, err)
+	got, err = p2.Evaluate(`() => typeof whoami`)
+	require.NoError(t, err)
+	assert.Equal(t, "function", got)
+
+	err = p.ExposeBinding("whoami", func(*common.BindingSource, []any) (any, error) { return nil, nil })
+	assert.ErrorContains(t, err, "has been already registered in the browser context")
+}
+
+func TestPageAddScriptAndStyleTag(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/polyfill.js", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = w.Write([]byte(`window.fromURL = true;`))
+	})
+	tb.withHandler("/style.css", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		_, _ = w.Write([]byte(`body { margin: 7px; }`))
+	})
+	p := tb.NewPage(nil)
+	opts := &common.FrameGotoOptions{
+		Timeout: common.DefaultTimeout,
+	}
+	_, err := p.Goto(tb.url("/get"), opts)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "flags.js")
+	require.NoError(t, os.WriteFile(path, []byte(`window.fromPath = true;`), 0o600))
+
+	for _, opts := range []*common.FrameAddScriptTagOptions{
+		{URL: tb.url("/polyfill.js")},
+		{Path: path},
+		{Content: `window.fromContent = true;`},
+	} {
+		eh, err := p.AddScriptTag(opts)
+		require.NoError(t, err)
+		tag, err := eh.Evaluate(`e => e.tagName`)
+		require.NoError(t, err)
+		assert.Equal(t, "SCRIPT", tag)
+	}
+	got, err := p.Evaluate(`() => [window.fromURL, window.fromPath, window.fromContent]`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{true, true, true}, got)
+
+	_, err = p.AddScriptTag(&common.FrameAddScriptTagOptions{URL: tb.url("/missing.js")})
+	assert.ErrorContains(t, err, "failed to load script")
+
+	eh, err := p.AddStyleTag(&common.FrameAddStyleTagOptions{URL: tb.url("/style.css")})
+	require.NoError(t, err)
+	tag, err := eh.Evaluate(`e => e.tagName`)
+	require.NoError(t, err)
+	assert.Equal(t, "LINK", tag)
+	got, err = p.Evaluate(`() => getComputedStyle(document.body).margin`)
+	require.NoError(t, err)
+	assert.Equal(t, "7px", got)
+
+	_, err = p.AddStyleTag(&common.FrameAddStyleTagOptions{Content: `body { margin: 3px; }`})
+	require.NoError(t, err)
+	got, err = p.Evaluate(`() => getComputedStyle(document.body).margin`)
+	require.NoError(t, err)
+	assert.Equal(t, "3px", got)
+}
+
+func TestPageGotoVisualMetrics(t *testing.T) {
+	t.Parallel()
+
+	samples := make(chan k6metrics.SampleContainer)
+	tb := newTestBrowser(t, withHTTPServer(), withSamples(samples))
+	tb.withHandler("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<body><script>
+			setTimeout(() => { document.body.style.background = 'red' }, 100);
+		</script></body>`)
+	})
+	dir := t.TempDir()
+	bctx, err := tb.NewContext(tb.toSobekValue(struct {
+		VisualMetrics map[string]any `js:"visualMetrics"`
+	}{
+		VisualMetrics: map[string]any{"filmstripDir": dir},
+	}))
+	require.NoError(t, err)
+	p, err := bctx.NewPage()
+	require.NoError(t, err)
+
+	metrics := make(chan string, 3)
+	ctx, cancel := context.WithTimeout(tb.context(), common.DefaultTimeout)
+	defer cancel()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sc := <-samples:
+				for _, s := range sc.GetSamples() {
+					switch s.Metric.Name {
+					case "browser_speed_index", "browser_first_visual_change", "browser_last_visual_change":
+						metrics <- s.Metric.Name
+					}
+				}
+			}
+		}
+	}()
+
+	opts := &common.FrameGotoOptions{
+		Timeout:   common.DefaultTimeout,
+		WaitUntil: common.LifecycleEventNetworkIdle,
+	}
+	_, err = p.Goto(tb.url("/slow"), opts)
+	require.NoError(t, err)
+
+	got := make(map[string]bool)
+	for len(got) < 3 {
+		select {
+		case name := <-metrics:
+			got[name] = true
+		case <-ctx.Done():
+			require.FailNow(t, "timed out waiting for the visual metrics", "got: %v", got)
+		}
+	}
+
+	// The frames of each navigation are persisted to their own directory.
+	frames, err := filepath.Glob(filepath.Join(dir, p.TargetID(), "1", "*.jpg"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, frames)
+}
+
+func TestPageAccessibilitySnapshot(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	err := p.SetContent(`
+		<main>
+			<h1>Products</h1>
+			<div><button id="buy">Buy</button></div>
+			<label>Email <input type="email"></label>
+		</main>
+	`, nil)
+	require.NoError(t, err)
+
+	root, err := p.GetAccessibility().Snapshot(common.NewAccessibilitySnapshotOptions())
+	require.NoError(t, err)
+	require.NotNil(t, root)
+	assert.Equal(t, "RootWebArea", root.Role)
+
+	var got []string
+	var walk func(n *common.AccessibilityNode)
+	walk = func(n *common.AccessibilityNode) {
+		got = append(got, n.Role+":"+n.Name)
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+	assert.Contains(t, got, "heading:Products")
+	assert.Contains(t, got, "button:Buy")
+	assert.Contains(t, got, "textbox:Email")
+	assert.NotContains(t, got, "generic:", "uninteresting nodes should be left out")
+
+	opts := common.NewAccessibilitySnapshotOptions()
+	opts.Root = "#buy"
+	button, err := p.GetAccessibility().Snapshot(opts)
+	require.NoError(t, err)
+	require.NotNil(t, button)
+	assert.Equal(t, "button", button.Role)
+	assert.Equal(t, "Buy", button.Name)
+}
+
+func TestPageCheckAccessibility(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	err := p.SetContent(`
+		<img id="logo" src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+		<input id="email" type="email">
+		<p id="faint" style="color: #ccc; background: #fff">Faint text</p>
+		<a id="skip" href="#" tabindex="3">Skip</a>
+	`, nil)
+	require.NoError(t, err)
+
+	violations, err := p.CheckAccessibility(common.NewPageCheckAccessibilityOptions())
+	require.NoError(t, err)
+
+	got := make(map[common.AccessibilityRule]string)
+	for _, v := range violations {
+		got[v.Rule] = v.Element
+	}
+	assert.Equal(t, map[common.AccessibilityRule]string{
+		common.AccessibilityRuleImageAlt:      "img#logo",
+		common.AccessibilityRuleLabel:         "input#email",
+		common.AccessibilityRuleColorContrast: "p#faint",
+		common.AccessibilityRuleLandmark:      "",
+		common.AccessibilityRuleTabIndex:      "a#skip",
+	}, got)
+
+	counts := make(map[string]float64)
+	tb.vu.AssertSamples(func(s k6metrics.Sample) {
+		if s.Metric.Name != "browser_a11y_violations" {
+			return
+		}
+		rule, ok := s.Tags.Get("rule")
+		require.True(t, ok)
+		counts[rule] += s.Value
+	})
+	assert.Equal(t, map[string]float64{
+		"image-alt":      1,
+		"label":          1,
+		"color-contrast": 1,
+		"landmark":       1,
+		"tabindex":       1,
+	}, counts)
+}
+
+func TestPageCoverage(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t, withHTTPServer())
+	tb.withHandler("/coverage", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head>
+			<style>h1 { color: red; } .unused { color: blue; }</style>
+		</head><body>
+			<h1>Coverage</h1>
+			<script>
+				function used() { return 1; }
+				function unused() { return 2; }
+				used();
+			</script>
+		</body></html>`)
+	})
+	p := tb.NewPage(nil)
+	c := p.GetCoverage()
+
+	require.NoError(t, c.StartJSCoverage(common.NewJSCoverageOptions()))
+	require.NoError(t, c.StartCSSCoverage(common.NewCSSCoverageOptions()))
+	assert.ErrorContains(t, c.StartJSCoverage(common.NewJSCoverageOptions()), "already started")
+
+	opts := &common.FrameGotoOptions{
+		Timeout:   common.DefaultTimeout,
+		WaitUntil: common.LifecycleEventLoad,
+	}
+	_, err := p.Goto(tb.url("/coverage"), opts)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	sp := &storage.LocalFilePersister{}
+	stopOpts := common.NewStopCoverageOptions()
+	stopOpts.Path = filepath.Join(dir, "js.json")
+	stopOpts.Format = common.CoverageFormatIstanbul
+	js, err := c.StopJSCoverage(stopOpts, sp)
+	require.NoError(t, err)
+	require.Len(t, js, 1)
+	assert.Equal(t, tb.url("/coverage"), js[0].URL)
+	require.NotEmpty(t, js[0].Ranges)
+
+	var used []string
+	for _, r := range js[0].Ranges {
+		used = append(used, js[0].Text[r.Start:r.End])
+	}
+	assert.Contains(t, strings.Join(used, ""), "return 1")
+	assert.NotContains(t, strings.Join(used, ""), "return 2")
+	assert.FileExists(t, stopOpts.Path)
+
+	css, err := c.StopCSSCoverage(common.NewStopCoverageOptions(), sp)
+	require.NoError(t, err)
+	require.Len(t, css, 1)
+	require.Len(t, css[0].Ranges, 1)
+	r := css[0].Ranges[0]
+	assert.Equal(t, "h1 { color: red; }", css[0].Text[r.Start:r.End])
+
+	_, err = c.StopCSSCoverage(common.NewStopCoverageOptions(), sp)
+	assert.ErrorContains(t, err, "not started")
+}
+
+func TestPageMetrics(t *testing.T) {
+	t.Parallel()
+
+	tb := newTestBrowser(t)
+	p := tb.NewPage(nil)
+
+	err := p.SetContent(`<div><p>one</p><p>two</p></div>`, nil)
+	require.NoError(t, err)
+
+	m, err := p.Metrics()
+	require.NoError(t, err)
+	for _, name := range []string{
+		"JSHeapUsedSize", "Nodes", "LayoutCount", "RecalcStyleCount", "ScriptDuration", "TaskDuration",
+	} {
+		assert.Contains(t, m, name)
+	}
+	assert.Greater(t, m["JSHeapUsedSize"], 0.0)
+	assert.GreaterOrEqual(t, m["Nodes"], 4.0)
+}
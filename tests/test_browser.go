@@ -111,9 +111,10 @@ func newTestBrowserVU(tb testing.TB, tbr *testBrowser) (_ *k6test.VU, cancel fun
 	mi, ok := k6http.New().NewModuleInstance(vu).(*k6http.ModuleInstance)
 	require.Truef(tb, ok, "want *k6http.ModuleInstance; got %T", mi)
 	require.NoError(tb, vu.Runtime().Set("http", mi.Exports().Default))
-	metricsCtx := k6ext.WithCustomMetrics(
-		vu.Context(),
-		k6ext.RegisterCustomMetrics(k6metrics.NewRegistry()),
+	registry := k6metrics.NewRegistry()
+	metricsCtx := k6ext.WithUserMetrics(
+		k6ext.WithCustomMetrics(vu.Context(), k6ext.RegisterCustomMetrics(registry)),
+		k6ext.NewUserMetrics(registry),
 	)
 	ctx, cancel := context.WithCancel(metricsCtx)
 	tb.Cleanup(cancel)
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -105,6 +106,7 @@ func TestTracing(t *testing.T) {
 			spans: []string{
 				"page.goto",
 				"navigation",
+				"request",
 			},
 		},
 		{
@@ -238,3 +240,108 @@ func (m *mockTracer) verifySpans(spanNames ...string) error {
 
 	return nil
 }
+
+// TestTracingTraceContext verifies that the trace context of the active
+// span is propagated to the requests of the configured origins, including
+// the requests that are continued by a route handler.
+func TestTracingTraceContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		route string
+	}{
+		{
+			name: "not_routed",
+		},
+		{
+			name:  "routed",
+			route: `await page.route('**', (route) => route.continue({ headers: { 'x-test': '1' } }));`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tp := &mockTracerProvider{
+				tracer: &spanContextTracer{},
+			}
+
+			var (
+				mu      sync.Mutex
+				headers []http.Header
+			)
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					mu.Lock()
+					headers = append(headers, r.Header.Clone())
+					mu.Unlock()
+					fmt.Fprint(w, html)
+				},
+			))
+			defer ts.Close()
+
+			vu := k6test.NewVU(t, k6test.WithTracerProvider(tp))
+
+			rt := vu.Runtime()
+			root := browser.New()
+			mod := root.NewModuleInstance(vu)
+			jsMod, ok := mod.Exports().Default.(*browser.JSModule)
+			require.Truef(t, ok, "unexpected default mod export type %T", mod.Exports().Default)
+			require.NoError(t, rt.Set("browser", jsMod.Browser))
+			vu.ActivateVU()
+
+			vu.StartIteration(t)
+			setupTestTracing(t, rt)
+
+			assertJSInEventLoop(t, vu, fmt.Sprintf(`
+				page = await browser.newPage({
+					traceContext: { origins: ['%s'], baggage: { tenant: 'k6' } },
+				});
+				%s
+				await page.goto('%s');
+			`, ts.URL, tt.route, ts.URL))
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.NotEmpty(t, headers)
+			tp0 := headers[0].Get("traceparent")
+			require.Regexp(t, `^00-0{31}1-[0-9a-f]{16}-01$`, tp0)
+			require.Equal(t, "tenant=k6", headers[0].Get("baggage"))
+			if tt.route != "" {
+				require.Equal(t, "1", headers[0].Get("x-test"))
+			}
+		})
+	}
+}
+
+// spanContextTracer creates sampled spans with unique span contexts in
+// the same trace.
+type spanContextTracer struct {
+	embedded.Tracer
+
+	lastID atomic.Uint64
+}
+
+func (m *spanContextTracer) Start(
+	ctx context.Context, _ string, _ ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	id := m.lastID.Add(1)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{15: 1},
+		SpanID:     trace.SpanID{0: 1, 7: byte(id)},
+		TraceFlags: trace.FlagsSampled,
+	})
+	span := spanContextSpan{Span: browsertrace.NoopSpan{}, sc: sc}
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type spanContextSpan struct {
+	trace.Span
+
+	sc trace.SpanContext
+}
+
+func (s spanContextSpan) SpanContext() trace.SpanContext { return s.sc }
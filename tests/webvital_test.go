@@ -2,6 +2,8 @@ package tests
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -145,3 +147,81 @@ func TestWebVitalMetricNoInteraction(t *testing.T) {
 		assert.True(t, v, "expected %s to have been measured and emitted", k)
 	}
 }
+
+func TestWebVitalMetricLongTasks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		samples  = make(chan k6metrics.SampleContainer)
+		browser  = newTestBrowser(t, withHTTPServer(), withSamples(samples))
+		expected = map[string]bool{
+			"browser_long_task_duration": false,
+			"browser_long_task_count":    false,
+			"browser_web_vital_tbt":      false,
+		}
+		tbt   float64
+		types = make(map[string]bool)
+	)
+	browser.withHandler("/long_tasks", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body>
+			<h1>Long tasks</h1>
+			<script>
+				requestAnimationFrame(() => setTimeout(() => {
+					const start = Date.now();
+					while (Date.now() - start < 200) {}
+					document.body.dataset.blocked = "true";
+				}, 0));
+			</script>
+		</body></html>`)
+	})
+
+	done := make(chan struct{})
+	ctx, cancel := context.WithTimeout(browser.context(), 5*time.Second)
+	defer cancel()
+	go func() {
+		for {
+			var metric k6metrics.SampleContainer
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case metric = <-samples:
+			}
+			samples := metric.GetSamples()
+			for _, s := range samples {
+				if _, ok := expected[s.Metric.Name]; ok {
+					expected[s.Metric.Name] = true
+				}
+				if s.Metric.Name == "browser_web_vital_tbt" {
+					tbt = s.Value
+				}
+				if s.Metric.Name == "browser_long_task_count" {
+					typ, _ := s.Tags.Get("type")
+					types[typ] = true
+				}
+			}
+		}
+	}()
+
+	page := browser.NewPage(nil)
+	opts := &common.FrameGotoOptions{
+		Timeout: common.DefaultTimeout,
+	}
+	_, err := page.Goto(browser.url("/long_tasks"), opts)
+	require.NoError(t, err)
+	_, err = page.WaitForSelector("body[data-blocked]", nil)
+	require.NoError(t, err)
+
+	// The TBT is reported when the page is hidden.
+	require.NoError(t, page.Close(nil))
+	done <- struct{}{}
+
+	for k, v := range expected {
+		assert.True(t, v, "expected %s to have been measured and emitted", k)
+	}
+	assert.Greater(t, tbt, 100.0)
+	// Either the long tasks or the long animation frames are reported,
+	// not both, so that the blocking isn't counted twice.
+	assert.Len(t, types, 1)
+}
@@ -0,0 +1,545 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	k6lib "go.k6.io/k6/lib"
+)
+
+// FileFormat is the format that the spans are written to a file in.
+type FileFormat string
+
+const (
+	// FileFormatOTLP is the OTLP JSON format, which is the JSON encoding
+	// of the spans that are sent to an OpenTelemetry collector.
+	FileFormatOTLP FileFormat = "otlp"
+
+	// FileFormatChrome is the Chrome trace event format, which can be
+	// opened in chrome://tracing or Perfetto.
+	FileFormatChrome FileFormat = "chrome"
+)
+
+// filePersister persists the file with the spans.
+type filePersister interface {
+	Persist(ctx context.Context, path string, data io.Reader) (err error)
+}
+
+// maxPendingSpans is the maximum number of spans that are kept in memory
+// until their traces end. The pending spans are encoded when there are
+// more of them, even though the traces that are encoded in parts are
+// split into more resource spans, or drawn on more threads in the Chrome
+// trace event format.
+const maxPendingSpans = 10000
+
+// FileExporter collects the spans of a test run and writes them to a
+// file, so that they can be inspected without a tracing backend.
+//
+// The spans of a trace are kept in memory until its root span ends, such
+// as the span of an iteration, or until there are more than
+// maxPendingSpans of them. They're then encoded into a temporary file,
+// and the file is written when the last of its users releases it, so
+// that the file has all the spans when the test run ends.
+type FileExporter struct {
+	path   string
+	format FileFormat
+	fp     filePersister
+	tp     *sdktrace.TracerProvider
+
+	mu      sync.Mutex
+	file    *os.File
+	written int // the number of the encoded elements in file
+	pending map[trace.TraceID][]sdktrace.ReadOnlySpan
+	npend   int                   // the number of the pending spans
+	pids    map[trace.TraceID]int // the Chrome processes of the traces
+	users   int
+}
+
+// NewFileExporter returns a new FileExporter that writes the spans to the
+// path in the format with the file persister.
+func NewFileExporter(path string, format FileFormat, fp filePersister) *FileExporter {
+	e := &FileExporter{
+		path:    path,
+		format:  format,
+		fp:      fp,
+		pending: make(map[trace.TraceID][]sdktrace.ReadOnlySpan),
+		pids:    make(map[trace.TraceID]int),
+	}
+	e.tp = sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(e),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "k6"))),
+	)
+
+	return e
+}
+
+// TracerProvider returns the TracerProvider whose spans are exported to
+// the file.
+func (e *FileExporter) TracerProvider() k6lib.TracerProvider {
+	return e.tp
+}
+
+// ExportSpans encodes the spans into the temporary file when their trace
+// ends.
+func (e *FileExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, s := range spans {
+		id := s.SpanContext().TraceID()
+		e.pending[id] = append(e.pending[id], s)
+		e.npend++
+		if !isRootSpan(s) {
+			continue
+		}
+		if err := e.flushTrace(id); err != nil {
+			return err
+		}
+	}
+	if e.npend > maxPendingSpans {
+		return e.flush()
+	}
+
+	return nil
+}
+
+// flushTrace encodes the pending spans of the trace into the file.
+func (e *FileExporter) flushTrace(id trace.TraceID) error {
+	spans := e.pending[id]
+	delete(e.pending, id)
+	e.npend -= len(spans)
+
+	if e.format != FileFormatChrome {
+		return writeJSON(e, newOTLPTraces(spans).ResourceSpans)
+	}
+
+	pid, ok := e.pids[id]
+	if !ok {
+		pid = len(e.pids) + 1
+		e.pids[id] = pid
+	}
+
+	return writeJSON(e, newChromeTraceEvents(spans, pid))
+}
+
+// flush encodes all the pending spans into the file.
+func (e *FileExporter) flush() error {
+	for id := range e.pending {
+		if err := e.flushTrace(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeJSON encodes the elements of the JSON array of the file, either
+// the OTLP resource spans or the Chrome trace events, into the temporary
+// file.
+func writeJSON[T any](e *FileExporter, elems []T) error {
+	if len(elems) == 0 {
+		return nil
+	}
+	if e.file == nil {
+		f, err := os.CreateTemp("", "k6browser-traces-*.json")
+		if err != nil {
+			return fmt.Errorf("creating temporary traces file: %w", err)
+		}
+		e.file = f
+	}
+
+	var buf bytes.Buffer
+	for _, el := range elems {
+		if e.written > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(el)
+		if err != nil {
+			return fmt.Errorf("encoding traces: %w", err)
+		}
+		buf.Write(b)
+		e.written++
+	}
+	if _, err := e.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("writing temporary traces file: %w", err)
+	}
+
+	return nil
+}
+
+// Shutdown implements the sdktrace.SpanExporter interface. The spans
+// are written when the exporter is released.
+func (e *FileExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// Acquire registers a user of the exporter, such as a VU.
+func (e *FileExporter) Acquire() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.users++
+}
+
+// Release unregisters a user of the exporter, and writes the spans to
+// the file if it was the last user.
+func (e *FileExporter) Release(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.users--; e.users > 0 {
+		return nil
+	}
+	defer e.removeFile()
+	if err := e.flush(); err != nil {
+		return err
+	}
+
+	head, tail := `{"resourceSpans":[`, `]}`
+	if e.format == FileFormatChrome {
+		head, tail = `{"traceEvents":[`, `],"displayTimeUnit":"ms"}`
+	}
+	data := []io.Reader{strings.NewReader(head)}
+	if e.file != nil {
+		if _, err := e.file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("reading temporary traces file: %w", err)
+		}
+		data = append(data, e.file)
+	}
+	data = append(data, strings.NewReader(tail))
+	if err := e.fp.Persist(ctx, e.path, io.MultiReader(data...)); err != nil {
+		return fmt.Errorf("persisting traces to %q: %w", e.path, err)
+	}
+
+	return nil
+}
+
+// removeFile removes the temporary file once it's written to the file,
+// so that the exporter starts over if it's acquired again.
+func (e *FileExporter) removeFile() {
+	if e.file == nil {
+		return
+	}
+	_ = e.file.Close()
+	_ = os.Remove(e.file.Name())
+	e.file = nil
+	e.written = 0
+}
+
+// isRootSpan returns true if the span is the root span of its trace in
+// the test run, such as the span of an iteration.
+func isRootSpan(s sdktrace.ReadOnlySpan) bool {
+	p := s.Parent()
+	return !p.IsValid() || p.IsRemote()
+}
+
+// The OTLP JSON types are the JSON encoding of the OTLP protobuf messages.
+// The IDs are hex encoded and the 64-bit integers are strings.
+type (
+	otlpTraces struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	otlpScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Events            []otlpEvent    `json:"events,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+
+	otlpEvent struct {
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Name         string         `json:"name"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+
+	otlpAnyValue struct {
+		StringValue *string         `json:"stringValue,omitempty"`
+		BoolValue   *bool           `json:"boolValue,omitempty"`
+		IntValue    *string         `json:"intValue,omitempty"`
+		DoubleValue *float64        `json:"doubleValue,omitempty"`
+		ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	}
+
+	otlpArrayValue struct {
+		Values []otlpAnyValue `json:"values"`
+	}
+)
+
+// The OTLP status codes are not the same as the OTel API ones.
+const (
+	otlpStatusCodeOK    = 1
+	otlpStatusCodeError = 2
+)
+
+func newOTLPTraces(spans []sdktrace.ReadOnlySpan) otlpTraces {
+	traces := otlpTraces{ResourceSpans: []otlpResourceSpans{}}
+	if len(spans) == 0 {
+		return traces
+	}
+
+	var (
+		scopes     []otlpScopeSpans
+		scopeIndex = make(map[string]int)
+	)
+	for _, s := range spans {
+		scope := s.InstrumentationScope()
+		i, ok := scopeIndex[scope.Name]
+		if !ok {
+			i = len(scopes)
+			scopeIndex[scope.Name] = i
+			scopes = append(scopes, otlpScopeSpans{
+				Scope: otlpScope{Name: scope.Name, Version: scope.Version},
+			})
+		}
+		scopes[i].Spans = append(scopes[i].Spans, newOTLPSpan(s))
+	}
+	traces.ResourceSpans = append(traces.ResourceSpans, otlpResourceSpans{
+		Resource:   otlpResource{Attributes: otlpAttributes(spans[0].Resource().Attributes())},
+		ScopeSpans: scopes,
+	})
+
+	return traces
+}
+
+func newOTLPSpan(s sdktrace.ReadOnlySpan) otlpSpan {
+	sc := s.SpanContext()
+	span := otlpSpan{
+		TraceID:           sc.TraceID().String(),
+		SpanID:            sc.SpanID().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()),
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime().UnixNano(), 10),
+		Attributes:        otlpAttributes(s.Attributes()),
+	}
+	if p := s.Parent(); p.IsValid() {
+		span.ParentSpanID = p.SpanID().String()
+	}
+	for _, e := range s.Events() {
+		span.Events = append(span.Events, otlpEvent{
+			TimeUnixNano: strconv.FormatInt(e.Time.UnixNano(), 10),
+			Name:         e.Name,
+			Attributes:   otlpAttributes(e.Attributes),
+		})
+	}
+	switch st := s.Status(); st.Code {
+	case codes.Ok:
+		span.Status = otlpStatus{Code: otlpStatusCodeOK}
+	case codes.Error:
+		span.Status = otlpStatus{Code: otlpStatusCodeError, Message: st.Description}
+	case codes.Unset:
+	}
+
+	return span
+}
+
+func otlpAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		kvs = append(kvs, otlpKeyValue{Key: string(a.Key), Value: otlpValue(a.Value)})
+	}
+
+	return kvs
+}
+
+func otlpValue(v attribute.Value) otlpAnyValue {
+	var av otlpAnyValue
+	switch v.Type() { //nolint:exhaustive
+	case attribute.BOOL:
+		b := v.AsBool()
+		av.BoolValue = &b
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		av.IntValue = &i
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		av.DoubleValue = &f
+	case attribute.BOOLSLICE, attribute.INT64SLICE, attribute.FLOAT64SLICE, attribute.STRINGSLICE:
+		av.ArrayValue = &otlpArrayValue{}
+		switch vs := v.AsInterface().(type) {
+		case []bool:
+			for _, b := range vs {
+				av.ArrayValue.Values = append(av.ArrayValue.Values, otlpValue(attribute.BoolValue(b)))
+			}
+		case []int64:
+			for _, i := range vs {
+				av.ArrayValue.Values = append(av.ArrayValue.Values, otlpValue(attribute.Int64Value(i)))
+			}
+		case []float64:
+			for _, f := range vs {
+				av.ArrayValue.Values = append(av.ArrayValue.Values, otlpValue(attribute.Float64Value(f)))
+			}
+		case []string:
+			for _, s := range vs {
+				av.ArrayValue.Values = append(av.ArrayValue.Values, otlpValue(attribute.StringValue(s)))
+			}
+		}
+	default:
+		s := v.Emit()
+		av.StringValue = &s
+	}
+
+	return av
+}
+
+// chromeTraceEvent is an event in the Chrome trace event format. The spans
+// of each trace are a process, and the spans are complete events on the
+// threads of the process, so that the nested spans are drawn below their
+// parents.
+type chromeTraceEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat,omitempty"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"`
+	Dur  float64        `json:"dur,omitempty"`
+	Pid  int            `json:"pid"`
+	Tid  int            `json:"tid"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// newChromeTraceEvents returns the events of the spans of a trace, which
+// is the process with the pid. The process is named after the root span
+// of the trace if it's one of the spans.
+func newChromeTraceEvents(spans []sdktrace.ReadOnlySpan, pid int) []chromeTraceEvent {
+	sorted := append([]sdktrace.ReadOnlySpan{}, spans...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, sj := sorted[i], sorted[j]
+		if !si.StartTime().Equal(sj.StartTime()) {
+			return si.StartTime().Before(sj.StartTime())
+		}
+		// The parents go before the children that start with them.
+		return si.EndTime().After(sj.EndTime())
+	})
+
+	events := make([]chromeTraceEvent, 0, len(sorted)+1)
+	for _, s := range sorted {
+		if !isRootSpan(s) {
+			continue
+		}
+		events = append(events, chromeTraceEvent{
+			Name: "process_name",
+			Ph:   "M",
+			Pid:  pid,
+			Args: map[string]any{"name": chromeProcessName(s)},
+		})
+		break
+	}
+
+	var lanes [][]sdktrace.ReadOnlySpan
+	for _, s := range sorted {
+		tid := chromeLane(&lanes, s) + 1
+		events = append(events, chromeTraceEvent{
+			Name: s.Name(),
+			Cat:  s.InstrumentationScope().Name,
+			Ph:   "X",
+			Ts:   float64(s.StartTime().UnixNano()) / 1e3,
+			Dur:  float64(s.EndTime().Sub(s.StartTime()).Nanoseconds()) / 1e3,
+			Pid:  pid,
+			Tid:  tid,
+			Args: chromeArgs(s),
+		})
+	}
+
+	return events
+}
+
+// chromeProcessName names the process of a trace after its root span,
+// which is the iteration for the traces of the iterations.
+func chromeProcessName(root sdktrace.ReadOnlySpan) string {
+	name := root.Name()
+	for _, a := range root.Attributes() {
+		switch a.Key {
+		case "test.iteration.number":
+			name += " " + a.Value.Emit()
+		case "test.vu":
+			name += " (VU " + a.Value.Emit() + ")"
+		}
+	}
+
+	return name
+}
+
+// chromeLane returns the lane, a thread, that the span can be drawn on.
+// The complete events on a thread must be nested, so the span is drawn
+// on the first lane whose open spans either ended before it starts or
+// contain it.
+func chromeLane(lanes *[][]sdktrace.ReadOnlySpan, s sdktrace.ReadOnlySpan) int {
+	for i, open := range *lanes {
+		for len(open) > 0 && !open[len(open)-1].EndTime().After(s.StartTime()) {
+			open = open[:len(open)-1]
+		}
+		if len(open) == 0 || !s.EndTime().After(open[len(open)-1].EndTime()) {
+			(*lanes)[i] = append(open, s)
+			return i
+		}
+		(*lanes)[i] = open
+	}
+	*lanes = append(*lanes, []sdktrace.ReadOnlySpan{s})
+
+	return len(*lanes) - 1
+}
+
+func chromeArgs(s sdktrace.ReadOnlySpan) map[string]any {
+	args := map[string]any{
+		"trace_id": s.SpanContext().TraceID().String(),
+		"span_id":  s.SpanContext().SpanID().String(),
+	}
+	for _, a := range s.Attributes() {
+		args[string(a.Key)] = a.Value.AsInterface()
+	}
+	if st := s.Status(); st.Code == codes.Error {
+		args["error"] = st.Description
+	}
+
+	return args
+}
@@ -0,0 +1,180 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// chromeTrace is the file that the exporter writes in the Chrome trace
+// event format.
+type chromeTrace struct {
+	TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+}
+
+type memoryPersister struct {
+	files map[string][]byte
+}
+
+func (p *memoryPersister) Persist(_ context.Context, path string, data io.Reader) error {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	p.files[path] = b
+
+	return nil
+}
+
+// recordSpans records an iteration with a navigation, and two requests
+// of the navigation that overlap.
+func recordSpans(t *testing.T, e *FileExporter) {
+	t.Helper()
+
+	var (
+		tracer = e.TracerProvider().Tracer("browser")
+		start  = time.Unix(1000, 0)
+		at     = func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	)
+	ctx, iter := tracer.Start(context.Background(), "iteration", trace.WithTimestamp(at(0)),
+		trace.WithAttributes(attribute.Int64("test.iteration.number", 3), attribute.Int64("test.vu", 1)))
+	ctx, nav := tracer.Start(ctx, "navigation", trace.WithTimestamp(at(10)))
+	_, req1 := tracer.Start(ctx, "request", trace.WithTimestamp(at(20)), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.url", "https://k6.io/"), attribute.Int("http.status_code", 200)))
+	_, req2 := tracer.Start(ctx, "request", trace.WithTimestamp(at(30)), trace.WithSpanKind(trace.SpanKindClient))
+	req2.SetStatus(codes.Error, "net::ERR_FAILED")
+	req1.End(trace.WithTimestamp(at(40)))
+	req2.End(trace.WithTimestamp(at(50)))
+	nav.End(trace.WithTimestamp(at(60)))
+	iter.End(trace.WithTimestamp(at(70)))
+}
+
+func TestFileExporterOTLP(t *testing.T) {
+	t.Parallel()
+
+	fp := &memoryPersister{files: make(map[string][]byte)}
+	e := NewFileExporter("traces.json", FileFormatOTLP, fp)
+	e.Acquire()
+	e.Acquire()
+	recordSpans(t, e)
+
+	require.NoError(t, e.Release(context.Background()))
+	assert.Empty(t, fp.files, "must not write the file while it has users")
+	require.NoError(t, e.Release(context.Background()))
+
+	var traces otlpTraces
+	require.NoError(t, json.Unmarshal(fp.files["traces.json"], &traces))
+	require.Len(t, traces.ResourceSpans, 1)
+	require.Len(t, traces.ResourceSpans[0].ScopeSpans, 1)
+	assert.Equal(t, "browser", traces.ResourceSpans[0].ScopeSpans[0].Scope.Name)
+
+	spans := make(map[string]otlpSpan)
+	for _, s := range traces.ResourceSpans[0].ScopeSpans[0].Spans {
+		spans[s.Name+s.StartTimeUnixNano] = s
+	}
+	require.Len(t, spans, 4)
+
+	var (
+		iter = spans["iteration1000000000000"]
+		nav  = spans["navigation1000010000000"]
+		req1 = spans["request1000020000000"]
+		req2 = spans["request1000030000000"]
+	)
+	assert.Empty(t, iter.ParentSpanID)
+	assert.Equal(t, iter.SpanID, nav.ParentSpanID)
+	assert.Equal(t, nav.SpanID, req1.ParentSpanID)
+	assert.Equal(t, iter.TraceID, req1.TraceID)
+	assert.Len(t, iter.TraceID, 32)
+	assert.Equal(t, "1000040000000", req1.EndTimeUnixNano)
+	assert.Equal(t, int(trace.SpanKindClient), req1.Kind)
+	assert.Equal(t, otlpStatus{Code: otlpStatusCodeError, Message: "net::ERR_FAILED"}, req2.Status)
+
+	require.Len(t, req1.Attributes, 2)
+	assert.Equal(t, "https://k6.io/", *req1.Attributes[0].Value.StringValue)
+	assert.Equal(t, "200", *req1.Attributes[1].Value.IntValue)
+}
+
+func TestFileExporterChrome(t *testing.T) {
+	t.Parallel()
+
+	fp := &memoryPersister{files: make(map[string][]byte)}
+	e := NewFileExporter("traces.json", FileFormatChrome, fp)
+	e.Acquire()
+	recordSpans(t, e)
+	require.NoError(t, e.Release(context.Background()))
+
+	var ct chromeTrace
+	require.NoError(t, json.Unmarshal(fp.files["traces.json"], &ct))
+	require.Len(t, ct.TraceEvents, 5)
+
+	meta := ct.TraceEvents[0]
+	assert.Equal(t, "M", meta.Ph)
+	assert.Equal(t, "iteration 3 (VU 1)", meta.Args["name"])
+
+	type event struct {
+		name    string
+		ts, dur float64
+		tid     int
+	}
+	var got []event
+	for _, ev := range ct.TraceEvents[1:] {
+		assert.Equal(t, "X", ev.Ph)
+		assert.Equal(t, meta.Pid, ev.Pid)
+		got = append(got, event{ev.Name, ev.Ts - 1e9, ev.Dur, ev.Tid})
+	}
+	// The overlapping requests can't be nested, so the second one is
+	// drawn on another thread.
+	assert.Equal(t, []event{
+		{"iteration", 0, 70000, 1},
+		{"navigation", 10000, 50000, 1},
+		{"request", 20000, 20000, 1},
+		{"request", 30000, 20000, 2},
+	}, got)
+	assert.Equal(t, "https://k6.io/", ct.TraceEvents[3].Args["http.url"])
+	assert.Equal(t, "net::ERR_FAILED", ct.TraceEvents[4].Args["error"])
+}
+
+func TestFileExporterPendingSpans(t *testing.T) {
+	t.Parallel()
+
+	fp := &memoryPersister{files: make(map[string][]byte)}
+	e := NewFileExporter("traces.json", FileFormatChrome, fp)
+	e.Acquire()
+
+	tracer := e.TracerProvider().Tracer("browser")
+	ctx, iter := tracer.Start(context.Background(), "iteration")
+	for i := 0; i <= maxPendingSpans; i++ {
+		_, span := tracer.Start(ctx, "request")
+		span.End()
+	}
+
+	// The spans of the iteration are encoded once there are too many
+	// of them, even though the iteration has not ended yet.
+	e.mu.Lock()
+	assert.Zero(t, e.npend)
+	require.NotNil(t, e.file)
+	tmp := e.file.Name()
+	e.mu.Unlock()
+
+	iter.End()
+	require.NoError(t, e.Release(context.Background()))
+
+	var ct chromeTrace
+	require.NoError(t, json.Unmarshal(fp.files["traces.json"], &ct))
+	assert.Len(t, ct.TraceEvents, maxPendingSpans+3)
+	assert.Equal(t, "ms", ct.DisplayTimeUnit)
+
+	_, err := os.Stat(tmp)
+	assert.ErrorIs(t, err, fs.ErrNotExist, "must remove the temporary file")
+}
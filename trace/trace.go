@@ -4,6 +4,7 @@ package trace
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -37,6 +38,22 @@ type Tracer struct {
 
 	liveSpansMu sync.RWMutex
 	liveSpans   map[string]*liveSpan
+	apiSpans    map[string][]*apiSpan
+}
+
+// apiSpan is the span of an API call that keeps track of whether it has
+// ended. The spans that are not sampled are never recording, so whether
+// a span is recording doesn't tell if it has ended.
+type apiSpan struct {
+	trace.Span
+
+	ended atomic.Bool
+}
+
+// End ends the span.
+func (s *apiSpan) End(options ...trace.SpanEndOption) {
+	s.ended.Store(true)
+	s.Span.End(options...)
 }
 
 // NewTracer creates a new Tracer from the given TracerProvider.
@@ -45,6 +62,7 @@ func NewTracer(tp k6lib.TracerProvider, metadata map[string]string, options ...t
 		Tracer:    tp.Tracer(tracerName, options...),
 		metadata:  buildMetadataAttributes(metadata),
 		liveSpans: make(map[string]*liveSpan),
+		apiSpans:  make(map[string][]*apiSpan),
 	}
 }
 
@@ -69,12 +87,44 @@ func (t *Tracer) TraceAPICall(
 
 	opts = append(opts, trace.WithAttributes(t.metadata...))
 
-	ls := t.liveSpans[targetID]
-	if ls == nil {
-		return t.Start(ctx, spanName, opts...)
+	if ls := t.liveSpans[targetID]; ls != nil {
+		ctx = ls.ctx
+	}
+	ctx, span := t.Start(ctx, spanName, opts...)
+
+	// Keep track of the API calls in progress so that the requests
+	// they cause can be associated with them.
+	as := &apiSpan{Span: span}
+	spans := t.apiSpans[targetID][:0]
+	for _, s := range t.apiSpans[targetID] {
+		if !s.ended.Load() {
+			spans = append(spans, s)
+		}
+	}
+	t.apiSpans[targetID] = append(spans, as)
+
+	return trace.ContextWithSpan(ctx, as), as
+}
+
+// ActiveSpanContext returns the span context of the span that is active
+// for the given targetID. It's the latest API call that has not ended
+// yet, or the liveSpan of the last navigation if there is none. It returns
+// an invalid span context if there is no active span.
+func (t *Tracer) ActiveSpanContext(targetID string) trace.SpanContext {
+	t.liveSpansMu.RLock()
+	defer t.liveSpansMu.RUnlock()
+
+	spans := t.apiSpans[targetID]
+	for i := len(spans) - 1; i >= 0; i-- {
+		if !spans[i].ended.Load() {
+			return spans[i].SpanContext()
+		}
+	}
+	if ls := t.liveSpans[targetID]; ls != nil {
+		return ls.span.SpanContext()
 	}
 
-	return t.Start(ls.ctx, spanName, opts...)
+	return trace.SpanContext{}
 }
 
 // TraceNavigation is only to be used when a frame has navigated.
@@ -110,6 +160,24 @@ func (t *Tracer) TraceNavigation(
 	return ls.ctx, ls.span
 }
 
+// TraceRequest creates a new span for a network request of the given targetID and
+// associates it with the current liveSpan, so that the requests of a navigation form
+// its waterfall. If there is not a liveSpan for the given targetID, the new span is
+// created based on the given context. It is the caller's responsibility to close the
+// generated span.
+func (t *Tracer) TraceRequest(
+	ctx context.Context, targetID string, opts ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	t.liveSpansMu.RLock()
+	defer t.liveSpansMu.RUnlock()
+
+	if ls := t.liveSpans[targetID]; ls != nil {
+		ctx = ls.ctx
+	}
+
+	return t.Start(ctx, "request", opts...)
+}
+
 // TraceEvent creates a new span representing the specified event and associates it with the current
 // liveSpan for the given targetID only if the spanID matches with the liveSpan.
 // It is the caller's responsibility to close the generated span.
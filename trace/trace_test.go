@@ -0,0 +1,72 @@
+package trace
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+func TestTracerActiveSpanContext(t *testing.T) {
+	t.Parallel()
+
+	const targetID = "target"
+
+	tracer := NewTracer(&testTracerProvider{}, nil)
+	assert.False(t, tracer.ActiveSpanContext(targetID).IsValid())
+
+	_, nav := tracer.TraceNavigation(context.Background(), targetID)
+	assert.Equal(t, nav.SpanContext(), tracer.ActiveSpanContext(targetID))
+
+	// The spans of the requests never become the active span.
+	_, req := tracer.TraceRequest(context.Background(), targetID)
+	assert.NotEqual(t, req.SpanContext(), tracer.ActiveSpanContext(targetID))
+
+	_, click := tracer.TraceAPICall(context.Background(), targetID, "page.click")
+	_, wait := tracer.TraceAPICall(context.Background(), targetID, "page.waitForNavigation")
+	assert.Equal(t, wait.SpanContext(), tracer.ActiveSpanContext(targetID))
+	assert.False(t, tracer.ActiveSpanContext("other").IsValid())
+
+	wait.End()
+	assert.Equal(t, click.SpanContext(), tracer.ActiveSpanContext(targetID))
+	click.End()
+	assert.Equal(t, nav.SpanContext(), tracer.ActiveSpanContext(targetID))
+}
+
+type testTracerProvider struct{}
+
+func (*testTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return &testTracer{}
+}
+
+// testTracer creates spans with unique span contexts that are never
+// recording, like the spans that are not sampled.
+type testTracer struct {
+	embedded.Tracer
+
+	lastID atomic.Uint64
+}
+
+func (t *testTracer) Start(
+	ctx context.Context, _ string, _ ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	id := t.lastID.Add(1)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{byte(id)},
+	})
+	span := testSpan{Span: NoopSpan{}, sc: sc}
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type testSpan struct {
+	trace.Span
+
+	sc trace.SpanContext
+}
+
+func (s testSpan) SpanContext() trace.SpanContext { return s.sc }